@@ -2,9 +2,11 @@ package api
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	db "simplebank/db/sqlc"
 	"simplebank/token"
+	"simplebank/util"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,6 +16,22 @@ type CreateAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"` // binding:currency is the custom Validator from validator.go
 }
 
+// accountResponse is an account with its amounts also formatted as decimals of its currency
+type accountResponse struct {
+	db.Account
+	BalanceFormatted        string `json:"balance_formatted"`
+	OverdraftLimitFormatted string `json:"overdraft_limit_formatted"`
+}
+
+// newAccountResponse converts a database account to an accountResponse
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:                 account,
+		BalanceFormatted:        util.FormatAmount(account.Balance, account.Currency),
+		OverdraftLimitFormatted: util.FormatAmount(account.OverdraftLimit, account.Currency),
+	}
+}
+
 // createAccount creates a new account for the authenticated user
 // @Summary Create a new account
 // @Description Create a new account for the authenticated user
@@ -21,8 +39,9 @@ type CreateAccountRequest struct {
 // @Accept json
 // @Produce json
 // @Param body body CreateAccountRequest true "Create account request body"
-// @Success 200 {object} Account "Account created successfully"
+// @Success 200 {object} accountResponse "Account created successfully"
 // @Failure 400 {object} gin.H "Invalid request body"
+// @Failure 403 {object} gin.H "Email not verified, with code email_not_verified"
 // @Failure 500 {object} gin.H "Internal server error"
 // @Security BearerAuth
 // @Router /accounts [post]
@@ -44,13 +63,13 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		Balance:  0,
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	result, err := server.store.CreateAccountTx(auditContext(ctx), db.CreateAccountTxParams{CreateAccountParams: arg})
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(result.Account))
 }
 
 // getAccountRequest represents the URI parameters for getting an account
@@ -65,7 +84,7 @@ type getAccountRequest struct {
 // @Accept json
 // @Produce json
 // @Param id path int true "Account ID"
-// @Success 200 {object} Account "Account details"
+// @Success 200 {object} accountResponse "Account details"
 // @Failure 400 {object} gin.H "Invalid URI parameter"
 // @Failure 404 {object} gin.H "Account not found"
 // @Failure 500 {object} gin.H "Internal server error"
@@ -94,13 +113,13 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	// get auth payload
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	if account.Owner != authPayload.Username {
+	if !canAccess(authPayload, account.Owner, util.PermAccountsReadAny) {
 		err := errors.New("account doesn't belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 // listAccountRequest represents the query parameters for listing accounts
@@ -117,7 +136,7 @@ type listAccountRequest struct {
 // @Produce json
 // @Param page_id query int true "Page number"
 // @Param page_size query int true "Page size"
-// @Success 200 {array} Account "List of accounts"
+// @Success 200 {array} accountResponse "List of accounts"
 // @Failure 400 {object} gin.H "Invalid query parameters"
 // @Failure 500 {object} gin.H "Internal server error"
 // @Security BearerAuth
@@ -144,5 +163,173 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, accounts)
+	rsp := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		rsp = append(rsp, newAccountResponse(account))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// errOverdraftBelowDebt is the answer to an overdraft limit that the current balance of the account already goes past
+var errOverdraftBelowDebt = errors.New("overdraft limit is below the current debt of the account")
+
+// updateOverdraftLimitRequest represents the request body for setting an account overdraft limit
+type updateOverdraftLimitRequest struct {
+	OverdraftLimit *int64 `json:"overdraft_limit" binding:"required,min=0"`
+}
+
+// updateOverdraftLimit sets how far below zero an account balance may go
+// @Summary Set account overdraft limit
+// @Description Set the overdraft limit of an account. It needs the accounts:update:any permission, granted to bankers.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param body body updateOverdraftLimitRequest true "Overdraft limit request body"
+// @Success 200 {object} accountResponse "Account with the new overdraft limit"
+// @Failure 400 {object} gin.H "Invalid request"
+// @Failure 403 {object} gin.H "Forbidden - Needs the accounts:update:any permission"
+// @Failure 404 {object} gin.H "Account not found"
+// @Failure 422 {object} gin.H "Unprocessable Entity - Limit below the current debt of the account"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /accounts/{id}/overdraft_limit [patch]
+func (server *Server) updateOverdraftLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req updateOverdraftLimitRequest
+
+	// validating the request from the URI.
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// validating the request from the body json.
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.UpdateAccountOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: *req.OverdraftLimit,
+	}
+
+	result, err := server.store.UpdateOverdraftLimitTx(auditContext(ctx), db.UpdateOverdraftLimitTxParams{UpdateAccountOverdraftLimitParams: arg})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		// balance + overdraft_limit >= 0 is checked by the accounts table
+		if db.ErrorCode(err) == db.CheckViolation {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(errOverdraftBelowDebt))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(result.Account))
+}
+
+// closeAccountRequest represents the optional request body for closing an account
+type closeAccountRequest struct {
+	SweepAccountID int64 `json:"sweep_account_id" binding:"omitempty,min=1"`
+}
+
+// closeAccountResponse is the closed account with the transfer of its remaining balance, if it had one
+type closeAccountResponse struct {
+	Account accountResponse     `json:"account"`
+	Sweep   *transferTxResponse `json:"sweep,omitempty"`
+}
+
+// closeAccount closes an account for good, it is kept for its entries and transfers
+// @Summary Close account
+// @Description Close an account. Its balance must be zero, unless a sweep account of the same owner in the same currency is given to receive the remainder.
+// @Description The authenticated user must own the account, bankers can close any account.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param body body closeAccountRequest false "Close account request body"
+// @Success 200 {object} closeAccountResponse "Closed account"
+// @Failure 400 {object} gin.H "Invalid request"
+// @Failure 401 {object} gin.H "Unauthorized - Account doesn't belong to the user"
+// @Failure 403 {object} gin.H "Forbidden - Email not verified"
+// @Failure 404 {object} gin.H "Account not found"
+// @Failure 409 {object} gin.H "Conflict - Account frozen or already closed"
+// @Failure 422 {object} gin.H "Unprocessable Entity - Balance not zero or invalid sweep account"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /accounts/{id}/close [post]
+func (server *Server) closeAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req closeAccountRequest
+
+	// validating the request from the URI.
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the body is optional, an account with a zero balance needs no sweep account
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.existingAccount(ctx, uri.ID)
+	if !valid {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if !canAccess(authPayload, account.Owner, util.PermAccountsCloseAny) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.ChangeAccountStatusTx(auditContext(ctx), db.ChangeAccountStatusTxParams{
+		AccountID:      account.ID,
+		Status:         util.AccountStatusClosed,
+		ChangedBy:      authPayload.Username,
+		SweepAccountID: req.SweepAccountID,
+	})
+	if err != nil {
+		accountStatusErrorResponse(ctx, err)
+		return
+	}
+
+	rsp := closeAccountResponse{
+		Account: newAccountResponse(result.Account),
+	}
+	if result.Sweep != nil {
+		sweep := newTransferTxResponse(*result.Sweep)
+		rsp.Sweep = &sweep
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// accountStatusErrorResponse maps the errors of an account status change to the HTTP status
+func accountStatusErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	// checked before ErrRecordNotFound, which it wraps when the sweep account doesn't exist
+	case errors.Is(err, db.ErrInvalidSweepAccount),
+		errors.Is(err, db.ErrAccountBalanceNotZero),
+		errors.Is(err, db.ErrAccountNotActive),
+		errors.Is(err, db.ErrInsufficientFunds):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case errors.Is(err, db.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, errorResponse(err))
+	case errors.Is(err, db.ErrInvalidStatusTransition):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
 }
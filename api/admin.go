@@ -0,0 +1,439 @@
+package api
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// backOfficeMiddleware keeps the /admin routes to the roles granted the back office, on top of the permission of each route
+func backOfficeMiddleware() gin.HandlerFunc {
+	return requirePermission(util.PermBackOfficeAccessAny)
+}
+
+// adminPageRequest represents the cursor pagination query parameters of the back office.
+// The cursor is the next_cursor of the previous page, empty for the first page.
+type adminPageRequest struct {
+	Cursor   string `form:"cursor"`
+	PageSize int32  `form:"page_size" binding:"required,min=5,max=100"`
+}
+
+// encodeCursor turns the key of the last row of a page into an opaque cursor
+func encodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeCursor returns the key of the last row of the previous page, empty without a cursor
+func decodeCursor(cursor string) (string, error) {
+	key, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", errInvalidCursor
+	}
+	return string(key), nil
+}
+
+// decodeIDCursor returns the ID of the last row of the previous page, 0 without a cursor
+func decodeIDCursor(cursor string) (int64, error) {
+	key, err := decodeCursor(cursor)
+	if err != nil || key == "" {
+		return 0, err
+	}
+
+	id, err := strconv.ParseInt(key, 10, 64)
+	if err != nil || id < 1 {
+		return 0, errInvalidCursor
+	}
+	return id, nil
+}
+
+// adminUserResponse is a user as seen by the back office
+type adminUserResponse struct {
+	userResponse
+	Role            string `json:"role"`
+	IsEmailVerified bool   `json:"is_email_verified"`
+	PendingEmail    string `json:"pending_email,omitempty"`
+	IsTotpEnabled   bool   `json:"is_totp_enabled"`
+}
+
+// newAdminUserResponse converts a database user to an adminUserResponse
+func newAdminUserResponse(user db.User) adminUserResponse {
+	return adminUserResponse{
+		userResponse:    newUserResponse(user),
+		Role:            user.Role,
+		IsEmailVerified: user.IsEmailVerified,
+		PendingEmail:    user.PendingEmail.String,
+		IsTotpEnabled:   user.IsTotpEnabled,
+	}
+}
+
+// searchUsersRequest represents the query parameters for searching users
+type searchUsersRequest struct {
+	adminPageRequest
+	Name            string `form:"name"`              // part of the full name or the username
+	Email           string `form:"email"`             // part of the email
+	IsEmailVerified *bool  `form:"is_email_verified"` // both when not set
+}
+
+// searchUsersResponse is a page of users, next_cursor is empty on the last page
+type searchUsersResponse struct {
+	Users      []adminUserResponse `json:"users"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// searchUsers searches the users for the back office
+// @Summary Search Users
+// @Description Search the users by name, email or email verification, sorted by username. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param name query string false "Part of the full name or the username"
+// @Param email query string false "Part of the email"
+// @Param is_email_verified query bool false "Email verification state"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int true "Page size"
+// @Success 200 {object} searchUsersResponse "Page of users"
+// @Failure 400 {object} gin.H "Bad Request - Invalid query or cursor"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and users:read:any permissions"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/users [get]
+func (server *Server) searchUsers(ctx *gin.Context) {
+	var req searchUsersRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	afterUsername, err := decodeCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.SearchUsersParams{
+		Name:          pgtype.Text{String: req.Name, Valid: req.Name != ""},
+		Email:         pgtype.Text{String: req.Email, Valid: req.Email != ""},
+		AfterUsername: afterUsername,
+		Limit:         req.PageSize + 1, // one more user tells whether there is a next page
+	}
+	if req.IsEmailVerified != nil {
+		arg.IsEmailVerified = pgtype.Bool{Bool: *req.IsEmailVerified, Valid: true}
+	}
+
+	users, err := server.store.SearchUsers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := searchUsersResponse{Users: []adminUserResponse{}}
+	if len(users) > int(req.PageSize) {
+		users = users[:req.PageSize]
+		rsp.NextCursor = encodeCursor(users[len(users)-1].Username)
+	}
+	for _, user := range users {
+		rsp.Users = append(rsp.Users, newAdminUserResponse(user))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// adminUserRequest represents the URI parameter of the back office routes about a user
+type adminUserRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// listUserAccountsResponse is a page of accounts, next_cursor is empty on the last page
+type listUserAccountsResponse struct {
+	Accounts   []accountResponse `json:"accounts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// listUserAccounts lists the accounts of any user for the back office
+// @Summary List User Accounts
+// @Description List the accounts of a user, sorted by ID. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param username path string true "Username"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int true "Page size"
+// @Success 200 {object} listUserAccountsResponse "Page of accounts"
+// @Failure 400 {object} gin.H "Bad Request - Invalid query or cursor"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and accounts:read:any permissions"
+// @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/users/{username}/accounts [get]
+func (server *Server) listUserAccounts(ctx *gin.Context) {
+	username, afterID, req, valid := server.bindAdminUserPage(ctx)
+	if !valid {
+		return
+	}
+
+	accounts, err := server.store.ListAccountsAfter(ctx, db.ListAccountsAfterParams{
+		Owner:   username,
+		AfterID: afterID,
+		Limit:   req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listUserAccountsResponse{Accounts: []accountResponse{}}
+	if len(accounts) > int(req.PageSize) {
+		accounts = accounts[:req.PageSize]
+		rsp.NextCursor = encodeCursor(strconv.FormatInt(accounts[len(accounts)-1].ID, 10))
+	}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, newAccountResponse(account))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// listUserTransfersResponse is a page of transfers, next_cursor is empty on the last page
+type listUserTransfersResponse struct {
+	Transfers  []transferResponse `json:"transfers"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// listUserTransfers lists the transfers from or to the accounts of any user for the back office
+// @Summary List User Transfers
+// @Description List the transfers from or to the accounts of a user, sorted by ID. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param username path string true "Username"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int true "Page size"
+// @Success 200 {object} listUserTransfersResponse "Page of transfers"
+// @Failure 400 {object} gin.H "Bad Request - Invalid query or cursor"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and transfers:read:any permissions"
+// @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/users/{username}/transfers [get]
+func (server *Server) listUserTransfers(ctx *gin.Context) {
+	username, afterID, req, valid := server.bindAdminUserPage(ctx)
+	if !valid {
+		return
+	}
+
+	transfers, err := server.store.ListUserTransfersAfter(ctx, db.ListUserTransfersAfterParams{
+		Owner:   username,
+		AfterID: afterID,
+		Limit:   req.PageSize + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listUserTransfersResponse{}
+	if len(transfers) > int(req.PageSize) {
+		transfers = transfers[:req.PageSize]
+		rsp.NextCursor = encodeCursor(strconv.FormatInt(transfers[len(transfers)-1].ID, 10))
+	}
+
+	rsp.Transfers, err = server.newTransferResponses(ctx, transfers)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// bindAdminUserPage binds the username, the page size and the ID cursor of a back office page about a user.
+// A bad request is answered with 400 and an unknown user with 404, ok is false once it has answered.
+func (server *Server) bindAdminUserPage(ctx *gin.Context) (string, int64, adminPageRequest, bool) {
+	var uri adminUserRequest
+	var req adminPageRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return "", 0, req, false
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return "", 0, req, false
+	}
+
+	afterID, err := decodeIDCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return "", 0, req, false
+	}
+
+	_, err = server.store.GetUser(ctx, uri.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return "", 0, req, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return "", 0, req, false
+	}
+
+	return uri.Username, afterID, req, true
+}
+
+// adminAccountRequest represents the URI parameter of the back office routes about an account
+type adminAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getAnyAccount gets any account for the back office
+// @Summary Get Any Account
+// @Description Get an account whoever owns it. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} accountResponse "Account"
+// @Failure 400 {object} gin.H "Bad Request - Invalid ID"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and accounts:read:any permissions"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/accounts/{id} [get]
+func (server *Server) getAnyAccount(ctx *gin.Context) {
+	var req adminAccountRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.existingAccount(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+// freezeAccount freezes an account for the back office
+// @Summary Freeze Account
+// @Description Stop every deposit, withdrawal and transfer from or to an account. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} accountResponse "Frozen account"
+// @Failure 400 {object} gin.H "Bad Request - Invalid ID"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and accounts:freeze:any permissions"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 409 {object} gin.H "Conflict - Account not active"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/accounts/{id}/freeze [post]
+func (server *Server) freezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, util.AccountStatusActive, util.AccountStatusFrozen)
+}
+
+// unfreezeAccount unfreezes an account for the back office
+// @Summary Unfreeze Account
+// @Description Let money move again from and to a frozen account. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} accountResponse "Active account"
+// @Failure 400 {object} gin.H "Bad Request - Invalid ID"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and accounts:freeze:any permissions"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 409 {object} gin.H "Conflict - Account not frozen"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/accounts/{id}/unfreeze [post]
+func (server *Server) unfreezeAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, util.AccountStatusFrozen, util.AccountStatusActive)
+}
+
+// reopenAccount reopens a closed account for the back office
+// @Summary Reopen Account
+// @Description Let money move again from and to a closed account. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {object} accountResponse "Active account"
+// @Failure 400 {object} gin.H "Bad Request - Invalid ID"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and accounts:reopen:any permissions"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 409 {object} gin.H "Conflict - Account not closed"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/accounts/{id}/reopen [post]
+func (server *Server) reopenAccount(ctx *gin.Context) {
+	server.changeAccountStatus(ctx, util.AccountStatusClosed, util.AccountStatusActive)
+}
+
+// changeAccountStatus moves the account of the URI from one status to another and records who did it.
+// The store refuses the transitions that aren't allowed, such as freezing a closed account, and unknown accounts.
+func (server *Server) changeAccountStatus(ctx *gin.Context, from string, to string) {
+	var req adminAccountRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// the store checks the current status once the account is locked, a concurrent change can't slip in between
+	result, err := server.store.ChangeAccountStatusTx(auditContext(ctx), db.ChangeAccountStatusTxParams{
+		AccountID:  req.ID,
+		Status:     to,
+		FromStatus: from,
+		ChangedBy:  authPayload.Username,
+	})
+	if err != nil {
+		accountStatusErrorResponse(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(result.Account))
+}
+
+// listAccountStatusChanges lists the status history of any account for the back office
+// @Summary List Account Status Changes
+// @Description List who froze, unfroze, closed or reopened an account and when, oldest first. Only the back office can do it.
+// @Tags admin
+// @Produce json
+// @Param id path int true "Account ID"
+// @Success 200 {array} db.AccountStatusChange "Status changes"
+// @Failure 400 {object} gin.H "Bad Request - Invalid ID"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and accounts:read:any permissions"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/accounts/{id}/status_changes [get]
+func (server *Server) listAccountStatusChanges(ctx *gin.Context) {
+	var req adminAccountRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, valid := server.existingAccount(ctx, req.ID)
+	if !valid {
+		return
+	}
+
+	changes, err := server.store.ListAccountStatusChanges(ctx, account.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, changes)
+}
@@ -0,0 +1,581 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchUsersAPI(t *testing.T) {
+	banker, _ := randomUser(t, util.BankerRole)
+	customer, _ := randomUser(t, util.DepositorRole)
+
+	users := make([]db.User, 6)
+	for i := range users {
+		users[i], _ = randomUser(t, util.DepositorRole)
+	}
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "FirstPage",
+			query: url.Values{"page_size": {"5"}, "name": {"jo"}, "is_email_verified": {"false"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Role, banker.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SearchUsersParams{
+					Name:            pgtype.Text{String: "jo", Valid: true},
+					IsEmailVerified: pgtype.Bool{Bool: false, Valid: true},
+					Limit:           6,
+				}
+
+				store.EXPECT().
+					SearchUsers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(users, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodeBody[searchUsersResponse](t, recorder.Body)
+				require.Len(t, rsp.Users, 5)
+				require.Equal(t, users[0].Username, rsp.Users[0].Username)
+				require.Equal(t, users[0].Role, rsp.Users[0].Role)
+				require.Equal(t, encodeCursor(users[4].Username), rsp.NextCursor)
+			},
+		},
+		{
+			name:  "LastPage",
+			query: url.Values{"page_size": {"5"}, "email": {"@example"}, "cursor": {encodeCursor(users[4].Username)}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.AuditorRole, "auditor", time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.SearchUsersParams{
+					Email:         pgtype.Text{String: "@example", Valid: true},
+					AfterUsername: users[4].Username,
+					Limit:         6,
+				}
+
+				store.EXPECT().
+					SearchUsers(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(users[5:], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodeBody[searchUsersResponse](t, recorder.Body)
+				require.Len(t, rsp.Users, 1)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:  "DepositorRole",
+			query: url.Values{"page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, customer.Role, customer.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "NoAuthorization",
+			query: url.Values{"page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: url.Values{"page_size": {"5"}, "cursor": {"not base64!"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Role, banker.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCursor)
+			},
+		},
+		{
+			name:  "InvalidPageSize",
+			query: url.Values{"page_size": {"1000"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Role, banker.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().SearchUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"page_size": {"5"}},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, banker.Role, banker.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					SearchUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/users?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListUserAccountsAPI(t *testing.T) {
+	banker, _ := randomUser(t, util.BankerRole)
+	customer, _ := randomUser(t, util.DepositorRole)
+
+	accounts := make([]db.Account, 6)
+	for i := range accounts {
+		accounts[i] = randomAccount(customer.Username)
+		accounts[i].ID = int64(i + 1)
+	}
+
+	testCases := []struct {
+		name          string
+		username      string
+		query         url.Values
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "FirstPage",
+			username: customer.Username,
+			query:    url.Values{"page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsAfterParams{
+					Owner: customer.Username,
+					Limit: 6,
+				}
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(customer.Username)).Times(1).Return(customer, nil)
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodeBody[listUserAccountsResponse](t, recorder.Body)
+				require.Len(t, rsp.Accounts, 5)
+				require.Equal(t, accounts[0], rsp.Accounts[0].Account)
+				require.Equal(t, encodeCursor(strconv.FormatInt(accounts[4].ID, 10)), rsp.NextCursor)
+			},
+		},
+		{
+			name:     "NextPage",
+			username: customer.Username,
+			query:    url.Values{"page_size": {"5"}, "cursor": {encodeCursor("5")}},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAccountsAfterParams{
+					Owner:   customer.Username,
+					AfterID: 5,
+					Limit:   6,
+				}
+
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(customer.Username)).Times(1).Return(customer, nil)
+				store.EXPECT().
+					ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(accounts[5:], nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodeBody[listUserAccountsResponse](t, recorder.Body)
+				require.Len(t, rsp.Accounts, 1)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:     "NonNumericCursor",
+			username: customer.Username,
+			query:    url.Values{"page_size": {"5"}, "cursor": {encodeCursor("abc")}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: customer.Username,
+			query:    url.Values{"page_size": {"5"}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(customer.Username)).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/users/%s/accounts?%s", tc.username, tc.query.Encode())
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, banker.Role, banker.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestListUserTransfersAPI(t *testing.T) {
+	customer, _ := randomUser(t, util.DepositorRole)
+	account := randomAccount(customer.Username)
+	account.Currency = util.USD
+	other := randomAccount("other")
+	other.ID = account.ID + 1
+	other.Currency = util.JPY
+
+	transfers := []db.Transfer{
+		{ID: 7, FromAccountID: account.ID, ToAccountID: other.ID, Amount: 1050, ConvertedAmount: 1580},
+		{ID: 9, FromAccountID: other.ID, ToAccountID: account.ID, Amount: 20, ConvertedAmount: 13},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(customer.Username)).Times(1).Return(customer, nil)
+	store.EXPECT().
+		ListUserTransfersAfter(gomock.Any(), gomock.Eq(db.ListUserTransfersAfterParams{
+			Owner:   customer.Username,
+			AfterID: 3,
+			Limit:   6,
+		})).
+		Times(1).
+		Return(transfers, nil)
+	store.EXPECT().
+		ListAccountCurrencies(gomock.Any(), gomock.Eq([]int64{account.ID, other.ID, other.ID, account.ID})).
+		Times(1).
+		Return(accountCurrencies(account, other), nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/admin/users/%s/transfers?page_size=5&cursor=%s", customer.Username, encodeCursor("3"))
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.AuditorRole, "auditor", time.Minute)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	rsp := decodeBody[listUserTransfersResponse](t, recorder.Body)
+	require.Len(t, rsp.Transfers, 2)
+	require.Equal(t, transfers[0], rsp.Transfers[0].Transfer)
+	require.Equal(t, "10.50", rsp.Transfers[0].AmountFormatted)
+	require.Equal(t, "1580", rsp.Transfers[0].ConvertedAmountFormatted)
+	require.Equal(t, transfers[1], rsp.Transfers[1].Transfer)
+	require.Equal(t, "20", rsp.Transfers[1].AmountFormatted)
+	require.Equal(t, "0.13", rsp.Transfers[1].ConvertedAmountFormatted)
+	require.Empty(t, rsp.NextCursor)
+}
+
+func TestFreezeAccountAPI(t *testing.T) {
+	customer, _ := randomUser(t, util.DepositorRole)
+	account := randomAccount(customer.Username)
+
+	frozenAccount := account
+	frozenAccount.Status = util.AccountStatusFrozen
+
+	// statusChangeArg is what the back office user "staff" asks the store for
+	statusChangeArg := func(from string, to string) db.ChangeAccountStatusTxParams {
+		return db.ChangeAccountStatusTxParams{
+			AccountID:  account.ID,
+			Status:     to,
+			FromStatus: from,
+			ChangedBy:  "staff",
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		action        string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:   "Freeze",
+			action: "freeze",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(statusChangeArg(util.AccountStatusActive, util.AccountStatusFrozen))).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{Account: frozenAccount}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, frozenAccount)
+			},
+		},
+		{
+			name:   "Unfreeze",
+			action: "unfreeze",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(statusChangeArg(util.AccountStatusFrozen, util.AccountStatusActive))).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:   "Reopen",
+			action: "reopen",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(statusChangeArg(util.AccountStatusClosed, util.AccountStatusActive))).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{Account: account}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name:   "InvalidTransition",
+			action: "unfreeze",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Eq(statusChangeArg(util.AccountStatusFrozen, util.AccountStatusActive))).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, db.ErrInvalidStatusTransition)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name:   "AuditorRole",
+			action: "freeze",
+			role:   util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ChangeAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:   "NotFound",
+			action: "freeze",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:   "InternalError",
+			action: "freeze",
+			role:   util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ChangeAccountStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChangeAccountStatusTxResult{}, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/admin/accounts/%d/%s", account.ID, tc.action)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.role, "staff", time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestGetAnyAccountAPI(t *testing.T) {
+	customer, _ := randomUser(t, util.DepositorRole)
+	account := randomAccount(customer.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+	server := newTestServer(t, store)
+
+	// the owner is not let in the back office
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/admin/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, customer.Role, customer.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest(http.MethodGet, fmt.Sprintf("/admin/accounts/%d", account.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.BankerRole, "banker", time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	requireBodyMatchAccount(t, recorder.Body, account)
+}
+
+// decodeBody unmarshals a JSON response body
+func decodeBody[T any](t *testing.T, body *bytes.Buffer) T {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp T
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	return rsp
+}
+
+func TestListAccountStatusChangesAPI(t *testing.T) {
+	customer, _ := randomUser(t, util.DepositorRole)
+	account := randomAccount(customer.Username)
+
+	changes := []db.AccountStatusChange{
+		{
+			ID:        1,
+			AccountID: account.ID,
+			OldStatus: util.AccountStatusActive,
+			NewStatus: util.AccountStatusFrozen,
+			ChangedBy: "staff",
+			ChangedAt: time.Now().Truncate(time.Second),
+		},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().ListAccountStatusChanges(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(changes, nil)
+
+	server := newTestServer(t, store)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/admin/accounts/%d/status_changes", account.ID), nil)
+	require.NoError(t, err)
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.AuditorRole, "staff", time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	gotChanges := decodeBody[[]db.AccountStatusChange](t, recorder.Body)
+	require.Len(t, gotChanges, 1)
+	require.Equal(t, changes[0].NewStatus, gotChanges[0].NewStatus)
+	require.WithinDuration(t, changes[0].ChangedAt, gotChanges[0].ChangedAt, time.Second)
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// auditContext carries who makes the request to the audit events the store writes in the transaction of the change.
+// The actor is the logged in user, nobody before a login.
+func auditContext(ctx *gin.Context) context.Context {
+	actor := newAuditActor(ctx)
+
+	if payload, ok := ctx.Get(authorizationPayloadKey); ok {
+		authPayload := payload.(*token.Payload)
+		actor.Username = authPayload.Username
+		actor.Role = authPayload.Role
+	}
+
+	return db.WithAuditActor(ctx, actor)
+}
+
+// auditContextFor is an auditContext whose actor is the given user, for the requests that log a user in
+func auditContextFor(ctx *gin.Context, username string, role string) context.Context {
+	actor := newAuditActor(ctx)
+	actor.Username = username
+	actor.Role = role
+
+	return db.WithAuditActor(ctx, actor)
+}
+
+// newAuditActor describes the client of the request, without a user
+func newAuditActor(ctx *gin.Context) db.AuditActor {
+	return db.AuditActor{
+		ClientIP:  ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		RequestID: ctx.GetString(requestIDKey),
+	}
+}
+
+// listAuditEventsRequest represents the query parameters for listing the audit events
+type listAuditEventsRequest struct {
+	adminPageRequest
+	Actor      string    `form:"actor"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// auditEventResponse is an audit event with its before and after as JSON objects
+type auditEventResponse struct {
+	ID            int64           `json:"id"`
+	ActorUsername string          `json:"actor_username,omitempty"`
+	ActorRole     string          `json:"actor_role,omitempty"`
+	Action        string          `json:"action"`
+	TargetType    string          `json:"target_type"`
+	TargetID      string          `json:"target_id"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	ClientIP      string          `json:"client_ip"`
+	UserAgent     string          `json:"user_agent"`
+	RequestID     string          `json:"request_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// newAuditEventResponse converts a database audit event to an auditEventResponse
+func newAuditEventResponse(event db.AuditEvent) auditEventResponse {
+	return auditEventResponse{
+		ID:            event.ID,
+		ActorUsername: event.ActorUsername.String,
+		ActorRole:     event.ActorRole.String,
+		Action:        event.Action,
+		TargetType:    event.TargetType,
+		TargetID:      event.TargetID,
+		Before:        event.Before,
+		After:         event.After,
+		ClientIP:      event.ClientIp,
+		UserAgent:     event.UserAgent,
+		RequestID:     event.RequestID,
+		CreatedAt:     event.CreatedAt,
+	}
+}
+
+// listAuditEventsResponse is a page of audit events, next_cursor is empty on the last page
+type listAuditEventsResponse struct {
+	Events     []auditEventResponse `json:"events"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// listAuditEvents lists the audit log for the back office
+// @Summary List Audit Events
+// @Description List who changed what and when, newest first. Only the back office roles that can read the audit log can do it.
+// @Tags admin
+// @Produce json
+// @Param actor query string false "Username of the actor"
+// @Param action query string false "Action, such as transfer.create"
+// @Param target_type query string false "Type of the target, such as account"
+// @Param target_id query string false "ID of the target"
+// @Param from query string false "Start of the period, RFC 3339"
+// @Param to query string false "End of the period, excluded, RFC 3339"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param page_size query int true "Page size"
+// @Success 200 {object} listAuditEventsResponse "Page of audit events"
+// @Failure 400 {object} gin.H "Bad Request - Invalid query or cursor"
+// @Failure 403 {object} gin.H "Forbidden - Needs the back_office:access:any and audit_events:read:any permissions"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /admin/audit [get]
+func (server *Server) listAuditEvents(ctx *gin.Context) {
+	var req listAuditEventsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// the newest events come first, the cursor is the ID the next page starts below
+	beforeID, err := decodeIDCursor(req.Cursor)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+	if beforeID == 0 {
+		beforeID = math.MaxInt64
+	}
+
+	arg := db.ListAuditEventsParams{
+		ActorUsername: pgtype.Text{String: req.Actor, Valid: req.Actor != ""},
+		Action:        pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		TargetType:    pgtype.Text{String: req.TargetType, Valid: req.TargetType != ""},
+		TargetID:      pgtype.Text{String: req.TargetID, Valid: req.TargetID != ""},
+		FromTime:      pgtype.Timestamptz{Time: req.From, Valid: !req.From.IsZero()},
+		ToTime:        pgtype.Timestamptz{Time: req.To, Valid: !req.To.IsZero()},
+		BeforeID:      beforeID,
+		Limit:         req.PageSize + 1, // one more event tells whether there is a next page
+	}
+
+	events, err := server.store.ListAuditEvents(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := listAuditEventsResponse{Events: []auditEventResponse{}}
+	if len(events) > int(req.PageSize) {
+		events = events[:req.PageSize]
+		rsp.NextCursor = encodeCursor(strconv.FormatInt(events[len(events)-1].ID, 10))
+	}
+	for _, event := range events {
+		rsp.Events = append(rsp.Events, newAuditEventResponse(event))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
@@ -0,0 +1,218 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAuditEventsAPI(t *testing.T) {
+	n := 6
+	events := make([]db.AuditEvent, n)
+	for i := range events {
+		events[i] = db.AuditEvent{
+			ID:         int64(100 - i),
+			Action:     db.AuditActionCreateAccount,
+			TargetType: db.AuditTargetAccount,
+			TargetID:   strconv.Itoa(i + 1),
+			After:      []byte(`{"id": 1}`),
+			RequestID:  util.RandomString(12),
+			CreatedAt:  time.Now().Truncate(time.Second),
+		}
+	}
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         url.Values
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "FirstPage",
+			query: url.Values{
+				"actor":     {"alice"},
+				"action":    {db.AuditActionCreateAccount},
+				"from":      {from.Format(time.RFC3339)},
+				"page_size": {"5"},
+			},
+			role: util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+						require.Equal(t, "alice", arg.ActorUsername.String)
+						require.Equal(t, db.AuditActionCreateAccount, arg.Action.String)
+						require.False(t, arg.TargetType.Valid)
+						require.True(t, from.Equal(arg.FromTime.Time))
+						require.False(t, arg.ToTime.Valid)
+						require.Equal(t, int64(math.MaxInt64), arg.BeforeID)
+						require.Equal(t, int32(6), arg.Limit)
+						return events, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodeBody[listAuditEventsResponse](t, recorder.Body)
+				require.Len(t, rsp.Events, 5)
+				require.Equal(t, encodeCursor(strconv.FormatInt(events[4].ID, 10)), rsp.NextCursor)
+				require.JSONEq(t, `{"id": 1}`, string(rsp.Events[0].After))
+				require.Empty(t, rsp.Events[0].Before)
+			},
+		},
+		{
+			name: "LastPage",
+			query: url.Values{
+				"cursor":    {encodeCursor("95")},
+				"page_size": {"5"},
+			},
+			role: util.AdminRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+						require.Equal(t, int64(95), arg.BeforeID)
+						return events[5:], nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := decodeBody[listAuditEventsResponse](t, recorder.Body)
+				require.Len(t, rsp.Events, 1)
+				require.Empty(t, rsp.NextCursor)
+			},
+		},
+		{
+			name:  "BankerRole",
+			query: url.Values{"page_size": {"5"}},
+			role:  util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidCursor",
+			query: url.Values{"cursor": {"!"}, "page_size": {"5"}},
+			role:  util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidFrom",
+			query: url.Values{"from": {"yesterday"}, "page_size": {"5"}},
+			role:  util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InternalError",
+			query: url.Values{"page_size": {"5"}},
+			role:  util.AuditorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/audit?"+tc.query.Encode(), nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.role, "staff", time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAuditContext(t *testing.T) {
+	user, _ := randomUser(t, util.DepositorRole)
+	account := randomAccount(user.Username)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	stubVerifiedEmail(store)
+
+	// the store receives who made the request along with the change
+	store.EXPECT().
+		CreateAccountTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, _ db.CreateAccountTxParams) (db.CreateAccountTxResult, error) {
+			actor := db.AuditActorFromContext(ctx)
+			require.Equal(t, user.Username, actor.Username)
+			require.Equal(t, user.Role, actor.Role)
+			require.Equal(t, "test-agent", actor.UserAgent)
+			require.Equal(t, "req-123", actor.RequestID)
+			require.NotEmpty(t, actor.ClientIP)
+			return db.CreateAccountTxResult{Account: account}, nil
+		})
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{"currency": account.Currency})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/accounts", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.RemoteAddr = "10.0.0.1:1234"
+	request.Header.Set("User-Agent", "test-agent")
+	request.Header.Set(requestIDHeaderKey, "req-123")
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, "req-123", recorder.Header().Get(requestIDHeaderKey))
+}
@@ -1,28 +1,37 @@
 package api
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"simplebank/token"
+	"simplebank/util"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RBAC(ctx *gin.Context, payloadRole string, accessibleRoles []string) error {
+var errPermissionDenied = errors.New("permission denied")
 
-	if !hasPermission(payloadRole, accessibleRoles) {
-		err := fmt.Errorf("permission denied")
-		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
-		return err
+// requirePermission builds a middleware refusing with 403 the users whose role is not granted the permission.
+// For an own permission the handler still has to check that the resource belongs to the user, or that the any permission is granted.
+func requirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		if !util.HasPermission(authPayload.Role, permission) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+			return
+		}
+
+		ctx.Next()
 	}
+}
 
-	return nil
+// canAccess returns true if the user of the payload owns the resource, or if its role is granted the any permission
+func canAccess(authPayload *token.Payload, owner string, anyPermission string) bool {
+	return authPayload.Username == owner || util.HasPermission(authPayload.Role, anyPermission)
 }
 
-func hasPermission(userRole string, accessibleRoles []string) bool {
-	for _, role := range accessibleRoles {
-		if userRole == role {
-			return true
-		}
-	}
-	return false
+// canManage returns true if the user of the payload may act on the account of a user with the target role.
+// An admin account is only managed by the roles that can give or take the admin role, or a banker could take it over.
+func canManage(authPayload *token.Payload, targetRole string) bool {
+	return targetRole != util.AdminRole || util.HasPermission(authPayload.Role, util.PermAdminsChangeRoleAny)
 }
@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entryAccountRequest represents the URI parameters for account deposits and withdrawals
+type entryAccountRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// EntryRequest defines the request body for a deposit or a withdrawal
+// @Description Request body for adding money to or taking money from an account
+// @Param amount body int64 true "Amount to deposit or withdraw"
+// @Param currency body string true "Currency of the account"
+// @Accept json
+// @Produce json
+type EntryRequest struct {
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Currency string `json:"currency" binding:"required,currency"` // binding:currency is the custom Validator from validator.go
+}
+
+// entryResponse is an account entry with its amount also formatted as a decimal of the account currency
+type entryResponse struct {
+	db.Entry
+	AmountFormatted string `json:"amount_formatted"`
+}
+
+// newEntryResponse converts a database entry of an account in the given currency to an entryResponse
+func newEntryResponse(entry db.Entry, currency string) entryResponse {
+	return entryResponse{
+		Entry:           entry,
+		AmountFormatted: util.FormatAmount(entry.Amount, currency),
+	}
+}
+
+// entryTxResponse is the response of a deposit or a withdrawal
+type entryTxResponse struct {
+	Account accountResponse `json:"account"`
+	Entry   entryResponse   `json:"entry"`
+}
+
+// newEntryTxResponse builds the response of a deposit or a withdrawal from the updated account and its new entry
+func newEntryTxResponse(account db.Account, entry db.Entry) entryTxResponse {
+	return entryTxResponse{
+		Account: newAccountResponse(account),
+		Entry:   newEntryResponse(entry, account.Currency),
+	}
+}
+
+// createDeposit handles a deposit into a customer account
+// @Summary Create a Deposit
+// @Description Add money to an account. It needs the entries:create:any permission, granted to bankers.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param request body EntryRequest true "Deposit Request"
+// @Success 200 {object} entryTxResponse "Deposit successfully processed"
+// @Failure 400 {object} gin.H "Bad Request - Invalid request data"
+// @Failure 403 {object} gin.H "Forbidden - Needs the entries:create:any permission, or the account is your own"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 422 {object} gin.H "Unprocessable Entity - Account frozen or closed"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /accounts/{id}/deposits [post]
+func (server *Server) createDeposit(ctx *gin.Context) {
+	accountID, req, valid := server.bindEntryRequest(ctx)
+	if !valid {
+		return
+	}
+
+	arg := db.DepositTxParams{
+		AccountID: accountID,
+		Amount:    req.Amount,
+	}
+
+	result, err := server.store.DepositTx(auditContext(ctx), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEntryTxResponse(result.Account, result.Entry))
+}
+
+// createWithdrawal handles a withdrawal from a customer account
+// @Summary Create a Withdrawal
+// @Description Take money out of an account. It needs the entries:create:any permission, granted to bankers.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param request body EntryRequest true "Withdrawal Request"
+// @Success 200 {object} entryTxResponse "Withdrawal successfully processed"
+// @Failure 400 {object} gin.H "Bad Request - Invalid request data"
+// @Failure 403 {object} gin.H "Forbidden - Needs the entries:create:any permission, or the account is your own"
+// @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 422 {object} gin.H "Unprocessable Entity - Insufficient funds, account frozen or closed"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /accounts/{id}/withdrawals [post]
+func (server *Server) createWithdrawal(ctx *gin.Context) {
+	accountID, req, valid := server.bindEntryRequest(ctx)
+	if !valid {
+		return
+	}
+
+	arg := db.WithdrawTxParams{
+		AccountID: accountID,
+		Amount:    req.Amount,
+	}
+
+	result, err := server.store.WithdrawTx(auditContext(ctx), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrInsufficientFunds) || errors.Is(err, db.ErrAccountNotActive) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newEntryTxResponse(result.Account, result.Entry))
+}
+
+// bindEntryRequest returns the account ID and the body of a deposit or a withdrawal,
+// once the account is known to exist, to be in the currency of the request, to be active
+// and not to belong to the authenticated user, a banker may not move money in or out of their own accounts.
+// It has already answered the client when it returns false.
+func (server *Server) bindEntryRequest(ctx *gin.Context) (int64, EntryRequest, bool) {
+	var uri entryAccountRequest
+	var req EntryRequest
+
+	// validating the request from the URI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return 0, req, false
+	}
+
+	// validating the request from the body JSON
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return 0, req, false
+	}
+
+	// Validating account and currency
+	account, valid := server.validAccount(ctx, uri.ID, req.Currency)
+	if !valid || !activeAccount(ctx, account) {
+		return 0, req, false
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner == authPayload.Username {
+		err := errors.New("can't deposit into or withdraw from your own account")
+		ctx.JSON(http.StatusForbidden, errorResponse(err))
+		return 0, req, false
+	}
+
+	return uri.ID, req, true
+}
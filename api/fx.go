@@ -0,0 +1,184 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// upsertFxRateRequest defines the request body for setting an exchange rate
+// @Description Request body for setting the rate of a currency pair
+type upsertFxRateRequest struct {
+	BaseCurrency  string `json:"base_currency" binding:"required,currency"`
+	QuoteCurrency string `json:"quote_currency" binding:"required,currency,nefield=BaseCurrency"`
+	Rate          int64  `json:"rate" binding:"required,gt=0"` // units of quote currency for one unit of base currency, scaled by util.FxRateScale
+}
+
+// upsertFxRate creates or replaces the exchange rate of a currency pair
+// @Summary Set an FX rate
+// @Description Set the rate of a currency pair, scaled by 10^8. It needs the fx_rates:update:any permission, granted to bankers.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param request body upsertFxRateRequest true "FX rate"
+// @Success 200 {object} db.FxRate "FX rate saved"
+// @Failure 400 {object} gin.H "Invalid request"
+// @Failure 403 {object} gin.H "Forbidden - Needs the fx_rates:update:any permission"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /fx_rates [put]
+func (server *Server) upsertFxRate(ctx *gin.Context) {
+	var req upsertFxRateRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// get auth payload
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.UpsertFxRateParams{
+		BaseCurrency:  req.BaseCurrency,
+		QuoteCurrency: req.QuoteCurrency,
+		Rate:          req.Rate,
+		UpdatedBy:     authPayload.Username,
+	}
+
+	result, err := server.store.UpsertFxRateTx(auditContext(ctx), db.UpsertFxRateTxParams{UpsertFxRateParams: arg})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result.FxRate)
+}
+
+// listFxRates lists every exchange rate
+// @Summary List FX rates
+// @Description List the rates of every currency pair
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Success 200 {array} db.FxRate "List of FX rates"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /fx_rates [get]
+func (server *Server) listFxRates(ctx *gin.Context) {
+	rates, err := server.store.ListFxRates(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rates)
+}
+
+// fxRatePairRequest represents the URI parameters of a currency pair
+type fxRatePairRequest struct {
+	BaseCurrency  string `uri:"base_currency" binding:"required,currency"`
+	QuoteCurrency string `uri:"quote_currency" binding:"required,currency"`
+}
+
+// deleteFxRate removes the exchange rate of a currency pair
+// @Summary Delete an FX rate
+// @Description Remove the rate of a currency pair, no new quotes can be given for it. It needs the fx_rates:delete:any permission, granted to bankers.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param base_currency path string true "Base currency"
+// @Param quote_currency path string true "Quote currency"
+// @Success 200 {object} gin.H "FX rate deleted"
+// @Failure 400 {object} gin.H "Invalid currency pair"
+// @Failure 403 {object} gin.H "Forbidden - Needs the fx_rates:delete:any permission"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /fx_rates/{base_currency}/{quote_currency} [delete]
+func (server *Server) deleteFxRate(ctx *gin.Context) {
+	var uri fxRatePairRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	_, err := server.store.DeleteFxRateTx(auditContext(ctx), db.DeleteFxRateTxParams{
+		DeleteFxRateParams: db.DeleteFxRateParams{
+			BaseCurrency:  uri.BaseCurrency,
+			QuoteCurrency: uri.QuoteCurrency,
+		},
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "fx rate deleted"})
+}
+
+// createFxQuoteRequest defines the request body for an exchange rate quote
+// @Description Request body for locking the rate of a currency pair before a transfer
+type createFxQuoteRequest struct {
+	FromCurrency string `json:"from_currency" binding:"required,currency"`
+	ToCurrency   string `json:"to_currency" binding:"required,currency,nefield=FromCurrency"`
+}
+
+// createFxQuote locks the current exchange rate of a currency pair for the authenticated user
+// @Summary Create an FX quote
+// @Description Lock the current rate of a currency pair for a short time. The quote ID is then sent with a transfer between accounts of these currencies.
+// @Tags fx
+// @Accept json
+// @Produce json
+// @Param request body createFxQuoteRequest true "Currency pair"
+// @Success 200 {object} db.FxQuote "FX quote"
+// @Failure 400 {object} gin.H "Invalid request"
+// @Failure 404 {object} gin.H "No rate for the currency pair"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /fx_quotes [post]
+func (server *Server) createFxQuote(ctx *gin.Context) {
+	var req createFxQuoteRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rate, err := server.store.GetFxRate(ctx, db.GetFxRateParams{
+		BaseCurrency:  req.FromCurrency,
+		QuoteCurrency: req.ToCurrency,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(errors.New("no fx rate for this currency pair")))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	arg := db.CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     authPayload.Username,
+		FromCurrency: rate.BaseCurrency,
+		ToCurrency:   rate.QuoteCurrency,
+		Rate:         rate.Rate,
+		ExpiresAt:    time.Now().Add(server.config.FxQuoteDuration),
+	}
+
+	quote, err := server.store.CreateFxQuote(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, quote)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/lockout"
+	"simplebank/util"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidCredentials is the single answer to a wrong username or password, so that it tells nothing about the username.
+// A login refused by the lockout gets 429 instead, for an unknown username as for an existing one.
+var errInvalidCredentials = errors.New("incorrect username or password")
+
+// dummyHashedPassword is checked when the username doesn't exist,
+// so that the response takes as long as the one of a wrong password
+var dummyHashedPassword = sync.OnceValues(func() (string, error) {
+	return util.HashPassword(util.RandomString(16))
+})
+
+// checkLoginAllowed counts the login as failed before it is tried, or refuses it with 429
+// and a Retry-After header while the username or the client IP is backing off or locked out.
+// The login handler stops as soon as it returns false.
+func (server *Server) checkLoginAllowed(ctx *gin.Context, username string) bool {
+	retryAfter, err := server.loginGuard.Attempt(ctx, username, ctx.ClientIP())
+	if err != nil {
+		if errors.Is(err, lockout.ErrTooManyAttempts) {
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+	return true
+}
+
+// refuseLogin answers wrong credentials with 401 whether the username exists or not, checkLoginAllowed has already counted the failure
+func (server *Server) refuseLogin(ctx *gin.Context, reason error) {
+	ctx.JSON(http.StatusUnauthorized, errorResponse(reason))
+}
+
+// listLockouts lists the usernames and client IPs that may not try to log in yet
+// @Summary List Login Lockouts
+// @Description List the usernames and client IPs that are backing off or locked out after failed logins. It needs the lockouts:read:any permission, granted to bankers.
+// @Tags users
+// @Produce json
+// @Success 200 {array} lockout.Lockout "Lockouts, latest failure first"
+// @Failure 403 {object} gin.H "Forbidden - Needs the lockouts:read:any permission"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /lockouts [get]
+func (server *Server) listLockouts(ctx *gin.Context) {
+	lockouts, err := server.loginGuard.Lockouts(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, lockouts)
+}
+
+// clearLockoutRequest represents the URI parameters for clearing a lockout
+type clearLockoutRequest struct {
+	Kind  string `uri:"kind" binding:"required,oneof=username ip"`
+	Value string `uri:"value" binding:"required"`
+}
+
+// clearLockout ends the lockout of a username or a client IP
+// @Summary Clear Login Lockout
+// @Description Forget the failed logins of a username or a client IP, ending its backoff or lockout, and record them in the audit log. It needs the lockouts:delete:any permission, granted to bankers.
+// @Tags users
+// @Produce json
+// @Param kind path string true "username or ip"
+// @Param value path string true "Username or client IP"
+// @Success 200 {object} gin.H "Lockout cleared"
+// @Failure 400 {object} gin.H "Bad Request - Invalid kind"
+// @Failure 403 {object} gin.H "Forbidden - Needs the lockouts:delete:any permission"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /lockouts/{kind}/{value} [delete]
+func (server *Server) clearLockout(ctx *gin.Context) {
+	var req clearLockoutRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	attempts, err := server.loginGuard.Clear(ctx, req.Kind, req.Value)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the counters live outside the database, the audit event is written on its own
+	err = server.store.RecordAuditEvent(auditContext(ctx), db.RecordAuditEventParams{
+		Action:     db.AuditActionClearLockout,
+		TargetType: db.AuditTargetLockout,
+		TargetID:   req.Kind + ":" + req.Value,
+		Before:     attempts,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "lockout cleared"})
+}
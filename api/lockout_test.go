@@ -0,0 +1,365 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/lockout"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginUserLockout(t *testing.T) {
+	user, password := randomUser(t, util.DepositorRole)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Not(user.Username)).AnyTimes().Return(db.User{}, db.ErrRecordNotFound)
+	store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(1)
+
+	server := newTestServer(t, store)
+
+	login := func(username, password string) *httptest.ResponseRecorder {
+		data, err := json.Marshal(gin.H{"username": username, "password": password})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+		require.NoError(t, err)
+		request.RemoteAddr = "203.0.113.11:40000"
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	// an existing and an unknown username are treated the same
+	for _, username := range []string{user.Username, util.RandomOwner()} {
+		recorder := login(username, "incorrect")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+		requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
+
+		// backing off, even with the right password
+		recorder = login(username, password)
+		require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+		require.Equal(t, "1", recorder.Header().Get("Retry-After"))
+		requireBodyMatchError(t, recorder.Body, lockout.ErrTooManyAttempts)
+	}
+
+	lockouts, err := server.loginGuard.Lockouts(context.Background())
+	require.NoError(t, err)
+	require.Len(t, lockouts, 2) // both usernames, the client IP is far from its lockout
+
+	_, err = server.loginGuard.Clear(context.Background(), lockout.KindUsername, user.Username)
+	require.NoError(t, err)
+
+	recorder := login(user.Username, password)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestLoginUserMfaKeepsFailures(t *testing.T) {
+	user, password := randomUser(t, util.DepositorRole)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	encryptedSecret, err := util.EncryptString(server.config.TotpEncryptionKey, secret)
+	require.NoError(t, err)
+	user.TotpSecret = pgtype.Text{String: encryptedSecret, Valid: true}
+	user.IsTotpEnabled = true
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+	store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, db.ErrRecordNotFound)
+	store.EXPECT().UseUserTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+	store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(1)
+
+	// a short backoff, so that the test doesn't wait between the attempts
+	backend := lockout.NewMemoryBackend()
+	server.loginGuard = lockout.NewGuard(backend, lockout.Policy{BackoffBase: time.Millisecond})
+
+	post := func(url string, body gin.H) *httptest.ResponseRecorder {
+		time.Sleep(5 * time.Millisecond)
+
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+		require.NoError(t, err)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+	usernameFailures := func() int {
+		attempts, err := backend.GetAttempts(context.Background(), lockout.KindUsername, user.Username)
+		require.NoError(t, err)
+		return attempts.Failures
+	}
+
+	recorder := post("/users/login", gin.H{"username": user.Username, "password": "incorrect"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, 1, usernameFailures())
+
+	// the right password alone doesn't forget the failures, they still limit the guesses of the code
+	recorder = post("/users/login", gin.H{"username": user.Username, "password": password})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var mfaRsp loginMfaRequiredResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &mfaRsp))
+	require.Equal(t, 1, usernameFailures())
+
+	recorder = post("/users/login/mfa", gin.H{"mfa_token": mfaRsp.MfaToken, "code": "abcde-fghjk"})
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Equal(t, 2, usernameFailures())
+
+	// until the login is complete
+	recorder = post("/users/login/mfa", gin.H{"mfa_token": mfaRsp.MfaToken, "code": currentTotpCode(t, secret)})
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Zero(t, usernameFailures())
+}
+
+func TestDisableTotpLockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	server := newTestServer(t, store)
+
+	secret, err := util.GenerateTOTPSecret()
+	require.NoError(t, err)
+	user := randomTotpUser(t, server, secret, true)
+
+	policy := lockout.Policy{BackoffBase: time.Nanosecond}
+	server.loginGuard = lockout.NewGuard(lockout.NewMemoryBackend(), policy)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).AnyTimes().Return(user, nil)
+	store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).AnyTimes().Return(db.RecoveryCode{}, db.ErrRecordNotFound)
+	store.EXPECT().UseUserTotpStep(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().DisableTotpTx(gomock.Any(), gomock.Any()).Times(0)
+
+	disable := func(code string) *httptest.ResponseRecorder {
+		time.Sleep(time.Millisecond)
+
+		data, err := json.Marshal(gin.H{"code": code})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodDelete, "/users/mfa/totp", bytes.NewReader(data))
+		require.NoError(t, err)
+		addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	for i := 0; i < lockout.DefaultPolicy.MaxUsernameFailures; i++ {
+		recorder := disable("abcde-fghjk")
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	// locked out, even with the right code
+	recorder := disable(currentTotpCode(t, secret))
+	require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	requireBodyMatchError(t, recorder.Body, lockout.ErrTooManyAttempts)
+}
+
+func TestListLockoutsAPI(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.BankerRole, "banker", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var lockouts []lockout.Lockout
+				err := json.Unmarshal(recorder.Body.Bytes(), &lockouts)
+				require.NoError(t, err)
+				require.Len(t, lockouts, 1)
+				require.Equal(t, lockout.KindUsername, lockouts[0].Kind)
+				require.Equal(t, username, lockouts[0].Value)
+				require.Equal(t, 1, lockouts[0].Failures)
+				require.False(t, lockouts[0].Locked)
+			},
+		},
+		{
+			name: "NotBanker",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.DepositorRole, "depositor", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			recorder := httptest.NewRecorder()
+
+			// the attempt is counted as failed until the login turns out right
+			_, err := server.loginGuard.Attempt(context.Background(), username, "")
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodGet, "/lockouts", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestClearLockoutAPI(t *testing.T) {
+	clientIP := "203.0.113.10"
+
+	testCases := []struct {
+		name          string
+		kind          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, guard *lockout.Guard)
+	}{
+		{
+			name: "OK",
+			kind: lockout.KindIP,
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.RecordAuditEventParams) error {
+						require.Equal(t, "user", db.AuditActorFromContext(ctx).Username)
+						require.Equal(t, db.AuditActionClearLockout, arg.Action)
+						require.Equal(t, db.AuditTargetLockout, arg.TargetType)
+						require.Equal(t, lockout.KindIP+":"+clientIP, arg.TargetID)
+						require.Equal(t, lockout.DefaultPolicy.MaxIPFailures, arg.Before.(lockout.Attempts).Failures)
+						require.Nil(t, arg.After)
+						return nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, guard *lockout.Guard) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				_, err := guard.Attempt(context.Background(), util.RandomOwner(), clientIP)
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			kind: lockout.KindIP,
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RecordAuditEvent(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, guard *lockout.Guard) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidKind",
+			kind: "email",
+			role: util.BankerRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, guard *lockout.Guard) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "NotBanker",
+			kind: lockout.KindIP,
+			role: util.DepositorRole,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordAuditEvent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, guard *lockout.Guard) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				_, err := guard.Attempt(context.Background(), util.RandomOwner(), clientIP)
+				require.ErrorIs(t, err, lockout.ErrTooManyAttempts)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			// lock the IP out
+			for i := 0; i < lockout.DefaultPolicy.MaxIPFailures; i++ {
+				_, err := server.loginGuard.Attempt(context.Background(), util.RandomOwner(), clientIP)
+				require.NoError(t, err)
+			}
+
+			url := fmt.Sprintf("/lockouts/%s/%s", tc.kind, clientIP)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.role, "user", time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server.loginGuard)
+		})
+	}
+}
+
+func requireBodyMatchError(t *testing.T, body *bytes.Buffer, expected error) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp gin.H
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	require.Equal(t, expected.Error(), rsp["error"])
+}
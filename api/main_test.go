@@ -3,6 +3,8 @@ package api
 import (
 	"os"
 	db "simplebank/db/sqlc"
+	"simplebank/lockout"
+	"simplebank/revocation"
 	"simplebank/util"
 	"simplebank/worker"
 	"testing"
@@ -15,8 +17,16 @@ import (
 
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey: util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		TokenSymmetricKey:      util.RandomString(32),
+		TokenIssuer:            "simplebank-test",
+		TokenAudience:          []string{"simplebank-api"},
+		TokenRevocationBackend: revocation.BackendMemory,
+		LoginLockoutBackend:    lockout.BackendMemory,
+		AccessTokenDuration:    time.Minute,
+		MfaTokenDuration:       time.Minute,
+		TotpEncryptionKey:      util.RandomString(util.EncryptionKeySize),
+		IdempotencyKeyTTL:      time.Minute,
+		FxQuoteDuration:        time.Minute,
 	}
 
 	redisOpt := asynq.RedisClientOpt{
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	// mfaPendingRole is the role of the token loginUser returns when a second factor is needed.
+	// authMiddleware refuses it, it can only be exchanged for real tokens by loginUserMfa.
+	mfaPendingRole = "mfa_pending"
+
+	// totpIssuer names the bank in authenticator apps
+	totpIssuer = "Simple Bank"
+
+	// recoveryCodeCount is how many one-time recovery codes an enrollment creates
+	recoveryCodeCount = 10
+)
+
+var (
+	errInvalidMfaCode     = errors.New("invalid or already used code")
+	errMfaRequired        = errors.New("multi-factor authentication is not complete")
+	errTotpAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	errTotpNotEnrolled    = errors.New("two-factor authentication enrollment was not started")
+	errTotpNotEnabled     = errors.New("two-factor authentication is not enabled")
+	errInvalidMfaToken    = errors.New("token is not a multi-factor authentication token")
+)
+
+// enrollTotpResponse defines the response body of a TOTP enrollment
+// @Description Response body of a TOTP enrollment, to be added to an authenticator app
+// @Property secret string "Base32 TOTP secret" example("JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP")
+// @Property provisioning_uri string "otpauth URI to show as a QR code" example("otpauth://totp/Simple%20Bank:johndoe?secret=JBSWY3DPEHPK3PXP&issuer=Simple+Bank")
+type enrollTotpResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// enrollTotp generates a new TOTP secret for the user
+// @Summary Enroll TOTP
+// @Description Generate an RFC 6238 secret and its provisioning URI. Two-factor authentication is enabled once a first code is confirmed.
+// @Tags users
+// @Produce json
+// @Success 200 {object} enrollTotpResponse "Secret generated"
+// @Failure 401 {object} gin.H "Unauthorized"
+// @Failure 409 {object} gin.H "Conflict - Two-factor authentication is already enabled"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/mfa/totp [post]
+func (server *Server) enrollTotp(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsTotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errTotpAlreadyEnabled))
+		return
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the secret is only stored encrypted
+	encryptedSecret, err := util.EncryptString(server.config.TotpEncryptionKey, secret)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.UpdateUserTotpSecret(ctx, db.UpdateUserTotpSecretParams{
+		Username: user.Username,
+		TotpSecret: pgtype.Text{
+			String: encryptedSecret,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// enabled in the meantime
+			ctx.JSON(http.StatusConflict, errorResponse(errTotpAlreadyEnabled))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := enrollTotpResponse{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(totpIssuer, user.Username, secret),
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// mfaCodeRequest defines the request body carrying a second factor code
+// @Description Request body carrying a TOTP code or a recovery code
+// @Param code body string true "6-digit TOTP code, or a recovery code where allowed" example("123456")
+// @Accept json
+// @Produce json
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// confirmTotpResponse defines the response body of a TOTP confirmation
+// @Description Response body of a TOTP confirmation. The recovery codes are shown only once.
+// @Property recovery_codes []string "One-time recovery codes" example(["abcde-fghjk"])
+type confirmTotpResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// confirmTotp enables two-factor authentication with a first code of the enrolled secret
+// @Summary Confirm TOTP
+// @Description Enable two-factor authentication with a first TOTP code. Returns one-time recovery codes replacing any previous ones.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body mfaCodeRequest true "TOTP Code"
+// @Success 200 {object} confirmTotpResponse "Two-factor authentication enabled"
+// @Failure 400 {object} gin.H "Bad Request - Invalid code or no enrollment"
+// @Failure 401 {object} gin.H "Unauthorized"
+// @Failure 409 {object} gin.H "Conflict - Two-factor authentication is already enabled"
+// @Failure 429 {object} gin.H "Too Many Requests - Too many failed codes, see the Retry-After header"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/mfa/totp/confirm [post]
+func (server *Server) confirmTotp(ctx *gin.Context) {
+	var req mfaCodeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsTotpEnabled {
+		ctx.JSON(http.StatusConflict, errorResponse(errTotpAlreadyEnabled))
+		return
+	}
+	if !user.TotpSecret.Valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTotpNotEnrolled))
+		return
+	}
+
+	// a stolen access token mustn't be enough to guess the code
+	if !server.checkLoginAllowed(ctx, user.Username) {
+		return
+	}
+
+	step, valid, err := server.validateTotp(user, req.Code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	if !valid {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidMfaCode))
+		return
+	}
+
+	recoveryCodes, err := util.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	hashedCodes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashedCodes[i] = util.HashRecoveryCode(code)
+	}
+
+	_, err = server.store.EnableTotpTx(auditContext(ctx), db.EnableTotpTxParams{
+		Username:            user.Username,
+		Step:                step,
+		HashedRecoveryCodes: hashedCodes,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidMfaCode))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.loginGuard.RegisterSuccess(ctx, user.Username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, confirmTotpResponse{RecoveryCodes: recoveryCodes})
+}
+
+// disableTotp turns off two-factor authentication
+// @Summary Disable TOTP
+// @Description Turn off two-factor authentication with a TOTP code or a recovery code. The recovery codes are deleted.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body mfaCodeRequest true "TOTP or Recovery Code"
+// @Success 200 {object} gin.H "Two-factor authentication disabled"
+// @Failure 400 {object} gin.H "Bad Request - Two-factor authentication is not enabled"
+// @Failure 401 {object} gin.H "Unauthorized - Invalid code"
+// @Failure 429 {object} gin.H "Too Many Requests - Too many failed codes, see the Retry-After header"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/mfa/totp [delete]
+func (server *Server) disableTotp(ctx *gin.Context) {
+	var req mfaCodeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.IsTotpEnabled {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errTotpNotEnabled))
+		return
+	}
+
+	// a stolen access token mustn't be enough to guess the code
+	if !server.checkLoginAllowed(ctx, user.Username) {
+		return
+	}
+
+	if !server.checkSecondFactor(ctx, user, req.Code) {
+		return
+	}
+
+	_, err = server.store.DisableTotpTx(auditContext(ctx), db.DisableTotpTxParams{Username: user.Username})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.loginGuard.RegisterSuccess(ctx, user.Username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "two-factor authentication disabled"})
+}
+
+// loginMfaRequiredResponse defines the response body of a login waiting for a second factor
+// @Description Response body of a login of a user with two-factor authentication. The mfa_token must be sent to /users/login/mfa with a code.
+// @Property mfa_required bool "Always true" example(true)
+// @Property mfa_token string "Short-lived token to complete the login"
+// @Property mfa_token_expires_at string "Expiration time of the mfa_token" example("2024-07-31T12:05:00Z")
+type loginMfaRequiredResponse struct {
+	MfaRequired       bool      `json:"mfa_required"`
+	MfaToken          string    `json:"mfa_token"`
+	MfaTokenExpiresAt time.Time `json:"mfa_token_expires_at"`
+}
+
+// loginUserMfaRequest defines the request body for completing a login with a second factor
+// @Description Request body for completing a login with a TOTP code or a recovery code
+// @Param mfa_token body string true "Token returned by /users/login"
+// @Param code body string true "6-digit TOTP code or a recovery code" example("123456")
+// @Accept json
+// @Produce json
+type loginUserMfaRequest struct {
+	MfaToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// loginUserMfa completes the login of a user with two-factor authentication
+// @Summary User Login Second Factor
+// @Description Exchange the mfa_token of /users/login and a TOTP code or a one-time recovery code for access and refresh tokens.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body loginUserMfaRequest true "Login User MFA Request"
+// @Success 200 {object} loginUserResponse "Login successful, returns user details and tokens"
+// @Failure 400 {object} gin.H "Bad Request - Invalid input data"
+// @Failure 401 {object} gin.H "Unauthorized - Invalid token or code"
+// @Failure 429 {object} gin.H "Too Many Requests - Too many failed codes, see the Retry-After header"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Router /users/login/mfa [post]
+func (server *Server) loginUserMfa(ctx *gin.Context) {
+	var req loginUserMfaRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	mfaPayload, err := server.tokenMaker.VerifyToken(req.MfaToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+	if mfaPayload.Role != mfaPendingRole {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaToken))
+		return
+	}
+
+	err = server.revocations.Check(ctx, mfaPayload)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	// codes are guessed as much as passwords are
+	if !server.checkLoginAllowed(ctx, mfaPayload.Username) {
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, mfaPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidMfaCode))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.IsTotpEnabled {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errTotpNotEnabled))
+		return
+	}
+
+	if !server.checkSecondFactor(ctx, user, req.Code) {
+		return
+	}
+
+	// the login is complete only now, its failures are forgotten
+	err = server.loginGuard.RegisterSuccess(ctx, user.Username, ctx.ClientIP())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the mfa token opens a single session
+	err = server.revocations.RevokeToken(ctx, mfaPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.createLoginSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// checkSecondFactor accepts a TOTP code or a one-time recovery code of the user, never twice the same.
+// A code that is wrong or already used is refused like a wrong password, a store failure with 500.
+func (server *Server) checkSecondFactor(ctx *gin.Context, user db.User, code string) bool {
+	step, valid, err := server.validateTotp(user, code)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	if valid {
+		_, err = server.store.UseUserTotpStep(ctx, db.UseUserTotpStepParams{
+			Username:     user.Username,
+			TotpLastStep: step,
+		})
+	} else {
+		_, err = server.store.UseRecoveryCode(ctx, db.UseRecoveryCodeParams{
+			Username:   user.Username,
+			HashedCode: util.HashRecoveryCode(code),
+		})
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			server.refuseLogin(ctx, errInvalidMfaCode)
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// validateTotp checks a code against the enrolled TOTP secret of the user and returns its period
+func (server *Server) validateTotp(user db.User, code string) (int64, bool, error) {
+	if !user.TotpSecret.Valid {
+		return 0, false, nil
+	}
+
+	secret, err := util.DecryptString(server.config.TotpEncryptionKey, user.TotpSecret.String)
+	if err != nil {
+		return 0, false, err
+	}
+
+	step, valid := util.ValidateTOTP(secret, code, time.Now())
+	return step, valid, nil
+}
@@ -0,0 +1,514 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// randomTotpUser returns a depositor whose TOTP secret is encrypted with the key of the server
+func randomTotpUser(t *testing.T, server *Server, secret string, enabled bool) db.User {
+	user, _ := randomUser(t, util.DepositorRole)
+
+	encryptedSecret, err := util.EncryptString(server.config.TotpEncryptionKey, secret)
+	require.NoError(t, err)
+
+	user.TotpSecret = pgtype.Text{String: encryptedSecret, Valid: true}
+	user.IsTotpEnabled = enabled
+	return user
+}
+
+// currentTotpCode returns the code an authenticator app shows now
+func currentTotpCode(t *testing.T, secret string) string {
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+	return code
+}
+
+func TestEnrollTotpAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		enabled       bool
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker, user db.User)
+		buildStubs    func(store *mockdb.MockStore, user db.User)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server, user db.User)
+	}{
+		{
+			name: "OK",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, user db.User) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				user.TotpSecret = pgtype.Text{}
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UpdateUserTotpSecret(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdateUserTotpSecretParams) (db.User, error) {
+						user.TotpSecret = arg.TotpSecret
+						return user, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server, user db.User) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp enrollTotpResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.NotEmpty(t, rsp.Secret)
+				require.Contains(t, rsp.ProvisioningURI, "otpauth://totp/")
+				require.Contains(t, rsp.ProvisioningURI, "secret="+rsp.Secret)
+			},
+		},
+		{
+			name:    "AlreadyEnabled",
+			enabled: true,
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, user db.User) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UpdateUserTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server, user db.User) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "NoAuthorization",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, user db.User) {
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateUserTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server, user db.User) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker, user db.User) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, fmt.Errorf("internal server error"))
+				store.EXPECT().UpdateUserTotpSecret(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, server *Server, user db.User) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			secret, err := util.GenerateTOTPSecret()
+			require.NoError(t, err)
+			user := randomTotpUser(t, server, secret, tc.enabled)
+			tc.buildStubs(store, user)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/mfa/totp", nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server.tokenMaker, user)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, server, user)
+		})
+	}
+}
+
+func TestConfirmTotpAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		enabled       bool
+		notEnrolled   bool
+		code          func(t *testing.T, secret string) string
+		buildStubs    func(store *mockdb.MockStore, user db.User)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			code: currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					EnableTotpTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.EnableTotpTxParams) (db.EnableTotpTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, util.TOTPStep(time.Now()), arg.Step)
+						require.Len(t, arg.HashedRecoveryCodes, recoveryCodeCount)
+						user.IsTotpEnabled = true
+						return db.EnableTotpTxResult{User: user}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp confirmTotpResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Len(t, rsp.RecoveryCodes, recoveryCodeCount)
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: func(t *testing.T, secret string) string {
+				return "000000"
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().EnableTotpTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "CodeAlreadyUsed",
+			code: currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().EnableTotpTx(gomock.Any(), gomock.Any()).Times(1).Return(db.EnableTotpTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "NotEnrolled",
+			notEnrolled: true,
+			code:        currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().EnableTotpTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:    "AlreadyEnabled",
+			enabled: true,
+			code:    currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().EnableTotpTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			secret, err := util.GenerateTOTPSecret()
+			require.NoError(t, err)
+			user := randomTotpUser(t, server, secret, tc.enabled)
+			if tc.notEnrolled {
+				user.TotpSecret = pgtype.Text{}
+			}
+			tc.buildStubs(store, user)
+
+			data, err := json.Marshal(gin.H{"code": tc.code(t, secret)})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/mfa/totp/confirm", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestDisableTotpAPI(t *testing.T) {
+	recoveryCode := "abcde-fghjk"
+
+	testCases := []struct {
+		name          string
+		enabled       bool
+		code          func(t *testing.T, secret string) string
+		buildStubs    func(store *mockdb.MockStore, user db.User)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OKWithTotpCode",
+			enabled: true,
+			code:    currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UseUserTotpStep(gomock.Any(), gomock.Eq(db.UseUserTotpStepParams{Username: user.Username, TotpLastStep: util.TOTPStep(time.Now())})).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().DisableTotpTx(gomock.Any(), gomock.Eq(db.DisableTotpTxParams{Username: user.Username})).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "OKWithRecoveryCode",
+			enabled: true,
+			code: func(t *testing.T, secret string) string {
+				return recoveryCode
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseUserTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{Username: user.Username, HashedCode: util.HashRecoveryCode(recoveryCode)})).
+					Times(1)
+				store.EXPECT().DisableTotpTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:    "InvalidCode",
+			enabled: true,
+			code: func(t *testing.T, secret string) string {
+				return "000000"
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Any()).Times(1).Return(db.RecoveryCode{}, db.ErrRecordNotFound)
+				store.EXPECT().DisableTotpTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "NotEnabled",
+			code: currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().DisableTotpTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			secret, err := util.GenerateTOTPSecret()
+			require.NoError(t, err)
+			user := randomTotpUser(t, server, secret, tc.enabled)
+			tc.buildStubs(store, user)
+
+			data, err := json.Marshal(gin.H{"code": tc.code(t, secret)})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodDelete, "/users/mfa/totp", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestLoginUserMfaAPI(t *testing.T) {
+	recoveryCode := "abcde-fghjk"
+
+	testCases := []struct {
+		name          string
+		mfaToken      func(t *testing.T, server *Server, user db.User) string
+		code          func(t *testing.T, secret string) string
+		buildStubs    func(store *mockdb.MockStore, user db.User)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User)
+	}{
+		{
+			name:     "OKWithTotpCode",
+			mfaToken: randomMfaToken,
+			code:     currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseUserTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(user, nil)
+				store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLogin(t, recorder.Body, user)
+			},
+		},
+		{
+			name:     "OKWithRecoveryCode",
+			mfaToken: randomMfaToken,
+			code: func(t *testing.T, secret string) string {
+				return recoveryCode
+			},
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					UseRecoveryCode(gomock.Any(), gomock.Eq(db.UseRecoveryCodeParams{Username: user.Username, HashedCode: util.HashRecoveryCode(recoveryCode)})).
+					Times(1)
+				store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchLogin(t, recorder.Body, user)
+			},
+		},
+		{
+			name:     "CodeAlreadyUsed",
+			mfaToken: randomMfaToken,
+			code:     currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseUserTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "AccessToken",
+			mfaToken: func(t *testing.T, server *Server, user db.User) string {
+				accessToken, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, time.Minute)
+				require.NoError(t, err)
+				return accessToken
+			},
+			code: currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MfaTokenAlreadyUsed",
+			mfaToken: func(t *testing.T, server *Server, user db.User) string {
+				mfaToken, mfaPayload, err := server.tokenMaker.CreateToken(user.Username, mfaPendingRole, time.Minute)
+				require.NoError(t, err)
+				require.NoError(t, server.revocations.RevokeToken(context.Background(), mfaPayload))
+				return mfaToken
+			},
+			code: currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "ExpiredMfaToken",
+			mfaToken: func(t *testing.T, server *Server, user db.User) string {
+				mfaToken, _, err := server.tokenMaker.CreateToken(user.Username, mfaPendingRole, -time.Minute)
+				require.NoError(t, err)
+				return mfaToken
+			},
+			code: currentTotpCode,
+			buildStubs: func(store *mockdb.MockStore, user db.User) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder, user db.User) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			secret, err := util.GenerateTOTPSecret()
+			require.NoError(t, err)
+			user := randomTotpUser(t, server, secret, true)
+			tc.buildStubs(store, user)
+
+			data, err := json.Marshal(gin.H{
+				"mfa_token": tc.mfaToken(t, server, user),
+				"code":      tc.code(t, secret),
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/users/login/mfa", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder, user)
+		})
+	}
+}
+
+func randomMfaToken(t *testing.T, server *Server, user db.User) string {
+	mfaToken, _, err := server.tokenMaker.CreateToken(user.Username, mfaPendingRole, time.Minute)
+	require.NoError(t, err)
+	return mfaToken
+}
+
+func requireBodyMatchLogin(t *testing.T, body *bytes.Buffer, user db.User) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var rsp loginUserResponse
+	err = json.Unmarshal(data, &rsp)
+	require.NoError(t, err)
+	require.NotEmpty(t, rsp.AccessToken)
+	require.NotEmpty(t, rsp.RefreshToken)
+	require.Equal(t, user.Username, rsp.User.Username)
+}
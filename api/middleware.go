@@ -4,20 +4,29 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"regexp"
+	"simplebank/revocation"
 	"simplebank/token"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	requestIDHeaderKey      = "X-Request-ID"
+	requestIDKey            = "request_id"
+	maxRequestIDLength      = 128
 )
 
+// validRequestID matches the request IDs kept from the client, which end up in the audit log and the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 // authMiddleware is a middleware function that verifies the authorization token.
-// It checks the "Authorization" header and validates the token.
+// It checks the "Authorization" header, validates the token and refuses it once revoked.
 // @Summary Authenticate API requests
 // @Description Middleware to authenticate requests using Bearer tokens. Validates the token and sets the payload in the context.
 // @Tags auth
@@ -26,7 +35,7 @@ const (
 // @Failure 401 {object} gin.H "Unauthorized"
 // @Router / [get]  // This is a placeholder; actual routing does not apply to middleware.
 // @Security BearerAuth
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, revocations *revocation.List) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 		if len(authorizationHeader) == 0 {
@@ -55,12 +64,62 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		accessToken := fields[1]
 		payload, err := tokenMaker.VerifyToken(accessToken)
 		if err != nil {
+			// tell the client why the token was refused, as RFC 6750 does it
+			ctx.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, tokenErrorReason(err)))
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
 			return
 		}
 
+		// the token of a login waiting for its second factor opens nothing
+		if payload.Role == mfaPendingRole {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(errMfaRequired))
+			return
+		}
+
+		// the token may have been revoked by a logout or a password change before it expires
+		err = revocations.Check(ctx, payload)
+		if err != nil {
+			if errors.Is(err, revocation.ErrRevokedToken) {
+				ctx.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="invalid_token", error_description=%q`, tokenErrorReason(err)))
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
 		// payload is stored into a gin context with this specific key. To be used in the handlers.
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// tokenErrorReason returns the reason a token was refused, without the details of a malformed token
+func tokenErrorReason(err error) string {
+	switch {
+	case errors.Is(err, token.ErrExpiredToken),
+		errors.Is(err, token.ErrTokenNotYetValid),
+		errors.Is(err, token.ErrInvalidIssuer),
+		errors.Is(err, token.ErrInvalidAudience),
+		errors.Is(err, revocation.ErrRevokedToken):
+		return err.Error()
+	default:
+		return token.ErrInvalidToken.Error()
+	}
+}
+
+// requestIDMiddleware gives every request an ID, the one of the X-Request-ID header when the client or a proxy sent one.
+// A header that is too long or has other characters than letters, digits, '-' and '_' is replaced by a new ID.
+// The ID is sent back in the same header and written in the audit events of the request.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeaderKey)
+		if len(requestID) > maxRequestIDLength || !validRequestID.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeaderKey, requestID)
+		ctx.Next()
+	}
+}
@@ -1,9 +1,11 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"simplebank/revocation"
 	"simplebank/token"
 	"simplebank/util"
 	"testing"
@@ -65,6 +67,15 @@ func TestAuthMiddleware(t *testing.T) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
 			},
 		},
+		{
+			name: "MfaPendingToken",
+			SetupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, mfaPendingRole, "user", time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
 	}
 
 	for i := range testCases {
@@ -76,7 +87,7 @@ func TestAuthMiddleware(t *testing.T) {
 			authPath := "/auth"
 			server.router.GET(
 				authPath,
-				authMiddleware(server.tokenMaker),
+				authMiddleware(server.tokenMaker, server.revocations),
 				func(ctx *gin.Context) {
 					ctx.JSON(http.StatusOK, gin.H{})
 				},
@@ -93,6 +104,162 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestAuthMiddlewareClaims(t *testing.T) {
+	testCases := []struct {
+		name          string
+		opts          []token.Option
+		duration      time.Duration
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			opts:     []token.Option{token.WithIssuer("simplebank-test"), token.WithAudience("simplebank-api")},
+			duration: time.Minute,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidIssuer",
+			opts:     []token.Option{token.WithIssuer("simplebank-staging"), token.WithAudience("simplebank-api")},
+			duration: time.Minute,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireTokenRefused(t, recorder, token.ErrInvalidIssuer)
+			},
+		},
+		{
+			name:     "InvalidAudience",
+			opts:     []token.Option{token.WithIssuer("simplebank-test"), token.WithAudience("simplebank-admin")},
+			duration: time.Minute,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireTokenRefused(t, recorder, token.ErrInvalidAudience)
+			},
+		},
+		{
+			name:     "ExpiredToken",
+			opts:     []token.Option{token.WithIssuer("simplebank-test"), token.WithAudience("simplebank-api")},
+			duration: -time.Minute,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireTokenRefused(t, recorder, token.ErrExpiredToken)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			authPath := "/auth"
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			// a token minted by another environment sharing the same key
+			tokenMaker, err := token.NewPasetoMaker(server.config.TokenSymmetricKey, tc.opts...)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.DepositorRole, "user", tc.duration)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAuthMiddlewareRevocation(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name          string
+		setupAuth     func(t *testing.T, request *http.Request, server *Server)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "RevokedToken",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				accessToken, payload, err := server.tokenMaker.CreateToken(username, util.DepositorRole, time.Minute)
+				require.NoError(t, err)
+				require.NoError(t, server.revocations.RevokeToken(context.Background(), payload))
+
+				request.Header.Set(authorizationHeaderKey, fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireTokenRefused(t, recorder, revocation.ErrRevokedToken)
+			},
+		},
+		{
+			name: "RevokedUserTokens",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.DepositorRole, username, time.Minute)
+				require.NoError(t, server.revocations.RevokeUserTokens(context.Background(), username))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				requireTokenRefused(t, recorder, revocation.ErrRevokedToken)
+			},
+		},
+		{
+			name: "IssuedAfterRevocation",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				require.NoError(t, server.revocations.RevokeUserTokens(context.Background(), username))
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.DepositorRole, username, time.Minute)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "OtherUserRevoked",
+			setupAuth: func(t *testing.T, request *http.Request, server *Server) {
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, util.DepositorRole, username, time.Minute)
+				require.NoError(t, server.revocations.RevokeUserTokens(context.Background(), util.RandomOwner()))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+
+			authPath := "/auth"
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(http.MethodGet, authPath, nil)
+			require.NoError(t, err)
+
+			tc.setupAuth(t, request, server)
+			server.router.GET(
+				authPath,
+				authMiddleware(server.tokenMaker, server.revocations),
+				func(ctx *gin.Context) {
+					ctx.JSON(http.StatusOK, gin.H{})
+				},
+			)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func requireTokenRefused(t *testing.T, recorder *httptest.ResponseRecorder, reason error) {
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Header().Get("WWW-Authenticate"), reason.Error())
+	require.JSONEq(t, fmt.Sprintf(`{"error":%q}`, reason.Error()), recorder.Body.String())
+}
+
 func addAuthorization(
 	t *testing.T,
 	request *http.Request,
@@ -111,3 +278,38 @@ func addAuthorization(
 	// set the header
 	request.Header.Set(authorizationHeaderKey, authorizationHeader)
 }
+
+func TestRequestIDMiddleware(t *testing.T) {
+	router := gin.New()
+	router.Use(requestIDMiddleware())
+	router.GET("/request_id", func(ctx *gin.Context) {
+		ctx.String(http.StatusOK, ctx.GetString(requestIDKey))
+	})
+
+	// the ID sent by the client is kept
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/request_id", nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeaderKey, "abc")
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, "abc", recorder.Body.String())
+	require.Equal(t, "abc", recorder.Header().Get(requestIDHeaderKey))
+
+	request.Header.Set(requestIDHeaderKey, "a1-B2_c3")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	require.Equal(t, "a1-B2_c3", recorder.Body.String())
+
+	// a new ID is made when there is none, it is too long or it has other characters
+	for _, requestID := range []string{"", util.RandomString(maxRequestIDLength + 1), "abc def", "abc\"><script>", "abc;DROP TABLE"} {
+		recorder = httptest.NewRecorder()
+		request, err = http.NewRequest(http.MethodGet, "/request_id", nil)
+		require.NoError(t, err)
+		request.Header.Set(requestIDHeaderKey, requestID)
+		router.ServeHTTP(recorder, request)
+
+		generated := recorder.Header().Get(requestIDHeaderKey)
+		require.Len(t, generated, 36)
+		require.Equal(t, generated, recorder.Body.String())
+	}
+}
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"simplebank/worker"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errInvalidResetCode is returned when a password reset code is wrong, expired or already used
+var errInvalidResetCode = errors.New("invalid or expired reset code")
+
+// forgotPasswordRequest defines the request body for asking a password reset
+// @Description Request body for asking a password reset code by email
+// @Param email body string true "Email of the user" example("johndoe@example.com")
+// @Accept json
+// @Produce json
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// forgotPasswordResponse defines the response body for a password reset request
+// @Description Response body for a password reset request. It is the same whether the email belongs to a user or not.
+// @Property message string "Outcome of the request"
+type forgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// forgotPassword sends a single-use password reset code to the email of a user
+// @Summary Forgot Password
+// @Description Email a single-use, expiring reset code to the user owning the email. The response doesn't tell whether the email is registered.
+// @Description A user gets at most one reset code per cooldown, asking again sooner gets the same answer but no email.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body forgotPasswordRequest true "Forgot Password Request"
+// @Success 200 {object} forgotPasswordResponse "Reset code sent if the email is registered"
+// @Failure 400 {object} gin.H "Bad Request - Invalid input data"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Router /users/password/forgot [post]
+func (server *Server) forgotPassword(ctx *gin.Context) {
+	var req forgotPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	rsp := forgotPasswordResponse{
+		Message: "if the email is registered, a reset code was sent to it",
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// Don't reveal which emails are registered
+			ctx.JSON(http.StatusOK, rsp)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// generate a single-use reset code
+	secretCode, err := util.GenerateSecretCode(32)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the reset email is enqueued by the outbox relay once the reset is committed
+	_, err = server.store.CreatePasswordResetTx(ctx, db.CreatePasswordResetTxParams{
+		CreatePasswordResetParams: db.CreatePasswordResetParams{
+			Username:   user.Username,
+			Email:      user.Email,
+			SecretCode: secretCode,
+		},
+		Cooldown: server.config.PasswordResetCooldown,
+		Outbox:   resetPasswordOutbox,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrCooldown) {
+			// no new email, and the same answer so that a cooldown doesn't reveal the email is registered
+			ctx.JSON(http.StatusOK, rsp)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// resetPasswordOutbox builds the task emailing the code of a password reset, to be written in the transaction creating it
+func resetPasswordOutbox(passwordReset db.PasswordReset) ([]db.CreateOutboxMessageParams, error) {
+	resetEmail, err := worker.NewOutboxMessage(
+		worker.TaskSendResetPassword,
+		&worker.PayloadSendResetPassword{Username: passwordReset.Username, ResetID: passwordReset.ID},
+		worker.QueueCritical,
+		10,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []db.CreateOutboxMessageParams{resetEmail}, nil
+}
+
+// resetPasswordRequest defines the request body for resetting a password
+// @Description Request body for choosing a new password with a reset code
+// @Param reset_id body int64 true "ID of the password reset sent by email" example(12345)
+// @Param secret_code body string true "Reset code sent by email" example("abcd1234")
+// @Param password body string true "New password of the user" example("newpassword123")
+// @Accept json
+// @Produce json
+type resetPasswordRequest struct {
+	ResetID    int64  `json:"reset_id" binding:"required,min=1"`
+	SecretCode string `json:"secret_code" binding:"required"`
+	Password   string `json:"password" binding:"required,min=6"`
+}
+
+// resetPassword sets a new password with a reset code, blocks every session of the user and revokes its access tokens
+// @Summary Reset Password
+// @Description Set a new password with the single-use code sent by email. Every existing session of the user is blocked and its access tokens are revoked. A reset is used up after 5 wrong codes, a new one has to be asked for then.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body resetPasswordRequest true "Reset Password Request"
+// @Success 200 {object} userResponse "Password reset successfully"
+// @Failure 400 {object} gin.H "Bad Request - Invalid input data or invalid reset code"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Router /users/password/reset [post]
+func (server *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.Password)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	arg := db.ResetPasswordTxParams{
+		ResetID:        req.ResetID,
+		SecretCode:     req.SecretCode,
+		HashedPassword: hashedPassword,
+	}
+
+	result, err := server.store.ResetPasswordTx(auditContext(ctx), arg)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidResetCode))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.revocations.RevokeUserTokens(ctx, result.User.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(result.User))
+}
@@ -0,0 +1,363 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"simplebank/worker"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// eqResetPasswordTxParamsMatcher will check the new password and then the other fields of the ResetPasswordTx arg
+type eqResetPasswordTxParamsMatcher struct {
+	arg      db.ResetPasswordTxParams
+	password string
+}
+
+func (e eqResetPasswordTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.ResetPasswordTxParams)
+	if !ok {
+		return false
+	}
+
+	err := util.CheckPassword(e.password, arg.HashedPassword)
+	if err != nil {
+		return false
+	}
+
+	e.arg.HashedPassword = arg.HashedPassword
+	return reflect.DeepEqual(e.arg, arg)
+}
+
+func (e eqResetPasswordTxParamsMatcher) String() string {
+	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
+}
+
+func EqResetPasswordTxParams(arg db.ResetPasswordTxParams, password string) gomock.Matcher {
+	return eqResetPasswordTxParamsMatcher{arg, password}
+}
+
+func TestForgotPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t, util.DepositorRole)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreatePasswordResetTxParams) (db.CreatePasswordResetTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, user.Email, arg.Email)
+						require.Len(t, arg.SecretCode, 32)
+						require.Equal(t, time.Minute, arg.Cooldown)
+
+						// the reset email names the reset created in the same transaction
+						passwordReset := db.PasswordReset{ID: util.RandomInt(1, 1000), Username: arg.Username, Email: arg.Email, SecretCode: arg.SecretCode}
+						outbox, err := arg.Outbox(passwordReset)
+						require.NoError(t, err)
+						require.Len(t, outbox, 1)
+						require.Equal(t, worker.TaskSendResetPassword, outbox[0].TaskType)
+
+						var payload worker.PayloadSendResetPassword
+						require.NoError(t, json.Unmarshal(outbox[0].Payload, &payload))
+						require.Equal(t, worker.PayloadSendResetPassword{Username: user.Username, ResetID: passwordReset.ID}, payload)
+
+						return db.CreatePasswordResetTxResult{PasswordReset: passwordReset}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// same answer as a registered email
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Cooldown",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreatePasswordResetTxResult{}, db.ErrCooldown)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// same answer as a reset that was sent
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"email": "invalid-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "GetUserError",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.User{}, fmt.Errorf("internal server error"))
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "CreatePasswordResetError",
+			body: gin.H{
+				"email": user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserByEmail(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					CreatePasswordResetTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CreatePasswordResetTxResult{}, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			server.config.PasswordResetCooldown = time.Minute
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/password/forgot"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestResetPasswordAPI(t *testing.T) {
+	user, _ := randomUser(t, util.DepositorRole)
+	passwordReset := randomPasswordReset(user)
+	newPassword := util.RandomString(8)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{
+				"reset_id":    passwordReset.ID,
+				"secret_code": passwordReset.SecretCode,
+				"password":    newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ResetPasswordTxParams{
+					ResetID:    passwordReset.ID,
+					SecretCode: passwordReset.SecretCode,
+				}
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), EqResetPasswordTxParams(arg, newPassword)).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, _ db.ResetPasswordTxParams) (db.ResetPasswordTxResult, error) {
+						// nobody is logged in, the audit event carries the request alone
+						actor := db.AuditActorFromContext(ctx)
+						require.Empty(t, actor.Username)
+						require.NotEmpty(t, actor.RequestID)
+						return db.ResetPasswordTxResult{User: user, PasswordReset: passwordReset}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchUser(t, recorder.Body, user)
+			},
+		},
+		{
+			name: "InvalidCode",
+			body: gin.H{
+				"reset_id":    passwordReset.ID,
+				"secret_code": util.RandomString(32),
+				"password":    newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "PasswordTooShort",
+			body: gin.H{
+				"reset_id":    passwordReset.ID,
+				"secret_code": passwordReset.SecretCode,
+				"password":    "123",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "MissingResetID",
+			body: gin.H{
+				"secret_code": passwordReset.SecretCode,
+				"password":    newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			body: gin.H{
+				"reset_id":    passwordReset.ID,
+				"secret_code": passwordReset.SecretCode,
+				"password":    newPassword,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ResetPasswordTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResetPasswordTxResult{}, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := "/users/password/reset"
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomPasswordReset(user db.User) db.PasswordReset {
+	return db.PasswordReset{
+		ID:         util.RandomInt(1, 1000),
+		Username:   user.Username,
+		Email:      user.Email,
+		SecretCode: util.RandomString(32),
+		IsUsed:     true,
+		CreatedAt:  time.Now(),
+		ExpiredAt:  time.Now().Add(15 * time.Minute),
+	}
+}
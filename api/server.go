@@ -1,11 +1,17 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/lockout"
+	"simplebank/revocation"
 	"simplebank/token"
 	"simplebank/util"
 	"simplebank/worker"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
@@ -16,6 +22,9 @@ import (
 	"github.com/swaggo/gin-swagger"
 )
 
+// shutdownTimeout is how long the requests in flight may take to finish once the server is stopped
+const shutdownTimeout = 30 * time.Second
+
 // Server configures and holds the HTTP server
 // @title Simple Bank API
 // @version 1.0
@@ -27,6 +36,8 @@ type Server struct {
 	config          util.Config
 	store           db.Store
 	tokenMaker      token.Maker
+	revocations     *revocation.List
+	loginGuard      *lockout.Guard
 	router          *gin.Engine
 	taskDistributor worker.TaskDistributor
 }
@@ -44,15 +55,31 @@ type Server struct {
 // @Failure 500 {object} gin.H "Internal server error"
 // @Router /server [post]
 func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+	if len(config.TotpEncryptionKey) != util.EncryptionKeySize {
+		return nil, fmt.Errorf("invalid TOTP encryption key size: must be exactly %d characters", util.EncryptionKeySize)
+	}
+
+	tokenMaker, err := newTokenMaker(config)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	revocationBackend, err := newRevocationBackend(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token revocation backend: %w", err)
+	}
+
+	lockoutBackend, err := newLockoutBackend(config, store)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create login lockout backend: %w", err)
+	}
+
 	server := &Server{
 		config:          config,
 		store:           store,
 		tokenMaker:      tokenMaker,
+		revocations:     revocation.NewList(revocationBackend, config.TokenRevocationCacheTTL),
+		loginGuard:      lockout.NewGuard(lockoutBackend, loginPolicy(config)),
 		taskDistributor: taskDistributor,
 	}
 
@@ -66,11 +93,103 @@ func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDi
 	}
 
 	// setup routes
-	server.setupRoutes()
+	err = server.setupRoutes()
+	if err != nil {
+		return nil, fmt.Errorf("cannot set up routes: %w", err)
+	}
 
 	return server, nil
 }
 
+// newTokenMaker builds the token maker of the TOKEN_TYPE config, PASETO v2.local when it is not set
+func newTokenMaker(config util.Config) (token.Maker, error) {
+	opts := tokenClaimsOptions(config)
+
+	switch config.TokenType {
+	case "", token.TypePaseto:
+		return token.NewPasetoMaker(config.TokenSymmetricKey, opts...)
+	case token.TypePasetoPublic:
+		// without a private key the service can check tokens but not log anyone in
+		if config.TokenPrivateKeyFile == "" {
+			verificationKeys, err := token.LoadEd25519PublicKeys(config.TokenPublicKeyFiles)
+			if err != nil {
+				return nil, err
+			}
+			return token.NewPasetoPublicVerifier(verificationKeys, opts...)
+		}
+
+		signingKey, verificationKeys, err := token.LoadEd25519Keys(config.TokenPrivateKeyFile, config.TokenPublicKeyFiles)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewPasetoPublicMaker(signingKey, verificationKeys, opts...)
+	case token.TypeJWTHS256:
+		return token.NewJWTMaker(config.TokenSymmetricKey, opts...)
+	case token.TypeJWTRS256:
+		privateKey, err := token.LoadRSAPrivateKey(config.TokenPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewRS256JWTMaker(privateKey, opts...)
+	case token.TypeJWTEdDSA:
+		privateKey, _, err := token.LoadEd25519Keys(config.TokenPrivateKeyFile, nil)
+		if err != nil {
+			return nil, err
+		}
+		return token.NewEdDSAJWTMaker(privateKey, opts...)
+	default:
+		return nil, fmt.Errorf("unsupported token type %q", config.TokenType)
+	}
+}
+
+// tokenClaimsOptions turns the TOKEN_ISSUER, TOKEN_AUDIENCE, TOKEN_ALLOWED_* and TOKEN_CLOCK_SKEW configs into token maker options
+func tokenClaimsOptions(config util.Config) []token.Option {
+	opts := []token.Option{
+		token.WithIssuer(config.TokenIssuer),
+		token.WithAudience(config.TokenAudience...),
+		token.WithAllowedIssuers(config.TokenAllowedIssuers...),
+		token.WithAllowedAudiences(config.TokenAllowedAudiences...),
+	}
+	if config.TokenClockSkew > 0 {
+		opts = append(opts, token.WithClockSkew(config.TokenClockSkew))
+	}
+	return opts
+}
+
+// newRevocationBackend builds the token revocation backend of the TOKEN_REVOCATION_BACKEND config, Postgres when it is not set
+func newRevocationBackend(config util.Config, store db.Store) (revocation.Backend, error) {
+	switch config.TokenRevocationBackend {
+	case "", revocation.BackendPostgres:
+		return revocation.NewPostgresBackend(store), nil
+	case revocation.BackendMemory:
+		return revocation.NewMemoryBackend(), nil
+	default:
+		return nil, fmt.Errorf("unsupported token revocation backend %q", config.TokenRevocationBackend)
+	}
+}
+
+// newLockoutBackend builds the failed login counters of the LOGIN_LOCKOUT_BACKEND config, Postgres when it is not set
+func newLockoutBackend(config util.Config, store db.Store) (lockout.Backend, error) {
+	switch config.LoginLockoutBackend {
+	case "", lockout.BackendPostgres:
+		return lockout.NewPostgresBackend(store), nil
+	case lockout.BackendMemory:
+		return lockout.NewMemoryBackend(), nil
+	default:
+		return nil, fmt.Errorf("unsupported login lockout backend %q", config.LoginLockoutBackend)
+	}
+}
+
+// loginPolicy turns the LOGIN_* configs into a lockout policy, the defaults fill the ones that are not set
+func loginPolicy(config util.Config) lockout.Policy {
+	return lockout.Policy{
+		MaxUsernameFailures: config.LoginMaxUsernameFailures,
+		MaxIPFailures:       config.LoginMaxIPFailures,
+		BackoffBase:         config.LoginBackoffBase,
+		LockoutDuration:     config.LoginLockoutDuration,
+	}
+}
+
 // setupRoutes initializes the routes for the server and Swagger documentation
 // @Summary Setup API routes
 // @Description Configure API routes and Swagger documentation
@@ -78,33 +197,86 @@ func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDi
 // @Accept json
 // @Produce json
 // @Router / [get]
-func (server *Server) setupRoutes() {
+func (server *Server) setupRoutes() error {
 	router := gin.Default()
 
+	// the client IP is read from X-Forwarded-For only behind the configured proxies,
+	// or any client could pick the IP its failed logins are counted for
+	err := router.SetTrustedProxies(server.config.TrustedProxies)
+	if err != nil {
+		return err
+	}
+
+	router.Use(requestIDMiddleware())
+
 	// User routes
 	router.POST("/users", server.createUser)                     // Creates a new user
 	router.POST("/users/login", server.loginUser)                // User login
+	router.POST("/users/login/mfa", server.loginUserMfa)         // Complete a login with a second factor
 	router.POST("/tokens/renew_access", server.RenewAccessToken) // Renew access token
 	router.GET("/verify_email", server.verifyEmail)              // Verify email
+	router.POST("/users/password/forgot", server.forgotPassword) // Email a password reset code
+	router.POST("/users/password/reset", server.resetPassword)   // Set a new password with a reset code
 
 	// Swagger endpoint
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler)) // Swagger documentation
 
 	// Middleware authentication
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocations))
+
+	// Authenticated routes, each declaring the permission its role needs
+	authRoutes.POST("/accounts", requirePermission(util.PermAccountsCreateOwn), server.requireVerifiedEmail, server.createAccount)    // Create a new account
+	authRoutes.GET("/accounts/:id", requirePermission(util.PermAccountsReadOwn), server.getAccount)                                   // Get account details by ID
+	authRoutes.GET("/accounts", requirePermission(util.PermAccountsReadOwn), server.listAccounts)                                     // List all accounts
+	authRoutes.PATCH("/users/update", requirePermission(util.PermUsersUpdateOwn), server.updateUser)                                  // Update user information
+	authRoutes.POST("/transfers", requirePermission(util.PermTransfersCreateOwn), server.requireVerifiedEmail, server.createTransfer) // Create a new transfer
+	authRoutes.POST("/users/verify_email/resend", server.resendVerifyEmail)                                                           // Send a fresh verification email
+
+	authRoutes.POST("/accounts/:id/deposits", requirePermission(util.PermEntriesCreateAny), server.createDeposit)                          // Deposit money into an account
+	authRoutes.POST("/accounts/:id/withdrawals", requirePermission(util.PermEntriesCreateAny), server.createWithdrawal)                    // Withdraw money from an account
+	authRoutes.POST("/accounts/:id/close", requirePermission(util.PermAccountsCloseOwn), server.requireVerifiedEmail, server.closeAccount) // Close an account
+	authRoutes.PATCH("/accounts/:id/overdraft_limit", requirePermission(util.PermAccountsUpdateAny), server.updateOverdraftLimit)          // Set the overdraft limit of an account
+	authRoutes.GET("/accounts/:id/transfers", requirePermission(util.PermTransfersReadOwn), server.listAccountTransfers)                   // List the transfers of an account
+	authRoutes.GET("/transfers/:id", requirePermission(util.PermTransfersReadOwn), server.getTransfer)                                     // Get transfer details by ID
+	authRoutes.GET("/accounts/:id/statement", requirePermission(util.PermStatementsReadOwn), server.getAccountStatement)                   // Get the statement of an account
+
+	authRoutes.GET("/fx_rates", requirePermission(util.PermFxRatesReadAny), server.listFxRates)                                      // List the FX rates
+	authRoutes.PUT("/fx_rates", requirePermission(util.PermFxRatesUpdateAny), server.upsertFxRate)                                   // Set the FX rate of a currency pair
+	authRoutes.DELETE("/fx_rates/:base_currency/:quote_currency", requirePermission(util.PermFxRatesDeleteAny), server.deleteFxRate) // Delete the FX rate of a currency pair
+	authRoutes.POST("/fx_quotes", requirePermission(util.PermFxQuotesCreateOwn), server.createFxQuote)                               // Lock an FX rate before a transfer
 
-	// Authenticated routes
-	authRoutes.POST("/accounts", server.createAccount)   // Create a new account
-	authRoutes.GET("/accounts/:id", server.getAccount)   // Get account details by ID
-	authRoutes.GET("/accounts", server.listAccounts)     // List all accounts
-	authRoutes.PATCH("/users/update", server.updateUser) // Update user information
-	authRoutes.POST("/transfers", server.createTransfer) // Create a new transfer
+	authRoutes.POST("/users/logout", server.logoutUser)                                                                     // End the session of a refresh token
+	authRoutes.GET("/users/sessions", server.listUserSessions)                                                              // List the active sessions of the user
+	authRoutes.DELETE("/users/sessions/:id", server.revokeSession)                                                          // End a session of the user
+	authRoutes.DELETE("/users/:username/sessions", requirePermission(util.PermSessionsDeleteAny), server.blockUserSessions) // End every session of a user
+	authRoutes.PUT("/users/:username/role", requirePermission(util.PermUsersChangeRoleAny), server.changeUserRole)          // Promote or demote a user
+	authRoutes.GET("/lockouts", requirePermission(util.PermLockoutsReadAny), server.listLockouts)                           // List the usernames and IPs locked out of login
+	authRoutes.DELETE("/lockouts/:kind/:value", requirePermission(util.PermLockoutsDeleteAny), server.clearLockout)         // Clear the lockout of a username or an IP
+
+	authRoutes.POST("/users/mfa/totp", server.enrollTotp)          // Generate a TOTP secret
+	authRoutes.POST("/users/mfa/totp/confirm", server.confirmTotp) // Enable two-factor authentication with a first code
+	authRoutes.DELETE("/users/mfa/totp", server.disableTotp)       // Disable two-factor authentication
+
+	// Back office routes, for the roles granted the back office
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.tokenMaker, server.revocations), backOfficeMiddleware())
+
+	adminRoutes.GET("/users", requirePermission(util.PermUsersReadAny), server.searchUsers)                                       // Search the users
+	adminRoutes.GET("/users/:username/accounts", requirePermission(util.PermAccountsReadAny), server.listUserAccounts)            // List the accounts of a user
+	adminRoutes.GET("/users/:username/transfers", requirePermission(util.PermTransfersReadAny), server.listUserTransfers)         // List the transfers of a user
+	adminRoutes.GET("/accounts/:id", requirePermission(util.PermAccountsReadAny), server.getAnyAccount)                           // Get any account
+	adminRoutes.POST("/accounts/:id/freeze", requirePermission(util.PermAccountsFreezeAny), server.freezeAccount)                 // Freeze an account
+	adminRoutes.POST("/accounts/:id/unfreeze", requirePermission(util.PermAccountsFreezeAny), server.unfreezeAccount)             // Unfreeze an account
+	adminRoutes.POST("/accounts/:id/reopen", requirePermission(util.PermAccountsReopenAny), server.reopenAccount)                 // Reopen a closed account
+	adminRoutes.GET("/accounts/:id/status_changes", requirePermission(util.PermAccountsReadAny), server.listAccountStatusChanges) // List the status history of an account
+	adminRoutes.GET("/audit", requirePermission(util.PermAuditEventsReadAny), server.listAuditEvents)                             // List the audit log
 
 	// Set router to the server
 	server.router = router
+	return nil
 }
 
-// Start runs the HTTP server on the specified address
+// Start runs the HTTP server on the specified address until ctx is done,
+// then stops accepting requests and waits up to shutdownTimeout for the ones in flight
 // @Summary Start the HTTP server
 // @Description Starts the HTTP server and listens on the specified address
 // @Tags server
@@ -114,8 +286,34 @@ func (server *Server) setupRoutes() {
 // @Success 200 {string} string "Server started successfully"
 // @Failure 500 {object} gin.H "Internal server error"
 // @Router /start [get]
-func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+func (server *Server) Start(ctx context.Context, address string) error {
+	httpServer := &http.Server{
+		Addr:    address,
+		Handler: server.router,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return err
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // errorResponse formats an error message for JSON response
@@ -0,0 +1,239 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/lockout"
+	"simplebank/revocation"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTokenMaker(t *testing.T) {
+	dir := t.TempDir()
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	privateKeyFile := filepath.Join(dir, "token_key.pem")
+	err = os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaKeyFile := filepath.Join(dir, "token_rsa_key.pem")
+	err = os.WriteFile(rsaKeyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}), 0600)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		config      util.Config
+		tokenPrefix string
+		wantErr     bool
+	}{
+		{
+			name:        "Symmetric",
+			config:      util.Config{TokenSymmetricKey: util.RandomString(32)},
+			tokenPrefix: "v2.local.",
+		},
+		{
+			name:        "Paseto",
+			config:      util.Config{TokenType: token.TypePaseto, TokenSymmetricKey: util.RandomString(32)},
+			tokenPrefix: "v2.local.",
+		},
+		{
+			name:        "PasetoPublic",
+			config:      util.Config{TokenType: token.TypePasetoPublic, TokenPrivateKeyFile: privateKeyFile},
+			tokenPrefix: "v4.public.",
+		},
+		{
+			// base64url of {"alg":"HS256","typ":"JWT"}
+			name:        "JWTHS256",
+			config:      util.Config{TokenType: token.TypeJWTHS256, TokenSymmetricKey: util.RandomString(32)},
+			tokenPrefix: "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.",
+		},
+		{
+			name:        "JWTRS256",
+			config:      util.Config{TokenType: token.TypeJWTRS256, TokenPrivateKeyFile: rsaKeyFile},
+			tokenPrefix: "eyJhbGciOiJSUzI1NiIsInR5cCI6IkpXVCJ9.",
+		},
+		{
+			name:        "JWTEdDSA",
+			config:      util.Config{TokenType: token.TypeJWTEdDSA, TokenPrivateKeyFile: privateKeyFile},
+			tokenPrefix: "eyJhbGciOiJFZERTQSIsInR5cCI6IkpXVCJ9.",
+		},
+		{
+			name:    "MissingKeyFile",
+			config:  util.Config{TokenType: token.TypePasetoPublic, TokenPrivateKeyFile: filepath.Join(dir, "missing.pem")},
+			wantErr: true,
+		},
+		{
+			// a verify only maker needs at least one public key
+			name:    "PasetoPublicWithoutKeys",
+			config:  util.Config{TokenType: token.TypePasetoPublic},
+			wantErr: true,
+		},
+		{
+			name:    "WrongKeyType",
+			config:  util.Config{TokenType: token.TypeJWTRS256, TokenPrivateKeyFile: privateKeyFile},
+			wantErr: true,
+		},
+		{
+			name:    "UnknownType",
+			config:  util.Config{TokenType: "saml", TokenSymmetricKey: util.RandomString(32)},
+			wantErr: true,
+		},
+		{
+			name:    "InvalidSymmetricKey",
+			config:  util.Config{TokenSymmetricKey: util.RandomString(6)},
+			wantErr: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := newTokenMaker(tc.config)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			accessToken, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(accessToken, tc.tokenPrefix))
+
+			payload, err := maker.VerifyToken(accessToken)
+			require.NoError(t, err)
+			require.IsType(t, &token.Payload{}, payload)
+		})
+	}
+}
+
+func TestNewRevocationBackend(t *testing.T) {
+	testCases := []struct {
+		name     string
+		backend  string
+		wantType revocation.Backend
+		wantErr  bool
+	}{
+		{
+			name:     "Default",
+			backend:  "",
+			wantType: &revocation.PostgresBackend{},
+		},
+		{
+			name:     "Postgres",
+			backend:  revocation.BackendPostgres,
+			wantType: &revocation.PostgresBackend{},
+		},
+		{
+			name:     "Memory",
+			backend:  revocation.BackendMemory,
+			wantType: &revocation.MemoryBackend{},
+		},
+		{
+			name:    "Unknown",
+			backend: "redis",
+			wantErr: true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			backend, err := newRevocationBackend(util.Config{TokenRevocationBackend: tc.backend}, nil)
+			if tc.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.IsType(t, tc.wantType, backend)
+		})
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	proxyIP := "203.0.113.20"
+	forwardedIP := "198.51.100.7"
+
+	testCases := []struct {
+		name           string
+		trustedProxies []string
+		countedIP      string
+		ignoredIP      string
+	}{
+		{
+			name:      "NoneByDefault",
+			countedIP: proxyIP,
+			ignoredIP: forwardedIP,
+		},
+		{
+			name:           "TrustedProxy",
+			trustedProxies: []string{proxyIP},
+			countedIP:      forwardedIP,
+			ignoredIP:      proxyIP,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, db.ErrRecordNotFound)
+
+			server := newTestServer(t, store)
+			server.config.TrustedProxies = tc.trustedProxies
+			require.NoError(t, server.setupRoutes())
+
+			backend := lockout.NewMemoryBackend()
+			server.loginGuard = lockout.NewGuard(backend, lockout.Policy{})
+
+			data, err := json.Marshal(gin.H{"username": util.RandomOwner(), "password": "incorrect"})
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/users/login", bytes.NewReader(data))
+			require.NoError(t, err)
+			request.RemoteAddr = proxyIP + ":40000"
+			request.Header.Set("X-Forwarded-For", forwardedIP)
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+			counted, err := backend.GetAttempts(context.Background(), lockout.KindIP, tc.countedIP)
+			require.NoError(t, err)
+			require.Equal(t, 1, counted.Failures)
+
+			ignored, err := backend.GetAttempts(context.Background(), lockout.KindIP, tc.ignoredIP)
+			require.NoError(t, err)
+			require.Zero(t, ignored.Failures)
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// sessionResponse defines the response body for a session, without its refresh token
+// @Description Response body containing session details
+// @Property id string "Session ID" example("d3f1e8e0-30ae-4d34-8b35-69115b43d8a9")
+// @Property user_agent string "User agent of the client that logged in"
+// @Property client_ip string "IP of the client that logged in" example("203.0.113.7")
+// @Property is_blocked boolean "Whether the session was ended"
+// @Property expires_at string "Expiration time of the refresh token" example("2024-08-30T12:00:00Z")
+// @Property created_at string "Login time" example("2024-07-31T12:00:00Z")
+type sessionResponse struct {
+	ID        uuid.UUID `json:"id"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newSessionResponse converts a database session to a sessionResponse
+func newSessionResponse(session db.Session) sessionResponse {
+	return sessionResponse{
+		ID:        session.ID,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+		CreatedAt: session.CreatedAt,
+	}
+}
+
+// logoutUserRequest defines the request body for logging out
+// @Description Request body for logging out
+// @Param refresh_token body string true "Refresh token of the session to end"
+// @Accept json
+// @Produce json
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// logoutUser blocks the session of the given refresh token and revokes the access token of the request
+// @Summary User Logout
+// @Description End the session of the refresh token, which can't renew access tokens anymore. The access token of the request is revoked.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body logoutUserRequest true "Logout User Request"
+// @Success 200 {object} gin.H "Session ended"
+// @Failure 400 {object} gin.H "Bad Request - Invalid input data"
+// @Failure 401 {object} gin.H "Unauthorized - Invalid refresh token or session of another user"
+// @Failure 404 {object} gin.H "Not Found - Session not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/logout [post]
+func (server *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	refreshPayload, err := server.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Only the owner of the session can end it with its refresh token
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Username != authPayload.Username || session.RefreshToken != req.RefreshToken {
+		err := errors.New("session doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.BlockSessionTx(auditContext(ctx), db.BlockSessionTxParams{ID: session.ID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the access token of the request must not outlive the session
+	err = server.revocations.RevokeToken(ctx, authPayload)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// listUserSessions lists the active sessions of the authenticated user
+// @Summary List Sessions
+// @Description List the sessions of the authenticated user that are neither blocked nor expired, newest first.
+// @Tags users
+// @Produce json
+// @Success 200 {array} sessionResponse "Active sessions"
+// @Failure 401 {object} gin.H "Unauthorized"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/sessions [get]
+func (server *Server) listUserSessions(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	sessions, err := server.store.ListActiveSessions(ctx, authPayload.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		rsp = append(rsp, newSessionResponse(session))
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// revokeSessionRequest represents the URI parameter for ending a session
+type revokeSessionRequest struct {
+	ID string `uri:"id" binding:"required,uuid"`
+}
+
+// revokeSession blocks one session of the authenticated user
+// @Summary Revoke Session
+// @Description End one session of the authenticated user, e.g. a login on a lost device.
+// @Tags users
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} sessionResponse "Session ended"
+// @Failure 400 {object} gin.H "Bad Request - Invalid session ID"
+// @Failure 401 {object} gin.H "Unauthorized - Session of another user"
+// @Failure 404 {object} gin.H "Not Found - Session not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/sessions/{id} [delete]
+func (server *Server) revokeSession(ctx *gin.Context) {
+	var req revokeSessionRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	session, err := server.store.GetSession(ctx, uuid.MustParse(req.ID))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if session.Username != authPayload.Username {
+		err := errors.New("session doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	result, err := server.store.BlockSessionTx(auditContext(ctx), db.BlockSessionTxParams{ID: session.ID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newSessionResponse(result.Session))
+}
+
+// blockUserSessionsRequest represents the URI parameter for ending every session of a user
+type blockUserSessionsRequest struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// blockUserSessions blocks every session of a user and revokes its access tokens
+// @Summary Block User Sessions
+// @Description End every session of a user and revoke its access tokens. It needs the sessions:delete:any permission, granted to bankers, and admins:change_role:any for an admin.
+// @Tags users
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} gin.H "Sessions ended"
+// @Failure 400 {object} gin.H "Bad Request - Invalid username"
+// @Failure 403 {object} gin.H "Forbidden - Needs the sessions:delete:any permission, and admins:change_role:any for an admin"
+// @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/{username}/sessions [delete]
+func (server *Server) blockUserSessions(ctx *gin.Context) {
+	var req blockUserSessionsRequest
+
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if !canManage(authPayload, user.Role) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+		return
+	}
+
+	_, err = server.store.BlockUserSessionsTx(auditContext(ctx), db.BlockUserSessionsTxParams{Username: req.Username})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	err = server.revocations.RevokeUserTokens(ctx, req.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "sessions blocked"})
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/statement"
+	"simplebank/token"
+	"simplebank/util"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statementMaxPeriod is the longest period a single statement can cover
+const statementMaxPeriod = 366 * 24 * time.Hour
+
+// statementFormatJSON is the default statement format, the other ones are exports from the statement package
+const statementFormatJSON = "json"
+
+// statementLineResponse is a statement line with its amounts also formatted as decimals of the account currency
+type statementLineResponse struct {
+	db.AccountStatementLine
+	AmountFormatted         string `json:"amount_formatted"`
+	RunningBalanceFormatted string `json:"running_balance_formatted"`
+}
+
+// statementResponse is the JSON statement, its fields match db.AccountStatementTxResult
+type statementResponse struct {
+	Account                 accountResponse         `json:"account"`
+	StartTime               time.Time               `json:"start_time"`
+	EndTime                 time.Time               `json:"end_time"`
+	OpeningBalance          int64                   `json:"opening_balance"`
+	OpeningBalanceFormatted string                  `json:"opening_balance_formatted"`
+	ClosingBalance          int64                   `json:"closing_balance"`
+	ClosingBalanceFormatted string                  `json:"closing_balance_formatted"`
+	Lines                   []statementLineResponse `json:"lines"`
+}
+
+// newStatementResponse converts the result of a statement transaction to a statementResponse
+func newStatementResponse(result db.AccountStatementTxResult) statementResponse {
+	currency := result.Account.Currency
+
+	rsp := statementResponse{
+		Account:                 newAccountResponse(result.Account),
+		StartTime:               result.StartTime,
+		EndTime:                 result.EndTime,
+		OpeningBalance:          result.OpeningBalance,
+		OpeningBalanceFormatted: util.FormatAmount(result.OpeningBalance, currency),
+		ClosingBalance:          result.ClosingBalance,
+		ClosingBalanceFormatted: util.FormatAmount(result.ClosingBalance, currency),
+		Lines:                   make([]statementLineResponse, 0, len(result.Lines)),
+	}
+
+	for _, line := range result.Lines {
+		rsp.Lines = append(rsp.Lines, statementLineResponse{
+			AccountStatementLine:    line,
+			AmountFormatted:         util.FormatAmount(line.Amount, currency),
+			RunningBalanceFormatted: util.FormatAmount(line.RunningBalance, currency),
+		})
+	}
+
+	return rsp
+}
+
+// getAccountStatementRequest represents the query parameters of an account statement
+type getAccountStatementRequest struct {
+	From   time.Time `form:"from" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	To     time.Time `form:"to" binding:"required" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format string    `form:"format" binding:"omitempty,oneof=json csv ofx camt053"`
+}
+
+// getAccountStatement returns the statement of an account for a period
+// @Summary Get account statement
+// @Description Get the opening balance, every entry of the period with its running balance and transfer counterparty, and the closing balance. The authenticated user must own the account, bankers can see any account.
+// @Description The statement is JSON by default. It can be downloaded as CSV, OFX or camt.053 with the format parameter or the Accept header.
+// @Tags accounts
+// @Accept json
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ofx
+// @Produce application/xml
+// @Param id path int true "Account ID"
+// @Param from query string true "Start of the period, inclusive (RFC 3339)"
+// @Param to query string true "End of the period, exclusive (RFC 3339)"
+// @Param format query string false "json, csv, ofx or camt053, overrides the Accept header"
+// @Success 200 {object} statementResponse "Account statement"
+// @Failure 400 {object} gin.H "Invalid parameters"
+// @Failure 401 {object} gin.H "Account doesn't belong to the authenticated user"
+// @Failure 404 {object} gin.H "Account not found"
+// @Failure 406 {object} gin.H "None of the accepted content types is supported"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /accounts/{id}/statement [get]
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req getAccountStatementRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if !req.To.After(req.From) {
+		err := errors.New("to must be after from")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.To.Sub(req.From) > statementMaxPeriod {
+		err := errors.New("statement period must not be longer than 366 days")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = negotiateStatementFormat(ctx)
+		if format == "" {
+			err := errors.New("statement is available as JSON, CSV, OFX or camt.053 only")
+			ctx.JSON(http.StatusNotAcceptable, errorResponse(err))
+			return
+		}
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// get auth payload
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if !canAccess(authPayload, account.Owner, util.PermStatementsReadAny) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.AccountStatementTxParams{
+		AccountID: account.ID,
+		StartTime: req.From,
+		EndTime:   req.To,
+	}
+
+	result, err := server.store.AccountStatementTx(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if format == statementFormatJSON {
+		ctx.JSON(http.StatusOK, newStatementResponse(result))
+		return
+	}
+
+	// encode into a buffer first so that a failure can still be reported as an error response
+	exportFormat := statement.Format(format)
+	var buf bytes.Buffer
+	if err := statement.Write(&buf, exportFormat, result, time.Now()); err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	filename := fmt.Sprintf("statement-%d-%s-%s.%s",
+		account.ID, req.From.UTC().Format("20060102"), req.To.UTC().Format("20060102"), exportFormat.Extension())
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Data(http.StatusOK, exportFormat.ContentType(), buf.Bytes())
+}
+
+// negotiateStatementFormat picks the statement format from the Accept header, JSON when any type is accepted.
+// It returns an empty string when none of the accepted content types is supported.
+func negotiateStatementFormat(ctx *gin.Context) string {
+	offered := []string{gin.MIMEJSON}
+	for _, format := range statement.Formats {
+		offered = append(offered, format.ContentType())
+	}
+
+	contentType := ctx.NegotiateFormat(offered...)
+	if contentType == gin.MIMEJSON {
+		return statementFormatJSON
+	}
+
+	format, ok := statement.FormatFromContentType(contentType)
+	if !ok {
+		return ""
+	}
+	return string(format)
+}
@@ -5,9 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/revocation"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
 )
 
 // RenewAccessTokenRequest defines the request body for renewing access tokens
@@ -20,19 +23,29 @@ type RenewAccessTokenRequest struct {
 }
 
 // RenewAccessTokenResponse defines the response body for renewing access tokens
-// @Description Response body for renewing access tokens
+// @Description Response body for renewing access tokens. The refresh token of the request can't be used again, the new one replaces it.
+// @Param session_id query string true "ID of the new session"
 // @Param access_token query string true "Access Token"
 // @Param access_token_expires_at query string true "Access Token Expiration Time"
+// @Param refresh_token query string true "Refresh Token replacing the one of the request"
+// @Param refresh_token_expires_at query string true "Refresh Token Expiration Time"
 // @Accept json
 // @Produce json
 type RenewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// errRefreshTokenReused is returned to a client presenting a refresh token that was already rotated
+var errRefreshTokenReused = errors.New("refresh token reuse detected, please log in again")
+
 // RenewAccessToken handles the renewal of access tokens
 // @Summary Renew Access Token
-// @Description Renew an access token using a valid refresh token.
+// @Description Renew an access token using a valid refresh token. The refresh token is rotated: a new one is returned and the old one can't be used again.
+// @Description Presenting an already rotated refresh token blocks every session of its token family.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -58,6 +71,17 @@ func (server *Server) RenewAccessToken(ctx *gin.Context) {
 		return
 	}
 
+	// a password change or a role change refuses the refresh tokens issued before it, like the access tokens
+	err = server.revocations.Check(ctx, refreshPayload)
+	if err != nil {
+		if errors.Is(err, revocation.ErrRevokedToken) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	// Find the session in DB
 	session, err := server.store.GetSession(ctx, refreshPayload.ID)
 	if err != nil {
@@ -97,15 +121,75 @@ func (server *Server) RenewAccessToken(ctx *gin.Context) {
 		return
 	}
 
+	// A refresh token that was already exchanged may have been stolen
+	if session.RotatedAt.Valid {
+		server.blockReusedSessionFamily(ctx, session)
+		return
+	}
+
 	accessToken, accessPayload, err := server.tokenMaker.CreateToken(refreshPayload.Username, refreshPayload.Role, server.config.AccessTokenDuration)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateToken(refreshPayload.Username, refreshPayload.Role, server.config.RefreshTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// Replace the session with a new one of the same family
+	result, err := server.store.RotateSessionTx(auditContextFor(ctx, refreshPayload.Username, refreshPayload.Role), db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     newRefreshPayload.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		// Another request rotated the session in the meantime
+		if errors.Is(err, db.ErrRefreshTokenReused) {
+			server.blockReusedSessionFamily(ctx, session)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	rsp := RenewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             result.Session.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+// blockReusedSessionFamily blocks every session of the token family of a reused refresh token
+// and logs a security event. It writes the error response itself.
+func (server *Server) blockReusedSessionFamily(ctx *gin.Context, session db.Session) {
+	log.Warn().
+		Str("event", "refresh_token_reuse").
+		Str("username", session.Username).
+		Str("session_id", session.ID.String()).
+		Str("family_id", session.FamilyID.String()).
+		Str("client_ip", ctx.ClientIP()).
+		Str("user_agent", ctx.Request.UserAgent()).
+		Msg("refresh token reuse detected, blocking its token family")
+
+	// nobody is logged in, the audit event only knows the client that reused the token
+	_, err := server.store.BlockSessionFamilyTx(auditContext(ctx), db.BlockSessionFamilyTxParams{FamilyID: session.FamilyID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errRefreshTokenReused))
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -10,11 +12,13 @@ import (
 
 	mockdb "simplebank/db/mock"
 	db "simplebank/db/sqlc"
+	"simplebank/revocation"
 	"simplebank/token"
 	"simplebank/util"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,6 +39,8 @@ func TestRenewAccessToken(t *testing.T) {
 	mockSessionWrongUser := randomSession(mockRefreshToken, "anyUser", mockRefreshPayload, false, 1)
 	mockSessionWrongRefreshToken := randomSession(util.RandomString(6), mockRefreshPayload.Username, mockRefreshPayload, false, 1)
 	mockSessionExpired := randomSession(mockRefreshToken, mockRefreshPayload.Username, mockRefreshPayload, false, -1)
+	mockSessionRotated := randomSession(mockRefreshToken, mockRefreshPayload.Username, mockRefreshPayload, false, 1)
+	mockSessionRotated.RotatedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
 
 	testCases := []struct {
 		name          string
@@ -52,6 +58,23 @@ func TestRenewAccessToken(t *testing.T) {
 					GetSession(gomock.Any(), gomock.Eq(mockRefreshPayload.ID)).
 					Times(1).
 					Return(mockSession, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RotateSessionTxParams) (db.RotateSessionTxResult, error) {
+						require.Equal(t, mockSession.ID, arg.SessionID)
+						require.Equal(t, mockSession.Username, arg.NewSession.Username)
+						require.NotEqual(t, mockRefreshToken, arg.NewSession.RefreshToken)
+
+						newSession := db.Session{
+							ID:           arg.NewSession.ID,
+							Username:     arg.NewSession.Username,
+							RefreshToken: arg.NewSession.RefreshToken,
+							ExpiresAt:    arg.NewSession.ExpiresAt,
+							FamilyID:     mockSession.FamilyID,
+						}
+						return db.RotateSessionTxResult{OldSession: mockSession, Session: newSession}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -59,6 +82,93 @@ func TestRenewAccessToken(t *testing.T) {
 				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
 				require.NoError(t, err)
 				require.NotEmpty(t, rsp.AccessToken)
+				require.NotEmpty(t, rsp.RefreshToken)
+				require.NotEqual(t, mockRefreshToken, rsp.RefreshToken)
+				require.NotEqual(t, mockSession.ID, rsp.SessionID)
+			},
+		},
+		{
+			name: "RefreshTokenReused",
+			body: gin.H{
+				"refresh_token": mockRefreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(mockRefreshPayload.ID)).
+					Times(1).
+					Return(mockSessionRotated, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					BlockSessionFamilyTx(gomock.Any(), gomock.Eq(db.BlockSessionFamilyTxParams{FamilyID: mockSessionRotated.FamilyID})).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "RefreshTokenReusedConcurrently",
+			body: gin.H{
+				"refresh_token": mockRefreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(mockRefreshPayload.ID)).
+					Times(1).
+					Return(mockSession, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RotateSessionTxResult{}, db.ErrRefreshTokenReused)
+				store.EXPECT().
+					BlockSessionFamilyTx(gomock.Any(), gomock.Eq(db.BlockSessionFamilyTxParams{FamilyID: mockSession.FamilyID})).
+					Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "BlockSessionFamilyError",
+			body: gin.H{
+				"refresh_token": mockRefreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(mockRefreshPayload.ID)).
+					Times(1).
+					Return(mockSessionRotated, nil)
+				store.EXPECT().
+					BlockSessionFamilyTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.BlockSessionFamilyTxResult{}, errors.New("internal server error"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "RotateSessionError",
+			body: gin.H{
+				"refresh_token": mockRefreshToken,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetSession(gomock.Any(), gomock.Eq(mockRefreshPayload.ID)).
+					Times(1).
+					Return(mockSession, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.RotateSessionTxResult{}, errors.New("internal server error"))
+				store.EXPECT().
+					BlockSessionFamilyTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 		{
@@ -222,6 +332,38 @@ func TestRenewAccessToken(t *testing.T) {
 	}
 }
 
+// TestRenewAccessTokenRevoked tests a refresh token issued before a password or role change is not renewed
+func TestRenewAccessTokenRevoked(t *testing.T) {
+	user, _ := randomUser(t, util.DepositorRole)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetSession(gomock.Any(), gomock.Any()).Times(0)
+	store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	refreshToken, _ := randomRefreshToken(t, server.tokenMaker, user.Role, user.Username)
+
+	// the user changed their password after the refresh token was issued
+	backend := revocation.NewMemoryBackend()
+	err := backend.RevokeUserTokens(context.Background(), user.Username, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	server.revocations = revocation.NewList(backend, time.Minute)
+
+	data, err := json.Marshal(gin.H{"refresh_token": refreshToken})
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodPost, "/tokens/renew_access", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Contains(t, recorder.Body.String(), revocation.ErrRevokedToken.Error())
+}
+
 func randomRefreshToken(t *testing.T, maker token.Maker, role string, username string) (string, *token.Payload) {
 	mockRefreshToken, mockRefreshPayload, err := maker.CreateToken(username, role, time.Minute)
 	require.NoError(t, err)
@@ -241,5 +383,6 @@ func randomSession(mockRefreshToken string, username string, mockRefreshPayload
 		IsBlocked:    isBlocked,
 		CreatedAt:    time.Now(),
 		ExpiresAt:    time.Now().Add(expiration * time.Minute),
+		FamilyID:     mockRefreshPayload.ID,
 	}
 }
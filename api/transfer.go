@@ -1,14 +1,26 @@
 package api
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	db "simplebank/db/sqlc"
 	"simplebank/token"
 	"simplebank/util"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// idempotencyKeyHeader lets clients retry a transfer without moving the money twice
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	idempotencyKeyMaxLength = 255
 )
 
 // TransferRequest defines the request body for creating a transfer
@@ -16,7 +28,8 @@ import (
 // @Param from_account_id body int64 true "ID of the account to transfer from"
 // @Param to_account_id body int64 true "ID of the account to transfer to"
 // @Param amount body int64 true "Amount to transfer"
-// @Param currency body string true "Currency of the accounts"
+// @Param currency body string true "Currency of the from account"
+// @Param fx_quote_id body string false "FX quote, required when the to account has another currency"
 // @Accept json
 // @Produce json
 type TransferRequest struct {
@@ -24,19 +37,103 @@ type TransferRequest struct {
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
 	Amount        int64  `json:"amount" binding:"required,gt=1"`
 	Currency      string `json:"currency" binding:"required,currency"` // binding:currency is the custom Validator from validator.go
+	FxQuoteID     string `json:"fx_quote_id" binding:"omitempty,uuid"`
+}
+
+// transferResponse is a transfer with its amounts also formatted as decimals of the account currencies
+type transferResponse struct {
+	db.Transfer
+	AmountFormatted          string `json:"amount_formatted"`
+	ConvertedAmountFormatted string `json:"converted_amount_formatted"`
+}
+
+// transferTxResponse is the response of a transfer, its fields match db.TransferTxResult
+type transferTxResponse struct {
+	Transfer    transferResponse `json:"id"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+// newTransferResponse formats the amount in the currency of the from account and the converted amount in the one of the to account
+func newTransferResponse(transfer db.Transfer, fromCurrency, toCurrency string) transferResponse {
+	return transferResponse{
+		Transfer:                 transfer,
+		AmountFormatted:          util.FormatAmount(transfer.Amount, fromCurrency),
+		ConvertedAmountFormatted: util.FormatAmount(transfer.ConvertedAmount, toCurrency),
+	}
+}
+
+// newTransferResponses formats transfers, reading the currencies of all their accounts in one query
+func (server *Server) newTransferResponses(ctx *gin.Context, transfers []db.Transfer) ([]transferResponse, error) {
+	rsp := []transferResponse{}
+	if len(transfers) == 0 {
+		return rsp, nil
+	}
+
+	ids := make([]int64, 0, 2*len(transfers))
+	for _, transfer := range transfers {
+		ids = append(ids, transfer.FromAccountID, transfer.ToAccountID)
+	}
+
+	rows, err := server.store.ListAccountCurrencies(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies := make(map[int64]string, len(rows))
+	for _, row := range rows {
+		currencies[row.ID] = row.Currency
+	}
+
+	for _, transfer := range transfers {
+		fromCurrency, ok := currencies[transfer.FromAccountID]
+		if !ok {
+			return nil, fmt.Errorf("account (%d) of transfer (%d) not found", transfer.FromAccountID, transfer.ID)
+		}
+		toCurrency, ok := currencies[transfer.ToAccountID]
+		if !ok {
+			return nil, fmt.Errorf("account (%d) of transfer (%d) not found", transfer.ToAccountID, transfer.ID)
+		}
+
+		rsp = append(rsp, newTransferResponse(transfer, fromCurrency, toCurrency))
+	}
+
+	return rsp, nil
+}
+
+// newTransferTxResponse converts the result of a transfer transaction to a transferTxResponse
+func newTransferTxResponse(result db.TransferTxResult) transferTxResponse {
+	fromCurrency := result.FromAccount.Currency
+	toCurrency := result.ToAccount.Currency
+
+	return transferTxResponse{
+		Transfer:    newTransferResponse(result.Transfer, fromCurrency, toCurrency),
+		FromAccount: newAccountResponse(result.FromAccount),
+		ToAccount:   newAccountResponse(result.ToAccount),
+		FromEntry:   newEntryResponse(result.FromEntry, fromCurrency),
+		ToEntry:     newEntryResponse(result.ToEntry, toCurrency),
+	}
 }
 
 // createTransfer handles the creation of a new transfer
 // @Summary Create a Transfer
 // @Description Initiate a transfer between two accounts. The request should include the account IDs, amount, and currency.
+// @Description Retries sending the same Idempotency-Key header and body get the original response back, even if the accounts changed since.
+// @Description A to account in another currency is credited the amount converted at the rate of the fx quote.
 // @Tags transfers
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Key to safely retry the request"
 // @Param request body TransferRequest true "Transfer Request"
-// @Success 200 {object} db.TransferTxResult "Transfer successfully processed"
+// @Success 200 {object} transferTxResponse "Transfer successfully processed"
 // @Failure 400 {object} gin.H "Bad Request - Invalid request data"
 // @Failure 401 {object} gin.H "Unauthorized - User is not authorized for this transfer"
+// @Failure 403 {object} gin.H "Forbidden - Email not verified, with code email_not_verified"
 // @Failure 404 {object} gin.H "Not Found - Account not found"
+// @Failure 409 {object} gin.H "Conflict - Idempotency key already used with a different request"
+// @Failure 422 {object} gin.H "Unprocessable Entity - Insufficient funds, account frozen or closed, unusable fx quote or amount too small to convert"
 // @Failure 500 {object} gin.H "Internal Server Error"
 // @Router /transfers [post]
 func (server *Server) createTransfer(ctx *gin.Context) {
@@ -48,6 +145,41 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
+	idempotencyKey := ctx.GetHeader(idempotencyKeyHeader)
+	if len(idempotencyKey) > idempotencyKeyMaxLength {
+		err := fmt.Errorf("%s header must be at most %d characters", idempotencyKeyHeader, idempotencyKeyMaxLength)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// Get auth payload
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	var requestHash string
+	if len(idempotencyKey) > 0 {
+		var err error
+		requestHash, err = hashRequest(req)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		// a retry gets the stored response back even if the accounts were frozen or emptied since
+		result, err := server.store.ReplayIdempotentTransfer(ctx, db.ReplayIdempotentTransferParams{
+			Username:       authPayload.Username,
+			IdempotencyKey: idempotencyKey,
+			RequestHash:    requestHash,
+		})
+		if err == nil {
+			ctx.JSON(http.StatusOK, newTransferTxResponse(result.TransferTxResult))
+			return
+		}
+		if !errors.Is(err, db.ErrRecordNotFound) {
+			server.transferErrorResponse(ctx, err)
+			return
+		}
+	}
+
 	// Validating account and currency
 	fromAccount, valid := server.validAccount(ctx, req.FromAccountID, req.Currency)
 	// Validating currency with the sender and receiver
@@ -55,22 +187,25 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	// Get auth payload
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 	if fromAccount.Owner != authPayload.Username {
 		err := errors.New("from account doesn't belong to the authenticated user")
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccount, valid := server.existingAccount(ctx, req.ToAccountID)
 	if !valid {
 		return
 	}
 
-	// Validate RBAC
-	err := RBAC(ctx, authPayload.Role, []string{util.DepositorRole})
-	if err != nil {
+	if !activeAccount(ctx, fromAccount) || !activeAccount(ctx, toAccount) {
+		return
+	}
+
+	// a to account in another currency is credited the converted amount, which needs an fx quote
+	if toAccount.Currency != req.Currency && req.FxQuoteID == "" {
+		err := fmt.Errorf("account (%d) currency mismatch: %s vs %s, an fx quote is required", toAccount.ID, toAccount.Currency, req.Currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
@@ -80,14 +215,65 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
 	}
+	if req.FxQuoteID != "" {
+		// already validated by the uuid binding
+		arg.FxQuoteID = uuid.MustParse(req.FxQuoteID)
+	}
+
+	if len(idempotencyKey) == 0 {
+		result, err := server.store.TransferTx(auditContext(ctx), arg)
+		if err != nil {
+			server.transferErrorResponse(ctx, err)
+			return
+		}
+
+		ctx.JSON(http.StatusOK, newTransferTxResponse(result))
+		return
+	}
 
-	result, err := server.store.TransferTx(ctx, arg)
+	result, err := server.store.IdempotentTransferTx(auditContext(ctx), db.IdempotentTransferTxParams{
+		TransferTxParams: arg,
+		Username:         authPayload.Username,
+		IdempotencyKey:   idempotencyKey,
+		RequestHash:      requestHash,
+		ExpiresAt:        time.Now().Add(server.config.IdempotencyKeyTTL),
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		server.transferErrorResponse(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	ctx.JSON(http.StatusOK, newTransferTxResponse(result.TransferTxResult))
+}
+
+// transferErrorResponse maps the errors of a transfer transaction to the HTTP status
+func (server *Server) transferErrorResponse(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds),
+		errors.Is(err, db.ErrAccountNotActive):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case errors.Is(err, db.ErrFxQuoteRequired),
+		errors.Is(err, db.ErrInvalidFxQuote),
+		errors.Is(err, db.ErrFxQuoteExpired),
+		errors.Is(err, db.ErrFxQuoteUsed),
+		errors.Is(err, db.ErrFxAmountTooSmall):
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+	case errors.Is(err, db.ErrIdempotencyKeyReused):
+		ctx.JSON(http.StatusConflict, errorResponse(err))
+	default:
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	}
+}
+
+// hashRequest returns a fingerprint of a request body, used to detect idempotency keys sent with another request
+func hashRequest(req interface{}) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash request: %w", err)
+	}
+
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
 }
 
 // validAccount validates if an account exists and matches the provided currency
@@ -100,6 +286,33 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 // @Failure 500 {object} gin.H "Internal Server Error"
 // @Router /accounts/validate [get]
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.existingAccount(ctx, accountID)
+	if !valid {
+		return account, false
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account (%d) currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return account, false
+	}
+
+	return account, true
+}
+
+// activeAccount checks that money can move in and out of an account, writing the error response itself when it can't
+func activeAccount(ctx *gin.Context, account db.Account) bool {
+	if account.Status != util.AccountStatusActive {
+		err := fmt.Errorf("account (%d) is %s", account.ID, account.Status)
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+		return false
+	}
+
+	return true
+}
+
+// existingAccount gets an account, writing the error response itself when it can't
+func (server *Server) existingAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
@@ -111,11 +324,197 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 		return account, false
 	}
 
-	if account.Currency != currency {
-		err := fmt.Errorf("account (%d) currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	return account, true
+}
+
+// getTransferRequest represents the URI parameters for getting a transfer
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+// getTransfer retrieves a transfer by ID
+// @Summary Get transfer by ID
+// @Description Get the details of a transfer. The authenticated user must own one of its accounts, bankers can see any transfer.
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param id path int true "Transfer ID"
+// @Success 200 {object} transferResponse "Transfer details"
+// @Failure 400 {object} gin.H "Invalid URI parameter"
+// @Failure 401 {object} gin.H "Transfer doesn't belong to the authenticated user"
+// @Failure 404 {object} gin.H "Transfer not found"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /transfers/{id} [get]
+func (server *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+
+	// validating the request from the URI.
+	if err := ctx.ShouldBindUri(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return account, false
+		return
 	}
 
-	return account, true
+	transfer, err := server.store.GetTransfer(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// get auth payload
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if !util.HasPermission(authPayload.Role, util.PermTransfersReadAny) {
+		fromAccount, err := server.store.GetAccount(ctx, transfer.FromAccountID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		if fromAccount.Owner != authPayload.Username {
+			toAccount, err := server.store.GetAccount(ctx, transfer.ToAccountID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+
+			if toAccount.Owner != authPayload.Username {
+				err := errors.New("transfer doesn't belong to the authenticated user")
+				ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+				return
+			}
+		}
+	}
+
+	rsp, err := server.newTransferResponses(ctx, []db.Transfer{transfer})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp[0])
+}
+
+// Directions of a transfer seen from one account
+const (
+	transferDirectionIncoming = "incoming"
+	transferDirectionOutgoing = "outgoing"
+)
+
+// listAccountTransfersRequest represents the query parameters for listing the transfers of an account
+type listAccountTransfersRequest struct {
+	PageID    int32      `form:"page_id" binding:"required,min=1"`
+	PageSize  int32      `form:"page_size" binding:"required,min=5"`
+	Direction string     `form:"direction" binding:"omitempty,oneof=incoming outgoing"`
+	StartTime *time.Time `form:"start_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	EndTime   *time.Time `form:"end_time" time_format:"2006-01-02T15:04:05Z07:00"`
+	MinAmount *int64     `form:"min_amount" binding:"omitempty,min=0"`
+	MaxAmount *int64     `form:"max_amount" binding:"omitempty,min=0"`
+}
+
+// listAccountTransfers lists the transfers of an account with pagination and filters
+// @Summary List account transfers
+// @Description List the transfers in and out of an account. The authenticated user must own the account, bankers can see any account.
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param id path int true "Account ID"
+// @Param page_id query int true "Page number"
+// @Param page_size query int true "Page size"
+// @Param direction query string false "incoming or outgoing, both when empty"
+// @Param start_time query string false "Only transfers created at or after this time (RFC 3339)"
+// @Param end_time query string false "Only transfers created before this time (RFC 3339)"
+// @Param min_amount query int false "Minimum amount"
+// @Param max_amount query int false "Maximum amount"
+// @Success 200 {array} transferResponse "List of transfers"
+// @Failure 400 {object} gin.H "Invalid parameters"
+// @Failure 401 {object} gin.H "Account doesn't belong to the authenticated user"
+// @Failure 404 {object} gin.H "Account not found"
+// @Failure 500 {object} gin.H "Internal server error"
+// @Security BearerAuth
+// @Router /accounts/{id}/transfers [get]
+func (server *Server) listAccountTransfers(ctx *gin.Context) {
+	var uri getAccountRequest
+	var req listAccountTransfersRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.StartTime != nil && req.EndTime != nil && !req.EndTime.After(*req.StartTime) {
+		err := errors.New("end_time must be after start_time")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if req.MinAmount != nil && req.MaxAmount != nil && *req.MinAmount > *req.MaxAmount {
+		err := errors.New("min_amount must not be greater than max_amount")
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// get auth payload
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	if !canAccess(authPayload, account.Owner, util.PermTransfersReadAny) {
+		err := errors.New("account doesn't belong to the authenticated user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	arg := db.ListTransfersParams{
+		Outgoing:  req.Direction != transferDirectionIncoming,
+		AccountID: account.ID,
+		Incoming:  req.Direction != transferDirectionOutgoing,
+		Limit:     req.PageSize,
+		Offset:    (req.PageID - 1) * req.PageSize,
+	}
+	if req.StartTime != nil {
+		arg.StartTime = pgtype.Timestamptz{Time: *req.StartTime, Valid: true}
+	}
+	if req.EndTime != nil {
+		arg.EndTime = pgtype.Timestamptz{Time: *req.EndTime, Valid: true}
+	}
+	if req.MinAmount != nil {
+		arg.MinAmount = pgtype.Int8{Int64: *req.MinAmount, Valid: true}
+	}
+	if req.MaxAmount != nil {
+		arg.MaxAmount = pgtype.Int8{Int64: *req.MaxAmount, Valid: true}
+	}
+
+	transfers, err := server.store.ListTransfers(ctx, arg)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.newTransferResponses(ctx, transfers)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, rsp)
 }
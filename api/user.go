@@ -11,7 +11,6 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
-	"github.com/hibiken/asynq"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -19,7 +18,6 @@ import (
 // @Description Request body for creating a new user
 // @Param username body string true "Username of the new user" example("johndoe")
 // @Param password body string true "Password for the new user" example("password123")
-// @Param role body string true "Role of the new user" example("user")
 // @Param full_name body string true "Full name of the new user" example("John Doe")
 // @Param email body string true "Email of the new user" example("johndoe@example.com")
 // @Accept json
@@ -27,7 +25,6 @@ import (
 type createUserRequest struct {
 	Username string `json:"username" binding:"required,alphanum"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,role"` // binding:role is the custom Validator from validator.go
 	FullName string `json:"full_name" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 }
@@ -60,7 +57,7 @@ func newUserResponse(user db.User) userResponse {
 
 // createUser creates a new user in the system
 // @Summary Create a new user
-// @Description Create a new user with the provided details. A verification email will be sent after user creation.
+// @Description Create a new depositor with the provided details. A verification email will be sent after user creation.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -85,31 +82,31 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
+	// the verification email is enqueued by the outbox relay once the user is committed
+	verifyEmail, err := worker.NewOutboxMessage(
+		worker.TaskSendVerifyEmail,
+		&worker.PayloadSendVerifyEmail{Username: req.Username},
+		worker.QueueCritical,
+		10,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	arg := db.CreateUserTxParams{
 		CreateUserParams: db.CreateUserParams{
 			Username:       req.Username,
 			HashedPassword: hashedPassword,
 			FullName:       req.FullName,
-			Role:           req.Role,
+			Role:           util.DepositorRole, // only a banker or an admin can give another role
 			Email:          req.Email,
 		},
-		// Create a Redis task
-		AfterCreate: func(user db.User) error {
-			taskPayload := &worker.PayloadSendVerifyEmail{
-				Username: user.Username,
-			}
-			opts := []asynq.Option{
-				asynq.MaxRetry(10),
-				asynq.ProcessIn(10 * time.Second), // Necessary delay to wait for transaction to finish
-				asynq.Queue(worker.QueueCritical), // Set the priority queue
-			}
-
-			return server.taskDistributor.DistributeTaskSendVerifyEmail(ctx, taskPayload, opts...)
-		},
+		Outbox: []db.CreateOutboxMessageParams{verifyEmail},
 	}
 
-	// Use DB transaction to create a user and send email in a single transaction
-	txResult, err := server.store.CreateUserTx(ctx, arg)
+	// Use DB transaction to create a user and its verification email task in a single transaction
+	txResult, err := server.store.CreateUserTx(auditContext(ctx), arg)
 	if err != nil {
 		if db.ErrorCode(err) == db.UniqueViolation {
 			ctx.JSON(http.StatusForbidden, errorResponse(err))
@@ -156,15 +153,17 @@ type loginUserResponse struct {
 
 // loginUser handles user login and returns tokens and session details
 // @Summary User Login
-// @Description Authenticate the user and return access and refresh tokens, along with user details.
+// @Description Authenticate the user and return access and refresh tokens, along with user details. Users with two-factor authentication get an mfa_token to complete the login at /users/login/mfa instead.
+// @Description A wrong username or password gets the same 401. After failed logins, the username or the client IP gets 429 with a Retry-After header until its backoff or lockout ends, whether the username exists or not.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param request body loginUserRequest true "Login User Request"
 // @Success 200 {object} loginUserResponse "Login successful, returns user details and tokens"
+// @Success 200 {object} loginMfaRequiredResponse "Password accepted, a second factor is required"
 // @Failure 400 {object} gin.H "Bad Request - Invalid input data"
-// @Failure 401 {object} gin.H "Unauthorized - Invalid credentials"
-// @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 401 {object} gin.H "Unauthorized - Incorrect username or password"
+// @Failure 429 {object} gin.H "Too Many Requests - Too many failed logins, see the Retry-After header"
 // @Failure 500 {object} gin.H "Internal Server Error"
 // @Router /users/login [post]
 func (server *Server) loginUser(ctx *gin.Context) {
@@ -175,10 +174,21 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	if !server.checkLoginAllowed(ctx, req.Username) {
+		return
+	}
+
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, db.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			// as slow as a wrong password, and answered the same
+			hashedPassword, err := dummyHashedPassword()
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+				return
+			}
+			util.CheckPassword(req.Password, hashedPassword)
+			server.refuseLogin(ctx, errInvalidCredentials)
 			return
 		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
@@ -187,46 +197,89 @@ func (server *Server) loginUser(ctx *gin.Context) {
 
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		server.refuseLogin(ctx, errInvalidCredentials)
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	// a second factor is needed before any real token is issued,
+	// the earlier failures are kept until it is given so that they still limit the wrong codes
+	if user.IsTotpEnabled {
+		err = server.loginGuard.Release(ctx, user.Username, ctx.ClientIP())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		mfaToken, mfaPayload, err := server.tokenMaker.CreateToken(user.Username, mfaPendingRole, server.config.MfaTokenDuration)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		rsp := loginMfaRequiredResponse{
+			MfaRequired:       true,
+			MfaToken:          mfaToken,
+			MfaTokenExpiresAt: mfaPayload.ExpiredAt,
+		}
+		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	err = server.loginGuard.RegisterSuccess(ctx, user.Username, ctx.ClientIP())
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
+	rsp, err := server.createLoginSession(ctx, user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// createLoginSession issues the access and refresh tokens of a login and stores the session of the refresh token
+func (server *Server) createLoginSession(ctx *gin.Context, user db.User) (loginUserResponse, error) {
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
+
+	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.RefreshTokenDuration)
+	if err != nil {
+		return loginUserResponse{}, err
+	}
 
 	// Create a session to store the refresh token
-	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
-		ID:           refreshPayload.ID,
-		Username:     accessPayload.Username,
-		RefreshToken: refreshToken,
-		UserAgent:    ctx.Request.UserAgent(),
-		ClientIp:     ctx.ClientIP(),
-		IsBlocked:    false,
-		ExpiresAt:    refreshPayload.ExpiredAt,
-	})
+	arg := db.CreateSessionTxParams{
+		CreateSessionParams: db.CreateSessionParams{
+			ID:           refreshPayload.ID,
+			Username:     accessPayload.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    refreshPayload.ExpiredAt,
+			FamilyID:     refreshPayload.ID, // a login starts a new token family
+		},
+	}
+
+	// the user logging in is the actor of the audit event of the new session
+	result, err := server.store.CreateSessionTx(auditContextFor(ctx, user.Username, user.Role), arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return loginUserResponse{}, err
 	}
 
 	rsp := loginUserResponse{
-		SessionID:             session.ID,
+		SessionID:             result.Session.ID,
 		AccessToken:           accessToken,
 		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
 		RefreshToken:          refreshToken,
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 		User:                  newUserResponse(user),
 	}
-	ctx.JSON(http.StatusOK, rsp)
+	return rsp, nil
 }
 
 // updateUserRequest defines the request body for updating user information
@@ -249,27 +302,31 @@ type updateUserRequest struct {
 // @Property username string "Username of the user" example("johndoe")
 // @Property full_name string "Full name of the user" example("John Doe")
 // @Property email string "Email address of the user" example("johndoe@example.com")
+// @Property pending_email string "New email address waiting for its verification" example("john.doe@example.com")
 // @Property role string "Role of the user" example("user")
 // @Property password_changed_at string "Timestamp when the password was last changed" example("2024-07-31T12:00:00Z")
 type updateUserResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	PendingEmail      string    `json:"pending_email,omitempty"`
 	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 }
 
 // updateUser handles updating user details
 // @Summary Update User Information
-// @Description Update user details such as password, full name, and email. Only users with sufficient roles can update user information.
+// @Description Update user details such as password, full name, and email. Only users with sufficient roles can update user information. A new password revokes the access tokens of the user and ends every session. A new email becomes pending: a verification code is sent to it, a notice to the current email, and the email changes once the code is confirmed. Updating an admin needs the admins:change_role:any permission.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param request body updateUserRequest true "Update User Request"
 // @Success 200 {object} updateUserResponse "User details updated successfully"
 // @Failure 400 {object} gin.H "Bad Request - Invalid input data"
-// @Failure 401 {object} gin.H "Unauthorized - Insufficient permissions or invalid credentials"
+// @Failure 401 {object} gin.H "Unauthorized - Another user without the users:update:any permission"
+// @Failure 403 {object} gin.H "Forbidden - Needs the users:update:own permission, and admins:change_role:any to update an admin"
 // @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 409 {object} gin.H "Conflict - Email used by another user"
 // @Failure 500 {object} gin.H "Internal Server Error"
 // @Router /users [put]
 func (server *Server) updateUser(ctx *gin.Context) {
@@ -280,15 +337,10 @@ func (server *Server) updateUser(ctx *gin.Context) {
 		return
 	}
 
-	// Get auth payload and validate RBAC
+	// get auth payload
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
 
-	err := RBAC(ctx, authPayload.Role, []string{util.DepositorRole, util.BankerRole})
-	if err != nil {
-		return
-	}
-
-	if authPayload.Role != util.BankerRole && authPayload.Username != req.Username {
+	if !canAccess(authPayload, req.Username, util.PermUsersUpdateAny) {
 		err := errors.New("invalid user name")
 		// Abort the API call and return 401 to the user
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
@@ -317,6 +369,12 @@ func (server *Server) updateUser(ctx *gin.Context) {
 		return
 	}
 
+	// setting the password or the email of an admin would take the account over
+	if !canManage(authPayload, user.Role) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+		return
+	}
+
 	err = util.CheckPassword(req.Password, user.HashedPassword)
 	if err == nil {
 		// Passwords are the same, no need to update HashedPassword or PasswordChangedAt
@@ -345,16 +403,61 @@ func (server *Server) updateUser(ctx *gin.Context) {
 		}
 	}
 
-	result, err := server.store.UpdateUser(ctx, arg)
+	// a new email waits for its verification code, the current one is kept until then
+	emailChanged := req.Email != user.Email
+	if emailChanged {
+		// an email waiting for the code of another user is taken too, or both confirmations would clash
+		taken, err := server.store.IsEmailTaken(ctx, db.IsEmailTakenParams{
+			Username: user.Username,
+			Email:    req.Email,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		if taken {
+			ctx.JSON(http.StatusConflict, errorResponse(errEmailAlreadyUsed))
+			return
+		}
+
+		arg.Email = pgtype.Text{}
+		arg.PendingEmail = pgtype.Text{
+			String: req.Email,
+			Valid:  true,
+		}
+	}
+
+	txArg := db.UpdateUserTxParams{UpdateUserParams: arg}
+	if emailChanged {
+		// the emails are sent by the outbox relay once the pending email is committed
+		txArg.Outbox, err = emailChangeOutbox(user, req.Email)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
+
+	txResult, err := server.store.UpdateUserTx(auditContext(ctx), txArg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
+	result := txResult.User
+
+	// a new password refuses the access tokens issued with the old one
+	if arg.PasswordChangedAt.Valid {
+		err = server.revocations.RevokeUserTokens(ctx, result.Username)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+	}
 
 	rsp := updateUserResponse{
 		Username:          result.Username,
 		FullName:          result.FullName,
 		Email:             result.Email,
+		PendingEmail:      result.PendingEmail.String,
 		Role:              result.Role,
 		PasswordChangedAt: result.PasswordChangedAt,
 	}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errOwnRoleChange = errors.New("cannot change your own role")
+
+// changeUserRoleURI represents the URI parameter for changing the role of a user
+type changeUserRoleURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+// changeUserRoleRequest defines the request body for promoting or demoting a user
+// @Description Request body for changing the role of a user
+// @Param role body string true "New role of the user" example("banker")
+// @Accept json
+// @Produce json
+type changeUserRoleRequest struct {
+	Role string `json:"role" binding:"required,role"` // binding:role is the custom Validator from validator.go
+}
+
+// changeUserRole promotes or demotes a user
+// @Summary Change User Role
+// @Description Give a user a new role and record who changed it and when. The sessions and access tokens of the user are revoked so that none of them keeps the old role. Only admins can give or take the admin role.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param request body changeUserRoleRequest true "Change Role Request"
+// @Success 200 {object} db.RoleChange "Role changed"
+// @Failure 400 {object} gin.H "Bad Request - Invalid role or own role"
+// @Failure 403 {object} gin.H "Forbidden - Needs the users:change_role:any permission, and admins:change_role:any to give or take the admin role"
+// @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 409 {object} gin.H "Conflict - User already has this role"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/{username}/role [put]
+func (server *Server) changeUserRole(ctx *gin.Context) {
+	var uri changeUserRoleURI
+	var req changeUserRoleRequest
+
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// get auth payload
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	// nobody can promote themselves, nor lock themselves out
+	if uri.Username == authPayload.Username {
+		ctx.JSON(http.StatusBadRequest, errorResponse(errOwnRoleChange))
+		return
+	}
+
+	user, err := server.store.GetUser(ctx, uri.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !canManage(authPayload, req.Role) || !canManage(authPayload, user.Role) {
+		ctx.JSON(http.StatusForbidden, errorResponse(errPermissionDenied))
+		return
+	}
+
+	result, err := server.store.ChangeRoleTx(auditContext(ctx), db.ChangeRoleTxParams{
+		Username:  uri.Username,
+		Role:      req.Role,
+		ChangedBy: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRoleUnchanged) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// access tokens already issued still carry the old role
+	err = server.revocations.RevokeUserTokens(ctx, uri.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, result.RoleChange)
+}
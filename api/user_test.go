@@ -19,6 +19,8 @@ import (
 	db "simplebank/db/sqlc"
 	"simplebank/token"
 	"simplebank/util"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
@@ -43,10 +45,8 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 		return false
 	}
 
-	// Only check the fields other than HashedPassword and AfterCreate
+	// Only check the fields other than HashedPassword
 	e.arg.CreateUserParams.HashedPassword = arg.CreateUserParams.HashedPassword
-	e.arg.AfterCreate = nil // Exclude the AfterCreate function from DeepEqual check
-	arg.AfterCreate = nil
 
 	return reflect.DeepEqual(e.arg, arg)
 }
@@ -59,6 +59,18 @@ func EqCreateUserTxParams(arg db.CreateUserTxParams, password string) gomock.Mat
 	return eqCreateUserParamsMatcher{arg, password}
 }
 
+// verifyEmailOutbox is the outbox message signup writes to send the verification email
+func verifyEmailOutbox(t *testing.T, username string) []db.CreateOutboxMessageParams {
+	msg, err := worker.NewOutboxMessage(
+		worker.TaskSendVerifyEmail,
+		&worker.PayloadSendVerifyEmail{Username: username},
+		worker.QueueCritical,
+		10,
+	)
+	require.NoError(t, err)
+	return []db.CreateOutboxMessageParams{msg}
+}
+
 func TestCreateUserAPI(t *testing.T) {
 	role := util.DepositorRole
 	user, password := randomUser(t, role)
@@ -86,9 +98,7 @@ func TestCreateUserAPI(t *testing.T) {
 						FullName: user.FullName,
 						Email:    user.Email,
 					},
-					AfterCreate: func(user db.User) error {
-						return nil
-					},
+					Outbox: verifyEmailOutbox(t, user.Username),
 				}
 				store.EXPECT().
 					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
@@ -100,6 +110,34 @@ func TestCreateUserAPI(t *testing.T) {
 				requireBodyMatchUser(t, recorder.Body, user)
 			},
 		},
+		{
+			name: "BankerRoleIgnored",
+			body: gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"role":      util.BankerRole,
+				"full_name": user.FullName,
+				"email":     user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateUserTxParams{
+					CreateUserParams: db.CreateUserParams{
+						Username: user.Username,
+						Role:     util.DepositorRole,
+						FullName: user.FullName,
+						Email:    user.Email,
+					},
+					Outbox: verifyEmailOutbox(t, user.Username),
+				}
+				store.EXPECT().
+					CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).
+					Times(1).
+					Return(db.CreateUserTxResult{User: user}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
 		{
 			name: "InternalError",
 			body: gin.H{
@@ -243,13 +281,40 @@ func TestLoginUserAPI(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					CreateSession(gomock.Any(), gomock.Any()).
+					CreateSessionTx(gomock.Any(), gomock.Any()).
 					Times(1)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "MfaRequired",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				mfaUser := user
+				mfaUser.IsTotpEnabled = true
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(mfaUser, nil)
+				store.EXPECT().
+					CreateSessionTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp loginMfaRequiredResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.True(t, rsp.MfaRequired)
+				require.NotEmpty(t, rsp.MfaToken)
+			},
+		},
 		{
 			name: "UserNotFound",
 			body: gin.H{
@@ -263,7 +328,9 @@ func TestLoginUserAPI(t *testing.T) {
 					Return(db.User{}, db.ErrRecordNotFound)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				// same answer as a wrong password
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
 			},
 		},
 		{
@@ -280,6 +347,7 @@ func TestLoginUserAPI(t *testing.T) {
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidCredentials)
 			},
 		},
 		{
@@ -345,6 +413,7 @@ func TestLoginUserAPI(t *testing.T) {
 func TestUpdateUserAPI(t *testing.T) {
 	role := util.DepositorRole
 	user, password := randomUser(t, role)
+	admin, _ := randomUser(t, util.AdminRole)
 	newPassword := util.RandomString(10)
 
 	testCases := []struct {
@@ -394,15 +463,15 @@ func TestUpdateUserAPI(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					DoAndReturn(func(_ interface{}, updateArg db.UpdateUserParams) (db.User, error) {
+					DoAndReturn(func(_ interface{}, updateArg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
 						require.Equal(t, arg.Username, updateArg.Username)
 						require.Equal(t, arg.FullName, updateArg.FullName)
 						require.Equal(t, arg.Email, updateArg.Email)
 						require.Equal(t, arg.HashedPassword.Valid, updateArg.HashedPassword.Valid)
 						require.Equal(t, arg.PasswordChangedAt.Valid, updateArg.PasswordChangedAt.Valid)
-						return user, nil
+						return db.UpdateUserTxResult{User: user}, nil
 					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -438,21 +507,45 @@ func TestUpdateUserAPI(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					DoAndReturn(func(_ interface{}, updateArg db.UpdateUserParams) (db.User, error) {
+					DoAndReturn(func(_ interface{}, updateArg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
 						require.Equal(t, arg.Username, updateArg.Username)
 						require.Equal(t, arg.FullName, updateArg.FullName)
 						require.Equal(t, arg.Email, updateArg.Email)
 						require.False(t, updateArg.HashedPassword.Valid)
 						require.False(t, updateArg.PasswordChangedAt.Valid)
-						return user, nil
+						return db.UpdateUserTxResult{User: user}, nil
 					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "BankerOnAdmin",
+			body: gin.H{
+				"username":  admin.Username,
+				"password":  newPassword,
+				"full_name": admin.FullName,
+				"email":     util.RandomEmail(),
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, util.BankerRole, util.RandomOwner(), time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(admin.Username)).
+					Times(1).
+					Return(admin, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
 		{
 			name: "UserNotFound",
 			body: gin.H{
@@ -470,7 +563,7 @@ func TestUpdateUserAPI(t *testing.T) {
 					Times(1).
 					Return(db.User{}, db.ErrRecordNotFound)
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -494,9 +587,9 @@ func TestUpdateUserAPI(t *testing.T) {
 					Times(1).
 					Return(user, nil)
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.User{}, errors.New("internal error"))
+					Return(db.UpdateUserTxResult{}, errors.New("internal error"))
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -518,7 +611,7 @@ func TestUpdateUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -541,7 +634,7 @@ func TestUpdateUserAPI(t *testing.T) {
 					GetUser(gomock.Any(), gomock.Any()).
 					Times(0)
 				store.EXPECT().
-					UpdateUser(gomock.Any(), gomock.Any()).
+					UpdateUserTx(gomock.Any(), gomock.Any()).
 					Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
@@ -582,6 +675,142 @@ func TestUpdateUserAPI(t *testing.T) {
 }
 
 
+func TestUpdateUserEmailAPI(t *testing.T) {
+	user, password := randomUser(t, util.DepositorRole)
+	user.IsEmailVerified = true
+	newEmail := util.RandomEmail()
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					IsEmailTaken(gomock.Any(), gomock.Eq(db.IsEmailTakenParams{Username: user.Username, Email: newEmail})).
+					Times(1).
+					Return(false, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.UpdateUserTxParams) (db.UpdateUserTxResult, error) {
+						// users.email is left alone until the code is confirmed
+						require.False(t, arg.Email.Valid)
+						require.Equal(t, pgtype.Text{String: newEmail, Valid: true}, arg.PendingEmail)
+
+						// the emails are committed with the pending email
+						outbox, err := emailChangeOutbox(user, newEmail)
+						require.NoError(t, err)
+						require.Equal(t, outbox, arg.Outbox)
+
+						updated := user
+						updated.PendingEmail = arg.PendingEmail
+						return db.UpdateUserTxResult{User: updated}, nil
+					})
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+				distributor.EXPECT().
+					DistributeTaskSendEmailChanged(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp updateUserResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, user.Email, rsp.Email)
+				require.Equal(t, newEmail, rsp.PendingEmail)
+			},
+		},
+		{
+			// used or waiting for its code on another user
+			name: "EmailTaken",
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					IsEmailTaken(gomock.Any(), gomock.Eq(db.IsEmailTakenParams{Username: user.Username, Email: newEmail})).
+					Times(1).
+					Return(true, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "UpdateUserTxError",
+			buildStubs: func(store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					IsEmailTaken(gomock.Any(), gomock.Eq(db.IsEmailTakenParams{Username: user.Username, Email: newEmail})).
+					Times(1).
+					Return(false, nil)
+				store.EXPECT().
+					UpdateUserTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.UpdateUserTxResult{}, fmt.Errorf("connection refused"))
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			tc.buildStubs(store, distributor)
+
+			server := newTestServer(t, store)
+			server.taskDistributor = distributor
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(gin.H{
+				"username":  user.Username,
+				"password":  password,
+				"full_name": user.FullName,
+				"email":     newEmail,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPatch, "/users/update", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
 func randomUser(t *testing.T, role string) (user db.User, password string) {
 	password = util.RandomString(6)
 	hashedPassword, err := util.HashPassword(password)
@@ -1,12 +1,25 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/worker"
 
 	"github.com/gin-gonic/gin"
 )
 
+// errCodeEmailNotVerified lets clients tell the refusal of an unverified user from the other errors
+const errCodeEmailNotVerified = "email_not_verified"
+
+var (
+	errEmailNotVerified     = errors.New("email address is not verified, verify it before moving money")
+	errEmailAlreadyVerified = errors.New("email address is already verified")
+	errEmailAlreadyUsed     = errors.New("email address is used by another user")
+	errInvalidVerifyCode    = errors.New("invalid or expired verification code")
+)
+
 // verifyEmailRequest defines the request parameters for verifying an email
 // @Description Request parameters for email verification
 // @Param email_id query int64 true "ID of the email to verify" example(12345)
@@ -35,7 +48,8 @@ type verifyEmailResponse struct {
 // @Produce json
 // @Param request query verifyEmailRequest true "Verify Email Request"
 // @Success 200 {object} verifyEmailResponse "Email verified successfully"
-// @Failure 400 {object} gin.H "Bad Request - Invalid input data"
+// @Failure 400 {object} gin.H "Bad Request - Invalid input data, or a code that is wrong, expired or sent to an address the user moved away from"
+// @Failure 409 {object} gin.H "Conflict - Email used by another user"
 // @Failure 500 {object} gin.H "Internal Server Error"
 // @Router /verify-email [get]
 func (server *Server) verifyEmail(ctx *gin.Context) {
@@ -52,8 +66,17 @@ func (server *Server) verifyEmail(ctx *gin.Context) {
 		SecretCode: req.SecretCode,
 	}
 
-	result, err := server.store.VerifyEmailTx(ctx, arg)
+	result, err := server.store.VerifyEmailTx(auditContext(ctx), arg)
 	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusBadRequest, errorResponse(errInvalidVerifyCode))
+			return
+		}
+		// another user confirmed the same address first
+		if db.ErrorCode(err) == db.UniqueViolation {
+			ctx.JSON(http.StatusConflict, errorResponse(errEmailAlreadyUsed))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
@@ -64,3 +87,116 @@ func (server *Server) verifyEmail(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+// requireVerifiedEmail refuses the request with 403 and the email_not_verified code until the user verified its email.
+// It runs after authMiddleware on the routes that move money.
+func (server *Server) requireVerifiedEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if !user.IsEmailVerified {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": errEmailNotVerified.Error(),
+			"code":  errCodeEmailNotVerified,
+		})
+		return
+	}
+
+	ctx.Next()
+}
+
+// resendVerifyEmail sends a fresh verification email to the user, the codes sent before stop working
+// @Summary Resend Verification Email
+// @Description Send a new verification email to the logged in user, at its pending new email if it asked for one. The codes of the previous emails can't be used anymore. A user can ask again once the cooldown is over.
+// @Tags email
+// @Produce json
+// @Success 200 {object} gin.H "Verification email sent"
+// @Failure 401 {object} gin.H "Unauthorized"
+// @Failure 404 {object} gin.H "Not Found - User not found"
+// @Failure 409 {object} gin.H "Conflict - Email is already verified and no new email is pending"
+// @Failure 429 {object} gin.H "Too Many Requests - A verification email was sent too recently"
+// @Failure 500 {object} gin.H "Internal Server Error"
+// @Security BearerAuth
+// @Router /users/verify_email/resend [post]
+func (server *Server) resendVerifyEmail(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	if user.IsEmailVerified && !user.PendingEmail.Valid {
+		ctx.JSON(http.StatusConflict, errorResponse(errEmailAlreadyVerified))
+		return
+	}
+
+	// a pending new email is the one waiting for a code
+	verifyEmail, err := worker.NewOutboxMessage(
+		worker.TaskSendVerifyEmail,
+		&worker.PayloadSendVerifyEmail{Username: user.Username, Email: user.PendingEmail.String},
+		worker.QueueCritical,
+		10,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the verification email is enqueued by the outbox relay, as for a signup or an email change
+	_, err = server.store.ResendVerifyEmailTx(ctx, db.ResendVerifyEmailTxParams{
+		Username: user.Username,
+		Cooldown: server.config.VerifyEmailCooldown,
+		Outbox:   []db.CreateOutboxMessageParams{verifyEmail},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrCooldown) {
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "verification email sent"})
+}
+
+// emailChangeOutbox builds the tasks of an email change, a verification code to the new email of the user
+// and a notice to its current email, to be written in the transaction of the change
+func emailChangeOutbox(user db.User, newEmail string) ([]db.CreateOutboxMessageParams, error) {
+	verifyEmail, err := worker.NewOutboxMessage(
+		worker.TaskSendVerifyEmail,
+		&worker.PayloadSendVerifyEmail{Username: user.Username, Email: newEmail},
+		worker.QueueCritical,
+		10,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	emailChanged, err := worker.NewOutboxMessage(
+		worker.TaskSendEmailChanged,
+		&worker.PayloadSendEmailChanged{Username: user.Username, OldEmail: user.Email, NewEmail: newEmail},
+		worker.QueueCritical,
+		10,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []db.CreateOutboxMessageParams{verifyEmail, emailChanged}, nil
+}
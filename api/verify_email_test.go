@@ -1,6 +1,9 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -10,8 +13,12 @@ import (
 	mockdb "simplebank/db/mock"
 	db "simplebank/db/sqlc"
 	"simplebank/util"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
 
+	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,7 +52,13 @@ func TestVerifyEmailAPI(t *testing.T) {
 				store.EXPECT().
 					VerifyEmailTx(gomock.Any(), arg).
 					Times(1).
-					Return(db.VerifyEmailTxResult{User: user, VerifyEmail: verifyEmail}, nil)
+					DoAndReturn(func(ctx context.Context, _ db.VerifyEmailTxParams) (db.VerifyEmailTxResult, error) {
+						// nobody is logged in, the audit event carries the request alone
+						actor := db.AuditActorFromContext(ctx)
+						require.Empty(t, actor.Username)
+						require.NotEmpty(t, actor.RequestID)
+						return db.VerifyEmailTxResult{User: user, VerifyEmail: verifyEmail}, nil
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -67,6 +80,39 @@ func TestVerifyEmailAPI(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "EmailConfirmedByAnotherUser",
+			query: Query{
+				emailId:    verifyEmail.ID,
+				secretCode: verifyEmail.SecretCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidCode",
+			query: Query{
+				emailId:    verifyEmail.ID,
+				secretCode: verifyEmail.SecretCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.VerifyEmailTxResult{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireBodyMatchError(t, recorder.Body, errInvalidVerifyCode)
+			},
+		},
 		{
 			name: "InternalServerError",
 			query: Query{
@@ -129,3 +175,233 @@ func randomVerifyEmail(user db.User) db.VerifyEmail {
 		ExpiredAt:  time.Now().Add(10 * time.Second),
 	}
 }
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	user, _ := randomUser(t, util.DepositorRole)
+
+	routes := []string{"/accounts", "/transfers"}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "EmailNotVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				unverified := user
+				unverified.IsEmailVerified = false
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(unverified, nil)
+				store.EXPECT().CreateAccountTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+
+				var rsp gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.Equal(t, errCodeEmailNotVerified, rsp["code"])
+				require.Equal(t, errEmailNotVerified.Error(), rsp["error"])
+			},
+		},
+		{
+			name: "UserNotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, db.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(db.User{}, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for _, route := range routes {
+		for i := range testCases {
+			tc := testCases[i]
+
+			t.Run(route+"/"+tc.name, func(t *testing.T) {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+
+				store := mockdb.NewMockStore(ctrl)
+				tc.buildStubs(store)
+
+				server := newTestServer(t, store)
+				recorder := httptest.NewRecorder()
+
+				request, err := http.NewRequest(http.MethodPost, route, bytes.NewReader([]byte("{}")))
+				require.NoError(t, err)
+
+				addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+				server.router.ServeHTTP(recorder, request)
+				tc.checkResponse(t, recorder)
+			})
+		}
+	}
+}
+
+func TestResendVerifyEmailAPI(t *testing.T) {
+	user, _ := randomUser(t, util.DepositorRole)
+	user.IsEmailVerified = false
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				expectVerifyEmailOutbox(t, store, worker.PayloadSendVerifyEmail{Username: user.Username})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "PendingEmail",
+			buildStubs: func(store *mockdb.MockStore) {
+				changing := user
+				changing.IsEmailVerified = true
+				changing.PendingEmail = pgtype.Text{String: util.RandomEmail(), Valid: true}
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(changing, nil)
+				expectVerifyEmailOutbox(t, store, worker.PayloadSendVerifyEmail{Username: user.Username, Email: changing.PendingEmail.String})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "AlreadyVerified",
+			buildStubs: func(store *mockdb.MockStore) {
+				verified := user
+				verified.IsEmailVerified = true
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(verified, nil)
+				store.EXPECT().
+					ResendVerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "Cooldown",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ResendVerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResendVerifyEmailTxResult{}, db.ErrCooldown)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "OutboxError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(user, nil)
+				store.EXPECT().
+					ResendVerifyEmailTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ResendVerifyEmailTxResult{}, fmt.Errorf("internal server error"))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			// nothing is enqueued to Redis directly, the outbox relay does it
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			distributor.EXPECT().DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+			server := newTestServer(t, store)
+			server.taskDistributor = distributor
+			server.config.VerifyEmailCooldown = time.Minute
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodPost, "/users/verify_email/resend", nil)
+			require.NoError(t, err)
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Role, user.Username, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+// expectVerifyEmailOutbox expects the verification email with the payload to be written to the outbox
+func expectVerifyEmailOutbox(t *testing.T, store *mockdb.MockStore, payload worker.PayloadSendVerifyEmail) {
+	store.EXPECT().
+		ResendVerifyEmailTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, arg db.ResendVerifyEmailTxParams) (db.ResendVerifyEmailTxResult, error) {
+			require.Equal(t, payload.Username, arg.Username)
+			require.Equal(t, time.Minute, arg.Cooldown)
+			require.Len(t, arg.Outbox, 1)
+			require.Equal(t, worker.TaskSendVerifyEmail, arg.Outbox[0].TaskType)
+			require.Equal(t, worker.QueueCritical, arg.Outbox[0].Queue)
+
+			var got worker.PayloadSendVerifyEmail
+			require.NoError(t, json.Unmarshal(arg.Outbox[0].Payload, &got))
+			require.Equal(t, payload, got)
+			return db.ResendVerifyEmailTxResult{Outbox: []db.Outbox{{ID: 1, TaskType: arg.Outbox[0].TaskType, Payload: arg.Outbox[0].Payload}}}, nil
+		})
+}
+
+// stubVerifiedEmail lets requireVerifiedEmail accept every user in the tests of the routes that move money
+func stubVerifiedEmail(store *mockdb.MockStore) {
+	store.EXPECT().
+		GetUser(gomock.Any(), gomock.Any()).
+		AnyTimes().
+		DoAndReturn(func(_ any, username string) (db.User, error) {
+			return db.User{Username: username, IsEmailVerified: true}, nil
+		})
+}
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "balance_within_overdraft_limit";
+
+ALTER TABLE "accounts" DROP COLUMN "overdraft_limit";
@@ -0,0 +1,9 @@
+ALTER TABLE "accounts" ADD COLUMN "overdraft_limit" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'how far below zero the balance may go, set by bankers';
+
+-- accounts that are already overdrawn keep their current debt as their limit
+UPDATE "accounts" SET "overdraft_limit" = -"balance" WHERE "balance" < 0;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "balance_within_overdraft_limit" CHECK ("balance" + "overdraft_limit" >= 0) NOT VALID;
+ALTER TABLE "accounts" VALIDATE CONSTRAINT "balance_within_overdraft_limit";
@@ -0,0 +1,9 @@
+ALTER TABLE "transfers" DROP COLUMN "fx_quote_id";
+
+ALTER TABLE "transfers" DROP COLUMN "fx_rate";
+
+ALTER TABLE "transfers" DROP COLUMN "converted_amount";
+
+DROP TABLE IF EXISTS "fx_quotes";
+
+DROP TABLE IF EXISTS "fx_rates";
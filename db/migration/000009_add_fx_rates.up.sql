@@ -0,0 +1,48 @@
+CREATE TABLE "fx_rates" (
+  "base_currency" varchar NOT NULL,
+  "quote_currency" varchar NOT NULL,
+  "rate" bigint NOT NULL,
+  "updated_by" varchar NOT NULL,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("base_currency", "quote_currency")
+);
+
+CREATE TABLE "fx_quotes" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "from_currency" varchar NOT NULL,
+  "to_currency" varchar NOT NULL,
+  "rate" bigint NOT NULL,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "fx_rates"."rate" IS 'quote currency units for one base currency unit, scaled by 10^8';
+
+COMMENT ON COLUMN "fx_quotes"."rate" IS 'rate locked until expires_at, scaled by 10^8';
+
+ALTER TABLE "fx_rates" ADD CONSTRAINT "positive_rate" CHECK ("rate" > 0);
+
+ALTER TABLE "fx_rates" ADD CONSTRAINT "distinct_currencies" CHECK ("base_currency" <> "quote_currency");
+
+ALTER TABLE "fx_rates" ADD FOREIGN KEY ("updated_by") REFERENCES "users" ("username");
+
+ALTER TABLE "fx_quotes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "transfers" ADD COLUMN "converted_amount" bigint;
+
+UPDATE "transfers" SET "converted_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "converted_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD CONSTRAINT "positive_converted_amount" CHECK ("converted_amount" > 0);
+
+ALTER TABLE "transfers" ADD COLUMN "fx_rate" bigint;
+
+ALTER TABLE "transfers" ADD COLUMN "fx_quote_id" uuid UNIQUE;
+
+COMMENT ON COLUMN "transfers"."converted_amount" IS 'credited to the destination account, in its currency';
+
+COMMENT ON COLUMN "transfers"."fx_rate" IS 'applied rate scaled by 10^8, null when both accounts share the currency';
+
+ALTER TABLE "transfers" ADD FOREIGN KEY ("fx_quote_id") REFERENCES "fx_quotes" ("id");
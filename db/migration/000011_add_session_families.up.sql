@@ -0,0 +1,18 @@
+ALTER TABLE "sessions" ADD COLUMN "family_id" uuid;
+
+-- every existing session starts its own family
+UPDATE "sessions" SET "family_id" = "id";
+
+ALTER TABLE "sessions" ALTER COLUMN "family_id" SET NOT NULL;
+
+ALTER TABLE "sessions" ADD COLUMN "parent_id" uuid;
+
+ALTER TABLE "sessions" ADD COLUMN "rotated_at" timestamptz;
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("parent_id") REFERENCES "sessions" ("id");
+
+CREATE INDEX ON "sessions" ("family_id");
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the login session the refresh token was first issued for';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'set once the refresh token was exchanged for a new one, using it again is a reuse';
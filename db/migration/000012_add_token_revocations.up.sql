@@ -0,0 +1,18 @@
+CREATE TABLE "revoked_tokens" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "expired_at" timestamptz NOT NULL,
+  "revoked_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "revoked_tokens" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "revoked_tokens" ("expired_at");
+
+COMMENT ON COLUMN "revoked_tokens"."id" IS 'id of the revoked access token payload';
+
+COMMENT ON COLUMN "revoked_tokens"."expired_at" IS 'the access token is refused anyway after it, so the row can be deleted';
+
+ALTER TABLE "users" ADD COLUMN "tokens_revoked_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z';
+
+COMMENT ON COLUMN "users"."tokens_revoked_at" IS 'access tokens issued before are refused';
@@ -0,0 +1,25 @@
+ALTER TABLE "users" ADD COLUMN "totp_secret" varchar;
+
+ALTER TABLE "users" ADD COLUMN "is_totp_enabled" boolean NOT NULL DEFAULT false;
+
+ALTER TABLE "users" ADD COLUMN "totp_last_step" bigint NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'AES-GCM encrypted TOTP secret, set at enrollment';
+
+COMMENT ON COLUMN "users"."is_totp_enabled" IS 'set once the first code confirmed the enrollment, login then needs a second factor';
+
+COMMENT ON COLUMN "users"."totp_last_step" IS 'period of the last accepted code, a code is never accepted twice';
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "used_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE UNIQUE INDEX ON "recovery_codes" ("username", "hashed_code");
+
+COMMENT ON COLUMN "recovery_codes"."hashed_code" IS 'SHA-256 of the one-time code';
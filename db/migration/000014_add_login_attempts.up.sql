@@ -0,0 +1,15 @@
+CREATE TABLE "login_attempts" (
+  "kind" varchar NOT NULL,
+  "value" varchar NOT NULL,
+  "failures" integer NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL,
+  PRIMARY KEY ("kind", "value")
+);
+
+CREATE INDEX ON "login_attempts" ("last_failed_at");
+
+COMMENT ON COLUMN "login_attempts"."kind" IS 'what the failures are counted for: username or ip';
+
+COMMENT ON COLUMN "login_attempts"."value" IS 'the username or the client IP, the username is not required to exist';
+
+COMMENT ON COLUMN "login_attempts"."failures" IS 'consecutive failed logins, the backoff and the lockout are derived from it and last_failed_at';
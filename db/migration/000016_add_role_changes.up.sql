@@ -0,0 +1,16 @@
+CREATE TABLE "role_changes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "old_role" varchar NOT NULL,
+  "new_role" varchar NOT NULL,
+  "changed_by" varchar NOT NULL,
+  "changed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "role_changes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "role_changes" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "role_changes" ("username");
+
+COMMENT ON COLUMN "role_changes"."changed_by" IS 'the banker or admin who changed the role';
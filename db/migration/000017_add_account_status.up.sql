@@ -0,0 +1,5 @@
+ALTER TABLE "accounts" ADD COLUMN "status" varchar NOT NULL DEFAULT 'active';
+
+COMMENT ON COLUMN "accounts"."status" IS 'active or frozen, a frozen account can not move money';
+
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status" CHECK ("status" IN ('active', 'frozen'));
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS "account_status_changes";
+
+UPDATE "accounts" SET "status" = 'frozen' WHERE "status" = 'closed';
+
+ALTER TABLE "accounts" DROP CONSTRAINT "account_status";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status" CHECK ("status" IN ('active', 'frozen'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active or frozen, a frozen account can not move money';
@@ -0,0 +1,22 @@
+ALTER TABLE "accounts" DROP CONSTRAINT "account_status";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "account_status" CHECK ("status" IN ('active', 'frozen', 'closed'));
+
+COMMENT ON COLUMN "accounts"."status" IS 'active, frozen or closed, only an active account can move money';
+
+CREATE TABLE "account_status_changes" (
+  "id" bigserial PRIMARY KEY,
+  "account_id" bigint NOT NULL,
+  "old_status" varchar NOT NULL,
+  "new_status" varchar NOT NULL,
+  "changed_by" varchar NOT NULL,
+  "changed_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_status_changes" ADD FOREIGN KEY ("changed_by") REFERENCES "users" ("username");
+
+CREATE INDEX ON "account_status_changes" ("account_id");
+
+COMMENT ON COLUMN "account_status_changes"."changed_by" IS 'the owner or the banker who changed the status';
@@ -0,0 +1,21 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar NOT NULL,
+  "payload" jsonb NOT NULL,
+  "queue" varchar NOT NULL,
+  "max_retry" int NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" varchar,
+  "available_at" timestamptz NOT NULL DEFAULT (now()),
+  "sent_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "failed_at" timestamptz
+);
+
+CREATE INDEX ON "outbox" ("available_at") WHERE "sent_at" IS NULL AND "failed_at" IS NULL;
+
+COMMENT ON COLUMN "outbox"."available_at" IS 'the relay publishes the task from then, pushed back after each failed attempt';
+
+COMMENT ON COLUMN "outbox"."sent_at" IS 'set once the task is enqueued, null while it is pending';
+
+COMMENT ON COLUMN "outbox"."failed_at" IS 'set when the relay gives up on the task, after max_retry attempts or when it can never be published';
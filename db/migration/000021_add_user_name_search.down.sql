@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "users_username_trgm_idx";
+
+DROP INDEX IF EXISTS "users_full_name_trgm_idx";
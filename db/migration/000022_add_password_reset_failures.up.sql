@@ -0,0 +1,3 @@
+ALTER TABLE "password_resets" ADD COLUMN "failed_attempts" int NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN "password_resets"."failed_attempts" IS 'wrong codes tried for the reset, it is used up after too many';
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "outbox_sent_at_idx";
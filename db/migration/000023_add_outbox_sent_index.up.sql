@@ -0,0 +1 @@
+CREATE INDEX ON "outbox" ("sent_at") WHERE "sent_at" IS NOT NULL;
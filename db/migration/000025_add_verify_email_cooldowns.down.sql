@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "cooldowns";
+
+ALTER TABLE "verify_emails" DROP COLUMN "task_id";
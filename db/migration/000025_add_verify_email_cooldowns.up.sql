@@ -0,0 +1,18 @@
+ALTER TABLE "verify_emails" ADD COLUMN "task_id" varchar UNIQUE;
+
+COMMENT ON COLUMN "verify_emails"."task_id" IS 'task that created the code, the same task delivered again sends the same code';
+
+CREATE TABLE "cooldowns" (
+  "kind" varchar NOT NULL,
+  "value" varchar NOT NULL,
+  "available_at" timestamptz NOT NULL,
+  PRIMARY KEY ("kind", "value")
+);
+
+CREATE INDEX ON "cooldowns" ("available_at");
+
+COMMENT ON COLUMN "cooldowns"."kind" IS 'what is throttled, such as verify_email';
+
+COMMENT ON COLUMN "cooldowns"."value" IS 'who is throttled, such as a username';
+
+COMMENT ON COLUMN "cooldowns"."available_at" IS 'when the next request is allowed';
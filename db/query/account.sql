@@ -17,6 +17,10 @@ SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
+-- name: ListAccountCurrencies :many
+SELECT id, currency FROM accounts
+WHERE id = ANY(sqlc.arg(ids)::bigint[]);
+
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE owner = $1
@@ -24,6 +28,14 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE
+  owner = sqlc.arg(owner)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
 -- name: UpdateAccount :one
 UPDATE accounts
 SET balance = $2
@@ -36,6 +48,14 @@ SET balance = balance + sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
--- name: DeleteAccount :exec
-DELETE FROM accounts
-WHERE id = $1;
\ No newline at end of file
+-- name: UpdateAccountOverdraftLimit :one
+UPDATE accounts
+SET overdraft_limit = sqlc.arg(overdraft_limit)
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
@@ -0,0 +1,15 @@
+-- name: CreateAccountStatusChange :one
+INSERT INTO account_status_changes (
+  account_id,
+  old_status,
+  new_status,
+  changed_by
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListAccountStatusChanges :many
+SELECT * FROM account_status_changes
+WHERE account_id = $1
+ORDER BY id;
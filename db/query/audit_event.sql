@@ -0,0 +1,29 @@
+-- name: CreateAuditEvent :one
+INSERT INTO audit_events (
+  actor_username,
+  actor_role,
+  action,
+  target_type,
+  target_id,
+  before,
+  after,
+  client_ip,
+  user_agent,
+  request_id
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+RETURNING *;
+
+-- name: ListAuditEvents :many
+SELECT * FROM audit_events
+WHERE
+  (sqlc.narg(actor_username)::text IS NULL OR actor_username = sqlc.narg(actor_username))
+  AND (sqlc.narg(action)::text IS NULL OR action = sqlc.narg(action))
+  AND (sqlc.narg(target_type)::text IS NULL OR target_type = sqlc.narg(target_type))
+  AND (sqlc.narg(target_id)::text IS NULL OR target_id = sqlc.narg(target_id))
+  AND (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time))
+  AND (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time))
+  AND id < sqlc.arg(before_id)
+ORDER BY id DESC
+LIMIT sqlc.arg('limit');
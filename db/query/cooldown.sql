@@ -0,0 +1,11 @@
+-- name: StartCooldown :one
+INSERT INTO cooldowns (
+  kind,
+  value,
+  available_at
+) VALUES (
+  sqlc.arg(kind), sqlc.arg(value), sqlc.arg(available_at)
+) ON CONFLICT (kind, value) DO UPDATE SET
+  available_at = EXCLUDED.available_at
+WHERE cooldowns.available_at <= sqlc.arg(now)
+RETURNING *;
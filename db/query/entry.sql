@@ -1,9 +1,10 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
   account_id,
-  amount
+  amount,
+  transfer_id
 ) VALUES (
-  $1, $2
+  $1, $2, $3
 ) RETURNING *;
 
 -- name: GetEntry :one
@@ -15,4 +16,37 @@ SELECT * FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
-OFFSET $3;
\ No newline at end of file
+OFFSET $3;
+
+-- name: GetAccountBalanceAt :one
+SELECT (
+  accounts.balance - COALESCE((
+    SELECT SUM(entries.amount) FROM entries
+    WHERE entries.account_id = accounts.id AND entries.created_at >= sqlc.arg(at)
+  ), 0)
+)::bigint AS balance
+FROM accounts
+WHERE accounts.id = sqlc.arg(account_id);
+
+-- name: ListAccountStatementEntries :many
+SELECT
+  entries.id,
+  entries.account_id,
+  entries.amount,
+  entries.created_at,
+  entries.transfer_id,
+  counterparty.id AS counterparty_account_id,
+  counterparty.owner AS counterparty_owner
+FROM entries
+LEFT JOIN transfers ON transfers.id = entries.transfer_id
+LEFT JOIN accounts AS counterparty ON counterparty.id = (
+  CASE WHEN transfers.from_account_id = entries.account_id
+    THEN transfers.to_account_id
+    ELSE transfers.from_account_id
+  END
+)
+WHERE
+  entries.account_id = sqlc.arg(account_id)
+  AND entries.created_at >= sqlc.arg(start_time)
+  AND entries.created_at < sqlc.arg(end_time)
+ORDER BY entries.created_at, entries.id;
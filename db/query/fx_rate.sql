@@ -0,0 +1,31 @@
+-- name: UpsertFxRate :one
+INSERT INTO fx_rates (
+  base_currency,
+  quote_currency,
+  rate,
+  updated_by
+) VALUES (
+  $1, $2, $3, $4
+)
+ON CONFLICT (base_currency, quote_currency) DO UPDATE
+SET rate = EXCLUDED.rate, updated_by = EXCLUDED.updated_by, updated_at = now()
+RETURNING *;
+
+-- name: GetFxRate :one
+SELECT * FROM fx_rates
+WHERE base_currency = $1 AND quote_currency = $2
+LIMIT 1;
+
+-- name: GetFxRateForUpdate :one
+SELECT * FROM fx_rates
+WHERE base_currency = $1 AND quote_currency = $2
+LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: ListFxRates :many
+SELECT * FROM fx_rates
+ORDER BY base_currency, quote_currency;
+
+-- name: DeleteFxRate :exec
+DELETE FROM fx_rates
+WHERE base_currency = $1 AND quote_currency = $2;
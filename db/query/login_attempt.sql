@@ -0,0 +1,44 @@
+-- name: CountLoginAttempt :one
+INSERT INTO login_attempts (
+  kind,
+  value,
+  failures,
+  last_failed_at
+) VALUES (
+  sqlc.arg(kind), sqlc.arg(value), 1, sqlc.arg(attempted_at)
+) ON CONFLICT (kind, value) DO UPDATE SET
+  failures = CASE
+    WHEN login_attempts.last_failed_at < sqlc.arg(reset_before) THEN 1
+    ELSE login_attempts.failures + 1
+  END,
+  last_failed_at = EXCLUDED.last_failed_at
+WHERE login_attempts.failures <= 0
+  OR login_attempts.last_failed_at < sqlc.arg(reset_before)
+  OR login_attempts.last_failed_at + CASE
+    WHEN login_attempts.failures >= sqlc.arg(max_failures)::int THEN sqlc.arg(lockout_duration_ms)::bigint
+    ELSE LEAST(sqlc.arg(backoff_base_ms)::bigint * power(2, login_attempts.failures - 1), sqlc.arg(lockout_duration_ms)::bigint)
+  END * interval '1 millisecond' <= sqlc.arg(attempted_at)
+RETURNING *;
+
+-- name: UncountLoginAttempt :exec
+UPDATE login_attempts
+SET failures = failures - 1
+WHERE kind = $1 AND value = $2 AND failures > 0;
+
+-- name: GetLoginAttempt :one
+SELECT * FROM login_attempts
+WHERE kind = $1 AND value = $2
+LIMIT 1;
+
+-- name: ListLoginAttempts :many
+SELECT * FROM login_attempts
+WHERE last_failed_at >= $1
+ORDER BY last_failed_at DESC;
+
+-- name: DeleteLoginAttempt :exec
+DELETE FROM login_attempts
+WHERE kind = $1 AND value = $2;
+
+-- name: DeleteStaleLoginAttempts :exec
+DELETE FROM login_attempts
+WHERE last_failed_at < $1;
@@ -0,0 +1,44 @@
+-- name: CreateOutboxMessage :one
+INSERT INTO outbox (
+  task_type,
+  payload,
+  queue,
+  max_retry
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetOutboxMessage :one
+SELECT * FROM outbox
+WHERE id = $1 LIMIT 1;
+
+-- name: ClaimOutboxMessages :many
+UPDATE outbox
+SET available_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM outbox
+  WHERE sent_at IS NULL AND failed_at IS NULL AND available_at <= now()
+  ORDER BY id
+  LIMIT sqlc.arg(batch_size)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE outbox
+SET sent_at = now()
+WHERE id = $1;
+
+-- name: MarkOutboxMessageFailed :exec
+UPDATE outbox
+SET
+  attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  available_at = sqlc.arg(available_at),
+  failed_at = sqlc.narg(failed_at)
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteSentOutboxMessages :execrows
+DELETE FROM outbox
+WHERE sent_at < sqlc.arg(sent_before)::timestamptz;
@@ -0,0 +1,33 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  username,
+  email,
+  secret_code
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetPasswordReset :one
+SELECT * FROM password_resets
+WHERE id = $1 LIMIT 1;
+
+-- name: UpdatePasswordReset :one
+UPDATE password_resets
+SET
+  is_used = TRUE
+WHERE
+  id = @id
+  AND secret_code = @secret_code
+  AND is_used = FALSE
+  AND expired_at > now()
+RETURNING *;
+
+-- name: RecordPasswordResetFailure :exec
+UPDATE password_resets
+SET
+  failed_attempts = failed_attempts + 1,
+  is_used = failed_attempts + 1 >= sqlc.arg(max_attempts)::int
+WHERE
+  id = sqlc.arg(id)
+  AND is_used = FALSE;
@@ -0,0 +1,18 @@
+-- name: CreateRevokedToken :exec
+INSERT INTO revoked_tokens (
+  id,
+  username,
+  expired_at
+) VALUES (
+  $1, $2, $3
+) ON CONFLICT (id) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM revoked_tokens
+  WHERE id = $1
+);
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM revoked_tokens
+WHERE expired_at < $1;
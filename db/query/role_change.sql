@@ -0,0 +1,15 @@
+-- name: CreateRoleChange :one
+INSERT INTO role_changes (
+  username,
+  old_role,
+  new_role,
+  changed_by
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: ListRoleChanges :many
+SELECT * FROM role_changes
+WHERE username = $1
+ORDER BY id;
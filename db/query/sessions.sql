@@ -6,11 +6,58 @@ INSERT INTO sessions (
   user_agent,
   client_ip,
   is_blocked,
-  expires_at
+  expires_at,
+  family_id,
+  parent_id
 ) VALUES (
-  $1, $2, $3, $4, $5, $6, $7
+  $1, $2, $3, $4, $5, $6, $7, $8, $9
 ) RETURNING *;
 
 -- name: GetSession :one
 SELECT * FROM sessions
-WHERE id = $1 LIMIT 1;
\ No newline at end of file
+WHERE id = $1 LIMIT 1;
+
+-- name: BlockUserSessions :many
+UPDATE sessions
+SET
+  is_blocked = TRUE
+WHERE
+  username = $1
+  AND is_blocked = FALSE
+RETURNING *;
+
+-- name: BlockSession :one
+UPDATE sessions
+SET
+  is_blocked = TRUE
+WHERE
+  id = $1
+RETURNING *;
+
+-- name: ListActiveSessions :many
+SELECT * FROM sessions
+WHERE
+  username = $1
+  AND is_blocked = FALSE
+  AND rotated_at IS NULL
+  AND expires_at > now()
+ORDER BY created_at DESC;
+
+-- name: RotateSession :one
+UPDATE sessions
+SET
+  rotated_at = now()
+WHERE
+  id = $1
+  AND rotated_at IS NULL
+  AND is_blocked = FALSE
+RETURNING *;
+
+-- name: BlockSessionFamily :many
+UPDATE sessions
+SET
+  is_blocked = TRUE
+WHERE
+  family_id = $1
+  AND is_blocked = FALSE
+RETURNING *;
@@ -2,9 +2,12 @@
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  converted_amount,
+  fx_rate,
+  fx_quote_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
 RETURNING *;
 
@@ -14,9 +17,26 @@ WHERE id = $1 LIMIT 1;
 
 -- name: ListTransfers :many
 SELECT * FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
+WHERE
+    (
+      (sqlc.arg(outgoing)::boolean AND from_account_id = sqlc.arg(account_id)) OR
+      (sqlc.arg(incoming)::boolean AND to_account_id = sqlc.arg(account_id))
+    )
+    AND (sqlc.narg(start_time)::timestamptz IS NULL OR created_at >= sqlc.narg(start_time))
+    AND (sqlc.narg(end_time)::timestamptz IS NULL OR created_at < sqlc.narg(end_time))
+    AND (sqlc.narg(min_amount)::bigint IS NULL OR amount >= sqlc.narg(min_amount))
+    AND (sqlc.narg(max_amount)::bigint IS NULL OR amount <= sqlc.narg(max_amount))
 ORDER BY id
-LIMIT $3
-OFFSET $4;
\ No newline at end of file
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
+
+-- name: ListUserTransfersAfter :many
+SELECT * FROM transfers
+WHERE
+    (
+      from_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner)) OR
+      to_account_id IN (SELECT id FROM accounts WHERE owner = sqlc.arg(owner))
+    )
+    AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
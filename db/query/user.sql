@@ -14,6 +14,36 @@ RETURNING *;
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserForUpdate :one
+SELECT * FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: IsEmailTaken :one
+SELECT EXISTS (
+  SELECT 1 FROM users
+  WHERE username <> sqlc.arg(username)
+    AND (email = sqlc.arg(email) OR pending_email = sqlc.arg(email))
+);
+
+-- name: SearchUsers :many
+-- the wildcards and the escape character of LIKE in name and email are escaped, so they match themselves
+SELECT * FROM users
+WHERE
+  (sqlc.narg(name)::text IS NULL
+    OR full_name ILIKE '%' || replace(replace(replace(sqlc.narg(name), '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\'
+    OR username ILIKE '%' || replace(replace(replace(sqlc.narg(name), '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\')
+  AND (sqlc.narg(email)::text IS NULL
+    OR email ILIKE '%' || replace(replace(replace(sqlc.narg(email), '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\')
+  AND (sqlc.narg(is_email_verified)::boolean IS NULL OR is_email_verified = sqlc.narg(is_email_verified))
+  AND username > sqlc.arg(after_username)
+ORDER BY username
+LIMIT sqlc.arg('limit');
+
 -- name: UpdateUser :one
 UPDATE users
 SET
@@ -22,7 +52,69 @@ SET
   full_name = COALESCE(sqlc.narg(full_name), full_name),
   email = COALESCE(sqlc.narg(email), email),
   role = COALESCE(sqlc.narg(role), role),
-  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified)
+  is_email_verified = COALESCE(sqlc.narg(is_email_verified), is_email_verified),
+  pending_email = COALESCE(sqlc.narg(pending_email), pending_email)
+WHERE
+  username = sqlc.arg(username)
+RETURNING *;
+
+-- name: ConfirmUserEmail :one
+UPDATE users
+SET
+  email = sqlc.arg(email),
+  is_email_verified = TRUE,
+  pending_email = CASE WHEN pending_email = sqlc.arg(email) THEN NULL ELSE pending_email END
 WHERE
   username = sqlc.arg(username)
+  AND (email = sqlc.arg(email) OR pending_email = sqlc.arg(email))
+RETURNING *;
+
+-- name: RevokeUserTokens :exec
+UPDATE users
+SET
+  tokens_revoked_at = $2
+WHERE
+  username = $1
+  AND tokens_revoked_at < $2;
+
+-- name: GetUserTokensRevokedAt :one
+SELECT password_changed_at, tokens_revoked_at FROM users
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserTotpSecret :one
+UPDATE users
+SET
+  totp_secret = $2,
+  totp_last_step = 0
+WHERE
+  username = $1
+  AND is_totp_enabled = FALSE
+RETURNING *;
+
+-- name: EnableUserTotp :one
+UPDATE users
+SET
+  is_totp_enabled = TRUE
+WHERE
+  username = $1
+  AND totp_secret IS NOT NULL
+RETURNING *;
+
+-- name: DisableUserTotp :one
+UPDATE users
+SET
+  totp_secret = NULL,
+  is_totp_enabled = FALSE,
+  totp_last_step = 0
+WHERE
+  username = $1
+RETURNING *;
+
+-- name: UseUserTotpStep :one
+UPDATE users
+SET
+  totp_last_step = $2
+WHERE
+  username = $1
+  AND totp_last_step < $2
 RETURNING *;
@@ -2,12 +2,17 @@
 INSERT INTO verify_emails (
   username,
   email,
-  secret_code
+  secret_code,
+  task_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
 RETURNING *;
 
+-- name: GetVerifyEmailByTaskID :one
+SELECT * FROM verify_emails
+WHERE task_id = $1 LIMIT 1;
+
 -- name: UpdateVerifyEmail :one
 UPDATE verify_emails 
 SET
@@ -17,4 +22,13 @@ WHERE
   AND secret_code = @secret_code
   AND is_used = FALSE
   AND expired_at > now()
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: ExpireUserVerifyEmails :exec
+UPDATE verify_emails
+SET
+  expired_at = now()
+WHERE
+  username = @username
+  AND is_used = FALSE
+  AND expired_at > now();
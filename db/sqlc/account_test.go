@@ -10,12 +10,17 @@ import (
 )
 
 func createRandomAccount(t *testing.T) Account {
+	return createRandomAccountWithCurrency(t, util.RandomCurrency())
+}
+
+// createRandomAccountWithCurrency creates a random account, transfers between accounts of the same currency need no fx quote
+func createRandomAccountWithCurrency(t *testing.T, currency string) Account {
 	user := createRandomUser(t)
 
 	arg := CreateAccountParams{
 		Owner:    user.Username,
 		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Currency: currency,
 	}
 
 	account, err := testStore.CreateAccount(context.Background(), arg)
@@ -30,6 +35,25 @@ func createRandomAccount(t *testing.T) Account {
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
+	require.Equal(t, util.AccountStatusActive, account.Status)
+
+	return account
+}
+
+// createFundedAccount creates a random account holding the given balance
+func createFundedAccount(t *testing.T, balance int64) Account {
+	return createFundedAccountWithCurrency(t, util.RandomCurrency(), balance)
+}
+
+func createFundedAccountWithCurrency(t *testing.T, currency string, balance int64) Account {
+	account := createRandomAccountWithCurrency(t, currency)
+
+	account, err := testStore.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	require.Equal(t, balance, account.Balance)
 
 	return account
 }
@@ -89,22 +113,6 @@ func TestUpdateAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
-func TestDeleteAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-
-	err := testStore.DeleteAccount(context.Background(), account1.ID)
-
-	// testing using package Testify
-	require.NoError(t, err)
-
-	account2, err := testStore.GetAccount(context.Background(), account1.ID)
-
-	// testing using package Testify
-	require.Error(t, err)
-	require.EqualError(t, err, ErrRecordNotFound.Error())
-	require.Empty(t, account2)
-}
-
 func TestListAccount(t *testing.T) {
 	var lastAccount Account
 
@@ -129,3 +137,80 @@ func TestListAccount(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+func TestUpdateAccountOverdraftLimit(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	arg := UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: util.RandomMoney(),
+	}
+
+	account2, err := testStore.UpdateAccountOverdraftLimit(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, account2)
+
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, account1.Balance, account2.Balance)
+	require.Equal(t, arg.OverdraftLimit, account2.OverdraftLimit)
+}
+
+func TestListAccountsAfter(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account1.Owner,
+		Currency: account1.Currency,
+	})
+	require.NoError(t, err)
+
+	accounts, err := testStore.ListAccountsAfter(context.Background(), ListAccountsAfterParams{
+		Owner: account1.Owner,
+		Limit: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account1.ID, accounts[0].ID)
+
+	// the next page starts after the last account of the previous one
+	accounts, err = testStore.ListAccountsAfter(context.Background(), ListAccountsAfterParams{
+		Owner:   account1.Owner,
+		AfterID: accounts[0].ID,
+		Limit:   5,
+	})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account2.ID, accounts[0].ID)
+}
+
+func TestListAccountCurrencies(t *testing.T) {
+	account1 := createRandomAccountWithCurrency(t, util.USD)
+	account2 := createRandomAccountWithCurrency(t, util.JPY)
+
+	// an account repeated in the IDs is returned once
+	rows, err := testStore.ListAccountCurrencies(context.Background(), []int64{account1.ID, account2.ID, account1.ID})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ListAccountCurrenciesRow{
+		{ID: account1.ID, Currency: util.USD},
+		{ID: account2.ID, Currency: util.JPY},
+	}, rows)
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	account1 := createRandomAccount(t)
+
+	account2, err := testStore.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: util.AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.ID, account2.ID)
+	require.Equal(t, util.AccountStatusFrozen, account2.Status)
+
+	// the check constraint refuses unknown statuses
+	_, err = testStore.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: "sleeping",
+	})
+	require.Error(t, err)
+	require.Equal(t, CheckViolation, ErrorCode(err))
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// actions of the audit events written by the store along with the change they describe
+const (
+	AuditActionCreateTransfer      = "transfer.create"
+	AuditActionCreateUser          = "user.create"
+	AuditActionUpdateUser          = "user.update"
+	AuditActionChangeUserRole      = "user.change_role"
+	AuditActionResetUserPassword   = "user.reset_password"
+	AuditActionConfirmUserEmail    = "user.confirm_email"
+	AuditActionEnableUserTotp      = "user.totp_enable"
+	AuditActionDisableUserTotp     = "user.totp_disable"
+	AuditActionCreateAccount       = "account.create"
+	AuditActionChangeAccountStatus = "account.change_status"
+	AuditActionChangeOverdraft     = "account.change_overdraft_limit"
+	AuditActionDeposit             = "account.deposit"
+	AuditActionWithdraw            = "account.withdraw"
+	AuditActionCreateSession       = "session.create"
+	AuditActionBlockSession        = "session.block"
+	AuditActionBlockUserSessions   = "session.block_user"
+	AuditActionBlockSessionFamily  = "session.block_family"
+	AuditActionSetFxRate           = "fx_rate.set"
+	AuditActionDeleteFxRate        = "fx_rate.delete"
+	AuditActionClearLockout        = "lockout.clear"
+)
+
+// types of the targets of the audit events
+const (
+	AuditTargetTransfer      = "transfer"
+	AuditTargetUser          = "user"
+	AuditTargetAccount       = "account"
+	AuditTargetSession       = "session"
+	AuditTargetSessionFamily = "session_family"
+	AuditTargetFxRate        = "fx_rate"
+	AuditTargetLockout       = "lockout"
+)
+
+// AuditActor describes who made the request behind a change.
+// Username and Role are empty when nobody is logged in, as for a signup.
+type AuditActor struct {
+	Username  string
+	Role      string
+	ClientIP  string
+	UserAgent string
+	RequestID string
+}
+
+type auditActorKey struct{}
+
+// WithAuditActor returns a copy of ctx carrying the actor of the audit events the store writes with it
+func WithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext returns the actor set by WithAuditActor, the zero actor when there is none
+func AuditActorFromContext(ctx context.Context) AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor
+}
+
+// recordAuditEvent writes an audit event with the queries of the transaction of the change, so both are committed or rolled back together.
+// before and after are stored as JSON, before is nil when the target is created and after when it is deleted.
+func recordAuditEvent(ctx context.Context, q *Queries, action string, targetType string, targetID string, before any, after any) error {
+	actor := AuditActorFromContext(ctx)
+
+	arg := CreateAuditEventParams{
+		ActorUsername: pgtype.Text{String: actor.Username, Valid: actor.Username != ""},
+		ActorRole:     pgtype.Text{String: actor.Role, Valid: actor.Role != ""},
+		Action:        action,
+		TargetType:    targetType,
+		TargetID:      targetID,
+		ClientIp:      actor.ClientIP,
+		UserAgent:     actor.UserAgent,
+		RequestID:     actor.RequestID,
+	}
+
+	var err error
+	if before != nil {
+		arg.Before, err = json.Marshal(before)
+		if err != nil {
+			return err
+		}
+	}
+	if after != nil {
+		arg.After, err = json.Marshal(after)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = q.CreateAuditEvent(ctx, arg)
+	return err
+}
+
+// RecordAuditEventParams contains the input parameters of RecordAuditEvent
+type RecordAuditEventParams struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// RecordAuditEvent writes an audit event on its own, for the changes made outside the database such as clearing a login lockout.
+// The changes made in the database record their audit event in their own transaction instead.
+func (store *SQLStore) RecordAuditEvent(ctx context.Context, arg RecordAuditEventParams) error {
+	return recordAuditEvent(ctx, store.Queries, arg.Action, arg.TargetType, arg.TargetID, arg.Before, arg.After)
+}
+
+// auditedUser is what the audit log keeps of a user, never the password hash or the TOTP secret
+type auditedUser struct {
+	Username          string    `json:"username"`
+	FullName          string    `json:"full_name"`
+	Email             string    `json:"email"`
+	PendingEmail      string    `json:"pending_email,omitempty"`
+	Role              string    `json:"role"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	IsTotpEnabled     bool      `json:"is_totp_enabled"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+}
+
+func newAuditedUser(user User) auditedUser {
+	return auditedUser{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PendingEmail:      user.PendingEmail.String,
+		Role:              user.Role,
+		IsEmailVerified:   user.IsEmailVerified,
+		IsTotpEnabled:     user.IsTotpEnabled,
+		PasswordChangedAt: user.PasswordChangedAt,
+	}
+}
+
+// auditedSession is what the audit log keeps of a session, never the refresh token
+type auditedSession struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	UserAgent string    `json:"user_agent"`
+	ClientIp  string    `json:"client_ip"`
+	IsBlocked bool      `json:"is_blocked"`
+	ExpiresAt time.Time `json:"expires_at"`
+	FamilyID  uuid.UUID `json:"family_id"`
+}
+
+func newAuditedSession(session Session) auditedSession {
+	return auditedSession{
+		ID:        session.ID,
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		IsBlocked: session.IsBlocked,
+		ExpiresAt: session.ExpiresAt,
+		FamilyID:  session.FamilyID,
+	}
+}
+
+func newAuditedSessions(sessions []Session) []auditedSession {
+	audited := make([]auditedSession, 0, len(sessions))
+	for _, session := range sessions {
+		audited = append(audited, newAuditedSession(session))
+	}
+	return audited
+}
@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"simplebank/util"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// lastAuditEvent returns the newest audit event of a target
+func lastAuditEvent(t *testing.T, targetType string, targetID string) AuditEvent {
+	events, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		TargetType: pgtype.Text{String: targetType, Valid: true},
+		TargetID:   pgtype.Text{String: targetID, Valid: true},
+		BeforeID:   math.MaxInt64,
+		Limit:      1,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	return events[0]
+}
+
+func TestListAuditEvents(t *testing.T) {
+	actor := createRandomUser(t)
+	ctx := WithAuditActor(context.Background(), AuditActor{
+		Username:  actor.Username,
+		Role:      util.BankerRole,
+		ClientIP:  "10.0.0.1",
+		UserAgent: "test",
+		RequestID: util.RandomString(12),
+	})
+
+	var accounts []Account
+	for i := 0; i < 3; i++ {
+		result, err := testStore.CreateAccountTx(ctx, CreateAccountTxParams{
+			CreateAccountParams: CreateAccountParams{
+				Owner:    actor.Username,
+				Currency: util.RandomCurrency(),
+			},
+		})
+		require.NoError(t, err)
+		accounts = append(accounts, result.Account)
+	}
+
+	arg := ListAuditEventsParams{
+		ActorUsername: pgtype.Text{String: actor.Username, Valid: true},
+		Action:        pgtype.Text{String: AuditActionCreateAccount, Valid: true},
+		FromTime:      pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+		BeforeID:      math.MaxInt64,
+		Limit:         2,
+	}
+
+	// newest first
+	events, err := testStore.ListAuditEvents(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, strconv.FormatInt(accounts[2].ID, 10), events[0].TargetID)
+	require.Equal(t, strconv.FormatInt(accounts[1].ID, 10), events[1].TargetID)
+
+	arg.BeforeID = events[1].ID
+	events, err = testStore.ListAuditEvents(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+
+	event := events[0]
+	require.Equal(t, strconv.FormatInt(accounts[0].ID, 10), event.TargetID)
+	require.Equal(t, AuditTargetAccount, event.TargetType)
+	require.Equal(t, util.BankerRole, event.ActorRole.String)
+	require.Equal(t, "10.0.0.1", event.ClientIp)
+	require.Equal(t, "test", event.UserAgent)
+	require.NotEmpty(t, event.RequestID)
+	require.Nil(t, event.Before)
+
+	var account Account
+	require.NoError(t, json.Unmarshal(event.After, &account))
+	require.Equal(t, accounts[0].ID, account.ID)
+}
+
+func TestAuditEventsAppendOnly(t *testing.T) {
+	account := createRandomAccount(t)
+	_, err := testStore.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    account.Owner,
+			Currency: account.Currency,
+		},
+	})
+	require.NoError(t, err)
+
+	connPool := testStore.(*SQLStore).connPool
+
+	_, err = connPool.Exec(context.Background(), "UPDATE audit_events SET action = 'forged'")
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = connPool.Exec(context.Background(), "DELETE FROM audit_events")
+	require.ErrorContains(t, err, "append-only")
+
+	_, err = connPool.Exec(context.Background(), "TRUNCATE audit_events")
+	require.ErrorContains(t, err, "append-only")
+}
+
+func TestRecordAuditEvent(t *testing.T) {
+	banker := util.RandomOwner()
+	targetID := "ip:" + util.RandomString(8)
+
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: banker, Role: util.BankerRole, RequestID: "req-123"})
+	err := testStore.RecordAuditEvent(ctx, RecordAuditEventParams{
+		Action:     AuditActionClearLockout,
+		TargetType: AuditTargetLockout,
+		TargetID:   targetID,
+		Before:     map[string]int{"failures": 3},
+	})
+	require.NoError(t, err)
+
+	event := lastAuditEvent(t, AuditTargetLockout, targetID)
+	require.Equal(t, AuditActionClearLockout, event.Action)
+	require.Equal(t, banker, event.ActorUsername.String)
+	require.Equal(t, "req-123", event.RequestID)
+	require.JSONEq(t, `{"failures": 3}`, string(event.Before))
+	require.Empty(t, event.After)
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Kinds of request throttled by a cooldown
+const (
+	CooldownVerifyEmail   = "verify_email"   // resending a verification email, per username
+	CooldownPasswordReset = "password_reset" // asking a password reset code, per username
+)
+
+// enterCooldown lets a request of the kind through and makes the next ones of the same value wait for duration,
+// or returns ErrCooldown while an earlier one is still cooling down.
+// The check and the new cooldown are a single upsert, so concurrent requests can't all get through.
+func enterCooldown(ctx context.Context, q *Queries, kind, value string, duration time.Duration) error {
+	now := time.Now()
+
+	_, err := q.StartCooldown(ctx, StartCooldownParams{
+		Kind:        kind,
+		Value:       value,
+		AvailableAt: now.Add(duration),
+		Now:         now,
+	})
+	if errors.Is(err, ErrRecordNotFound) {
+		return ErrCooldown
+	}
+	return err
+}
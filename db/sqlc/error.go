@@ -10,14 +10,61 @@ import (
 const (
 	ForeignKeyViolation = "23503"
 	UniqueViolation     = "23505"
+	CheckViolation      = "23514"
 )
 
 var ErrRecordNotFound = pgx.ErrNoRows
 
+// ErrInsufficientFunds is returned when a debit would take an account below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a different request
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+// ErrFxQuoteRequired is returned when a transfer between accounts in different currencies has no quote
+var ErrFxQuoteRequired = errors.New("transfer between different currencies requires an fx quote")
+
+// ErrInvalidFxQuote is returned when a quote doesn't exist, belongs to someone else or covers another currency pair
+var ErrInvalidFxQuote = errors.New("invalid fx quote")
+
+// ErrFxQuoteExpired is returned when the rate of a quote is no longer locked
+var ErrFxQuoteExpired = errors.New("fx quote has expired")
+
+// ErrFxAmountTooSmall is returned when the amount of a transfer converts to nothing in the currency of the to account
+var ErrFxAmountTooSmall = errors.New("amount is too small to convert")
+
+// ErrFxQuoteUsed is returned when a quote was already used by another transfer
+var ErrFxQuoteUsed = errors.New("fx quote was already used")
+
+// ErrCooldown is returned when the same request was made too recently
+var ErrCooldown = errors.New("requested too recently, try again later")
+
+// ErrRefreshTokenReused is returned when a refresh token that was already exchanged for a new one is presented again
+var ErrRefreshTokenReused = errors.New("refresh token was already used")
+
+// ErrRoleUnchanged is returned when a user is given the role they already have
+var ErrRoleUnchanged = errors.New("user already has this role")
+
+// ErrAccountNotActive is returned when money would move in or out of a frozen or closed account
+var ErrAccountNotActive = errors.New("account is not active")
+
+// ErrInvalidStatusTransition is returned when an account can not go from its current status to the requested one
+var ErrInvalidStatusTransition = errors.New("invalid account status transition")
+
+// ErrAccountBalanceNotZero is returned when an account with money left is closed without a sweep account
+var ErrAccountBalanceNotZero = errors.New("account balance is not zero")
+
+// ErrInvalidSweepAccount is returned when the remaining balance of a closed account can not go to the nominated account
+var ErrInvalidSweepAccount = errors.New("invalid sweep account")
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
 
+var ErrCheckViolation = &pgconn.PgError{
+	Code: CheckViolation,
+}
+
 func ErrorCode(err error) string {
 	var pgErr *pgconn.PgError
 	if errors.As(err, &pgErr) {
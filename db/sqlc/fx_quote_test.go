@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createFxQuoteForUser(t *testing.T, username string, fromCurrency string, toCurrency string, rate int64, expiresAt time.Time) FxQuote {
+	arg := CreateFxQuoteParams{
+		ID:           uuid.New(),
+		Username:     username,
+		FromCurrency: fromCurrency,
+		ToCurrency:   toCurrency,
+		Rate:         rate,
+		ExpiresAt:    expiresAt,
+	}
+
+	quote, err := testStore.CreateFxQuote(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, quote)
+
+	require.Equal(t, arg.ID, quote.ID)
+	require.Equal(t, arg.Username, quote.Username)
+	require.Equal(t, arg.FromCurrency, quote.FromCurrency)
+	require.Equal(t, arg.ToCurrency, quote.ToCurrency)
+	require.Equal(t, arg.Rate, quote.Rate)
+	require.WithinDuration(t, arg.ExpiresAt, quote.ExpiresAt, time.Second)
+	require.NotZero(t, quote.CreatedAt)
+
+	return quote
+}
+
+func TestCreateFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+	createFxQuoteForUser(t, user.Username, util.USD, util.EUR, util.FxRateScale, time.Now().Add(time.Minute))
+}
+
+func TestGetFxQuote(t *testing.T) {
+	user := createRandomUser(t)
+	quote1 := createFxQuoteForUser(t, user.Username, util.USD, util.EUR, util.FxRateScale, time.Now().Add(time.Minute))
+
+	quote2, err := testStore.GetFxQuote(context.Background(), quote1.ID)
+	require.NoError(t, err)
+	require.Equal(t, quote1.Username, quote2.Username)
+	require.Equal(t, quote1.Rate, quote2.Rate)
+	require.WithinDuration(t, quote1.ExpiresAt, quote2.ExpiresAt, time.Second)
+
+	_, err = testStore.GetFxQuote(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomFxRate(t *testing.T) FxRate {
+	user := createRandomUser(t)
+
+	// a random pair keeps the tests from overwriting each other's rates
+	arg := UpsertFxRateParams{
+		BaseCurrency:  util.RandomString(3),
+		QuoteCurrency: util.RandomString(3),
+		Rate:          util.RandomInt(1, 2*util.FxRateScale),
+		UpdatedBy:     user.Username,
+	}
+
+	rate, err := testStore.UpsertFxRate(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, rate)
+
+	require.Equal(t, arg.BaseCurrency, rate.BaseCurrency)
+	require.Equal(t, arg.QuoteCurrency, rate.QuoteCurrency)
+	require.Equal(t, arg.Rate, rate.Rate)
+	require.Equal(t, arg.UpdatedBy, rate.UpdatedBy)
+	require.NotZero(t, rate.UpdatedAt)
+
+	return rate
+}
+
+func TestUpsertFxRate(t *testing.T) {
+	rate1 := createRandomFxRate(t)
+	user := createRandomUser(t)
+
+	// the same pair is updated in place
+	rate2, err := testStore.UpsertFxRate(context.Background(), UpsertFxRateParams{
+		BaseCurrency:  rate1.BaseCurrency,
+		QuoteCurrency: rate1.QuoteCurrency,
+		Rate:          rate1.Rate + 1,
+		UpdatedBy:     user.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate1.Rate+1, rate2.Rate)
+	require.Equal(t, user.Username, rate2.UpdatedBy)
+	require.True(t, !rate2.UpdatedAt.Before(rate1.UpdatedAt))
+}
+
+func TestUpsertFxRateInvalid(t *testing.T) {
+	user := createRandomUser(t)
+	currency := util.RandomString(3)
+
+	_, err := testStore.UpsertFxRate(context.Background(), UpsertFxRateParams{
+		BaseCurrency:  currency,
+		QuoteCurrency: util.RandomString(3),
+		Rate:          0,
+		UpdatedBy:     user.Username,
+	})
+	require.Error(t, err)
+	require.Equal(t, CheckViolation, ErrorCode(err))
+
+	_, err = testStore.UpsertFxRate(context.Background(), UpsertFxRateParams{
+		BaseCurrency:  currency,
+		QuoteCurrency: currency,
+		Rate:          util.FxRateScale,
+		UpdatedBy:     user.Username,
+	})
+	require.Error(t, err)
+	require.Equal(t, CheckViolation, ErrorCode(err))
+}
+
+func TestGetFxRate(t *testing.T) {
+	rate1 := createRandomFxRate(t)
+
+	rate2, err := testStore.GetFxRate(context.Background(), GetFxRateParams{
+		BaseCurrency:  rate1.BaseCurrency,
+		QuoteCurrency: rate1.QuoteCurrency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, rate1.Rate, rate2.Rate)
+	require.Equal(t, rate1.UpdatedBy, rate2.UpdatedBy)
+	require.WithinDuration(t, rate1.UpdatedAt, rate2.UpdatedAt, time.Second)
+
+	// rates are not symmetric
+	_, err = testStore.GetFxRate(context.Background(), GetFxRateParams{
+		BaseCurrency:  rate1.QuoteCurrency,
+		QuoteCurrency: rate1.BaseCurrency,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListFxRates(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		createRandomFxRate(t)
+	}
+
+	rates, err := testStore.ListFxRates(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(rates), 3)
+}
+
+func TestDeleteFxRate(t *testing.T) {
+	rate := createRandomFxRate(t)
+
+	arg := DeleteFxRateParams{
+		BaseCurrency:  rate.BaseCurrency,
+		QuoteCurrency: rate.QuoteCurrency,
+	}
+	err := testStore.DeleteFxRate(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testStore.GetFxRate(context.Background(), GetFxRateParams(arg))
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
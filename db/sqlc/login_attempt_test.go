@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createLoginAttempt(t *testing.T, kind string, value string, attemptedAt time.Time) LoginAttempt {
+	attempt, err := testStore.CountLoginAttempt(context.Background(), CountLoginAttemptParams{
+		Kind:              kind,
+		Value:             value,
+		AttemptedAt:       attemptedAt,
+		ResetBefore:       attemptedAt.Add(-time.Hour),
+		MaxFailures:       3,
+		LockoutDurationMs: time.Hour.Milliseconds(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, kind, attempt.Kind)
+	require.Equal(t, value, attempt.Value)
+	require.WithinDuration(t, attemptedAt, attempt.LastFailedAt, time.Second)
+	return attempt
+}
+
+func TestCountLoginAttempt(t *testing.T) {
+	username := util.RandomOwner()
+	now := time.Now()
+
+	arg := CountLoginAttemptParams{
+		Kind:              "username",
+		Value:             username,
+		AttemptedAt:       now,
+		ResetBefore:       now.Add(-time.Hour),
+		MaxFailures:       3,
+		LockoutDurationMs: time.Hour.Milliseconds(),
+		BackoffBaseMs:     time.Minute.Milliseconds(),
+	}
+
+	attempt, err := testStore.CountLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.Failures)
+
+	// backing off for a minute after the first failure, the attempt is not counted
+	arg.AttemptedAt = now.Add(30 * time.Second)
+	_, err = testStore.CountLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	arg.AttemptedAt = now.Add(time.Minute)
+	attempt, err = testStore.CountLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), attempt.Failures)
+
+	// an attempt taken back leaves the earlier failures
+	err = testStore.UncountLoginAttempt(context.Background(), UncountLoginAttemptParams{Kind: "username", Value: username})
+	require.NoError(t, err)
+
+	got, err := testStore.GetLoginAttempt(context.Background(), GetLoginAttemptParams{Kind: "username", Value: username})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), got.Failures)
+
+	// the last failure is older than reset_before, counting starts over
+	arg.AttemptedAt = now.Add(2 * time.Hour)
+	arg.ResetBefore = now.Add(time.Hour)
+	attempt, err = testStore.CountLoginAttempt(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), attempt.Failures)
+}
+
+func TestDeleteLoginAttempt(t *testing.T) {
+	ip := util.RandomString(12)
+
+	createLoginAttempt(t, "ip", ip, time.Now())
+
+	arg := GetLoginAttemptParams{Kind: "ip", Value: ip}
+	err := testStore.DeleteLoginAttempt(context.Background(), DeleteLoginAttemptParams(arg))
+	require.NoError(t, err)
+
+	_, err = testStore.GetLoginAttempt(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListLoginAttempts(t *testing.T) {
+	stale := util.RandomOwner()
+	recent := util.RandomOwner()
+
+	createLoginAttempt(t, "username", stale, time.Now().Add(-time.Hour))
+	createLoginAttempt(t, "username", recent, time.Now())
+
+	attempts, err := testStore.ListLoginAttempts(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	values := make([]string, len(attempts))
+	for i, attempt := range attempts {
+		values[i] = attempt.Value
+	}
+	require.Contains(t, values, recent)
+	require.NotContains(t, values, stale)
+
+	err = testStore.DeleteStaleLoginAttempts(context.Background(), time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	_, err = testStore.GetLoginAttempt(context.Background(), GetLoginAttemptParams{Kind: "username", Value: stale})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Account struct {
@@ -16,6 +17,46 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	// how far below zero the balance may go, set by bankers
+	OverdraftLimit int64 `json:"overdraft_limit"`
+	// active, frozen or closed, only an active account can move money
+	Status string `json:"status"`
+}
+
+type AccountStatusChange struct {
+	ID        int64  `json:"id"`
+	AccountID int64  `json:"account_id"`
+	OldStatus string `json:"old_status"`
+	NewStatus string `json:"new_status"`
+	// the owner or the banker who changed the status
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type AuditEvent struct {
+	ID int64 `json:"id"`
+	// null when nobody is logged in, as for a signup
+	ActorUsername pgtype.Text `json:"actor_username"`
+	ActorRole     pgtype.Text `json:"actor_role"`
+	Action        string      `json:"action"`
+	TargetType    string      `json:"target_type"`
+	TargetID      string      `json:"target_id"`
+	// the target before the change, null when it is created
+	Before    []byte    `json:"before"`
+	After     []byte    `json:"after"`
+	ClientIp  string    `json:"client_ip"`
+	UserAgent string    `json:"user_agent"`
+	RequestID string    `json:"request_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Cooldown struct {
+	// what is throttled, such as verify_email
+	Kind string `json:"kind"`
+	// who is throttled, such as a username
+	Value string `json:"value"`
+	// when the next request is allowed
+	AvailableAt time.Time `json:"available_at"`
 }
 
 type Entry struct {
@@ -24,6 +65,105 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// set when the entry is one side of a transfer
+	TransferID pgtype.Int8 `json:"transfer_id"`
+}
+
+type FxQuote struct {
+	ID           uuid.UUID `json:"id"`
+	Username     string    `json:"username"`
+	FromCurrency string    `json:"from_currency"`
+	ToCurrency   string    `json:"to_currency"`
+	// rate locked until expires_at, scaled by 10^8
+	Rate      int64     `json:"rate"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type FxRate struct {
+	BaseCurrency  string `json:"base_currency"`
+	QuoteCurrency string `json:"quote_currency"`
+	// quote currency units for one base currency unit, scaled by 10^8
+	Rate      int64     `json:"rate"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type IdempotencyKey struct {
+	Username    string `json:"username"`
+	Key         string `json:"key"`
+	RequestHash string `json:"request_hash"`
+	// serialized result of the first request
+	Response  []byte    `json:"response"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type LoginAttempt struct {
+	// what the failures are counted for: username or ip
+	Kind string `json:"kind"`
+	// the username or the client IP, the username is not required to exist
+	Value string `json:"value"`
+	// consecutive failed logins, the backoff and the lockout are derived from it and last_failed_at
+	Failures     int32     `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+type Outbox struct {
+	ID        int64       `json:"id"`
+	TaskType  string      `json:"task_type"`
+	Payload   []byte      `json:"payload"`
+	Queue     string      `json:"queue"`
+	MaxRetry  int32       `json:"max_retry"`
+	Attempts  int32       `json:"attempts"`
+	LastError pgtype.Text `json:"last_error"`
+	// the relay publishes the task from then, pushed back after each failed attempt
+	AvailableAt time.Time `json:"available_at"`
+	// set once the task is enqueued, null while it is pending
+	SentAt    pgtype.Timestamptz `json:"sent_at"`
+	CreatedAt time.Time          `json:"created_at"`
+	// set when the relay gives up on the task, after max_retry attempts or when it can never be published
+	FailedAt pgtype.Timestamptz `json:"failed_at"`
+}
+
+type PasswordReset struct {
+	ID         int64     `json:"id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	SecretCode string    `json:"secret_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+	// wrong codes tried for the reset, it is used up after too many
+	FailedAttempts int32 `json:"failed_attempts"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 of the one-time code
+	HashedCode string             `json:"hashed_code"`
+	UsedAt     pgtype.Timestamptz `json:"used_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+}
+
+type RevokedToken struct {
+	// id of the revoked access token payload
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// the access token is refused anyway after it, so the row can be deleted
+	ExpiredAt time.Time `json:"expired_at"`
+	RevokedAt time.Time `json:"revoked_at"`
+}
+
+type RoleChange struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	OldRole  string `json:"old_role"`
+	NewRole  string `json:"new_role"`
+	// the banker or admin who changed the role
+	ChangedBy string    `json:"changed_by"`
+	ChangedAt time.Time `json:"changed_at"`
 }
 
 type Session struct {
@@ -35,6 +175,11 @@ type Session struct {
 	IsBlocked    bool      `json:"is_blocked"`
 	ExpiresAt    time.Time `json:"expires_at"`
 	CreatedAt    time.Time `json:"created_at"`
+	// id of the login session the refresh token was first issued for
+	FamilyID uuid.UUID   `json:"family_id"`
+	ParentID pgtype.UUID `json:"parent_id"`
+	// set once the refresh token was exchanged for a new one, using it again is a reuse
+	RotatedAt pgtype.Timestamptz `json:"rotated_at"`
 }
 
 type Transfer struct {
@@ -44,6 +189,11 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// credited to the destination account, in its currency
+	ConvertedAmount int64 `json:"converted_amount"`
+	// applied rate scaled by 10^8, null when both accounts share the currency
+	FxRate    pgtype.Int8 `json:"fx_rate"`
+	FxQuoteID pgtype.UUID `json:"fx_quote_id"`
 }
 
 type User struct {
@@ -55,6 +205,16 @@ type User struct {
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
 	Role              string    `json:"role"`
+	// access tokens issued before are refused
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
+	// AES-GCM encrypted TOTP secret, set at enrollment
+	TotpSecret pgtype.Text `json:"totp_secret"`
+	// set once the first code confirmed the enrollment, login then needs a second factor
+	IsTotpEnabled bool `json:"is_totp_enabled"`
+	// period of the last accepted code, a code is never accepted twice
+	TotpLastStep int64 `json:"totp_last_step"`
+	// new email waiting for its verification code, email keeps the old one until then
+	PendingEmail pgtype.Text `json:"pending_email"`
 }
 
 type VerifyEmail struct {
@@ -65,4 +225,6 @@ type VerifyEmail struct {
 	IsUsed     bool      `json:"is_used"`
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
+	// task that created the code, the same task delivered again sends the same code
+	TaskID pgtype.Text `json:"task_id"`
 }
@@ -0,0 +1,19 @@
+package db
+
+import (
+	"context"
+)
+
+// createOutboxMessages writes the tasks a transaction publishes once committed, the outbox relay sends them.
+// None are left when the transaction rolls back.
+func createOutboxMessages(ctx context.Context, q *Queries, messages []CreateOutboxMessageParams) ([]Outbox, error) {
+	var outbox []Outbox
+	for _, message := range messages {
+		created, err := q.CreateOutboxMessage(ctx, message)
+		if err != nil {
+			return nil, err
+		}
+		outbox = append(outbox, created)
+	}
+	return outbox, nil
+}
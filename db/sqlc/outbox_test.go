@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomOutboxMessage(t *testing.T) Outbox {
+	arg := CreateOutboxMessageParams{
+		TaskType: "task:send_verify_email",
+		Payload:  []byte(`{"username":"` + util.RandomOwner() + `"}`),
+		Queue:    "critical",
+		MaxRetry: 10,
+	}
+
+	message, err := testStore.CreateOutboxMessage(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, message.ID)
+	require.Equal(t, arg.TaskType, message.TaskType)
+	require.JSONEq(t, string(arg.Payload), string(message.Payload))
+	require.Equal(t, arg.Queue, message.Queue)
+	require.Equal(t, arg.MaxRetry, message.MaxRetry)
+	require.Zero(t, message.Attempts)
+	require.False(t, message.LastError.Valid)
+	require.False(t, message.SentAt.Valid)
+	require.False(t, message.FailedAt.Valid)
+	require.WithinDuration(t, time.Now(), message.AvailableAt, time.Second)
+
+	return message
+}
+
+func TestCreateOutboxMessage(t *testing.T) {
+	createRandomOutboxMessage(t)
+}
+
+func TestMarkOutboxMessageFailed(t *testing.T) {
+	message := createRandomOutboxMessage(t)
+	availableAt := time.Now().Add(time.Minute)
+
+	err := testStore.MarkOutboxMessageFailed(context.Background(), MarkOutboxMessageFailedParams{
+		ID:          message.ID,
+		LastError:   pgtype.Text{String: "redis is down", Valid: true},
+		AvailableAt: availableAt,
+	})
+	require.NoError(t, err)
+
+	failed, err := testStore.GetOutboxMessage(context.Background(), message.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failed.Attempts)
+	require.Equal(t, "redis is down", failed.LastError.String)
+	require.WithinDuration(t, availableAt, failed.AvailableAt, time.Second)
+	require.False(t, failed.SentAt.Valid)
+	require.False(t, failed.FailedAt.Valid)
+
+	// a failed message is not claimed again before its backoff is over
+	claimed := claimAllOutboxMessages(t)
+	require.NotContains(t, claimed, message.ID)
+
+	// once it used up its attempts it is never claimed again
+	err = testStore.MarkOutboxMessageFailed(context.Background(), MarkOutboxMessageFailedParams{
+		ID:          message.ID,
+		LastError:   pgtype.Text{String: "redis is down", Valid: true},
+		AvailableAt: time.Now(),
+		FailedAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	})
+	require.NoError(t, err)
+
+	failed, err = testStore.GetOutboxMessage(context.Background(), message.ID)
+	require.NoError(t, err)
+	require.True(t, failed.FailedAt.Valid)
+
+	claimed = claimAllOutboxMessages(t)
+	require.NotContains(t, claimed, message.ID)
+}
+
+// claimAllOutboxMessages claims every pending message and returns their IDs
+func claimAllOutboxMessages(t *testing.T) []int64 {
+	messages, err := testStore.ClaimOutboxMessages(context.Background(), ClaimOutboxMessagesParams{
+		LeaseUntil: time.Now().Add(time.Minute),
+		BatchSize:  1000,
+	})
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestClaimOutboxMessages(t *testing.T) {
+	message := createRandomOutboxMessage(t)
+	leaseUntil := time.Now().Add(time.Minute)
+
+	messages, err := testStore.ClaimOutboxMessages(context.Background(), ClaimOutboxMessagesParams{
+		LeaseUntil: leaseUntil,
+		BatchSize:  1000,
+	})
+	require.NoError(t, err)
+
+	var claimed Outbox
+	for _, m := range messages {
+		if m.ID == message.ID {
+			claimed = m
+		}
+	}
+	require.Equal(t, message.ID, claimed.ID)
+	require.WithinDuration(t, leaseUntil, claimed.AvailableAt, time.Second)
+
+	// a claimed message is hidden from the other relays until its lease is over
+	require.NotContains(t, claimAllOutboxMessages(t), message.ID)
+}
+
+func TestMarkOutboxMessageSent(t *testing.T) {
+	message := createRandomOutboxMessage(t)
+
+	err := testStore.MarkOutboxMessageSent(context.Background(), message.ID)
+	require.NoError(t, err)
+
+	sent, err := testStore.GetOutboxMessage(context.Background(), message.ID)
+	require.NoError(t, err)
+	require.True(t, sent.SentAt.Valid)
+	require.WithinDuration(t, time.Now(), sent.SentAt.Time, time.Second)
+}
+
+func TestDeleteSentOutboxMessages(t *testing.T) {
+	sent := createRandomOutboxMessage(t)
+	pending := createRandomOutboxMessage(t)
+
+	err := testStore.MarkOutboxMessageSent(context.Background(), sent.ID)
+	require.NoError(t, err)
+
+	// a task sent after the cutoff is kept
+	_, err = testStore.DeleteSentOutboxMessages(context.Background(), time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = testStore.GetOutboxMessage(context.Background(), sent.ID)
+	require.NoError(t, err)
+
+	deleted, err := testStore.DeleteSentOutboxMessages(context.Background(), time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testStore.GetOutboxMessage(context.Background(), sent.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// pending tasks are never deleted
+	_, err = testStore.GetOutboxMessage(context.Background(), pending.ID)
+	require.NoError(t, err)
+}
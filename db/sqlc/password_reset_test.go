@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomPasswordReset(t *testing.T) PasswordReset {
+	user1 := createRandomUser(t)
+
+	arg := CreatePasswordResetParams{
+		Username:   user1.Username,
+		Email:      user1.Email,
+		SecretCode: util.RandomString(32),
+	}
+
+	passwordReset, err := testStore.CreatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, passwordReset)
+
+	require.Equal(t, arg.Username, passwordReset.Username)
+	require.Equal(t, arg.Email, passwordReset.Email)
+	require.Equal(t, arg.SecretCode, passwordReset.SecretCode)
+	require.False(t, passwordReset.IsUsed)
+	require.WithinDuration(t, time.Now(), passwordReset.CreatedAt, time.Second)
+	require.WithinDuration(t, time.Now().Add(15*time.Minute), passwordReset.ExpiredAt, time.Second)
+
+	return passwordReset
+}
+
+func TestCreatePasswordReset(t *testing.T) {
+	createRandomPasswordReset(t)
+}
+
+func TestGetPasswordReset(t *testing.T) {
+	passwordReset1 := createRandomPasswordReset(t)
+
+	passwordReset2, err := testStore.GetPasswordReset(context.Background(), passwordReset1.ID)
+	require.NoError(t, err)
+	require.Equal(t, passwordReset1, passwordReset2)
+}
+
+func TestUpdatePasswordReset(t *testing.T) {
+	passwordReset := createRandomPasswordReset(t)
+
+	arg := UpdatePasswordResetParams{
+		ID:         passwordReset.ID,
+		SecretCode: passwordReset.SecretCode,
+	}
+
+	updatedPasswordReset, err := testStore.UpdatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, updatedPasswordReset)
+
+	require.Equal(t, passwordReset.ID, updatedPasswordReset.ID)
+	require.Equal(t, passwordReset.Username, updatedPasswordReset.Username)
+	require.Equal(t, passwordReset.Email, updatedPasswordReset.Email)
+	require.Equal(t, passwordReset.SecretCode, updatedPasswordReset.SecretCode)
+	require.True(t, updatedPasswordReset.IsUsed)
+	require.WithinDuration(t, passwordReset.CreatedAt, updatedPasswordReset.CreatedAt, time.Second)
+	require.WithinDuration(t, passwordReset.ExpiredAt, updatedPasswordReset.ExpiredAt, time.Second)
+}
+
+func TestUpdatePasswordResetUsedOnce(t *testing.T) {
+	passwordReset := createRandomPasswordReset(t)
+
+	arg := UpdatePasswordResetParams{
+		ID:         passwordReset.ID,
+		SecretCode: passwordReset.SecretCode,
+	}
+
+	_, err := testStore.UpdatePasswordReset(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testStore.UpdatePasswordReset(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUpdatePasswordResetWrongCode(t *testing.T) {
+	passwordReset := createRandomPasswordReset(t)
+
+	arg := UpdatePasswordResetParams{
+		ID:         passwordReset.ID,
+		SecretCode: util.RandomString(32),
+	}
+
+	_, err := testStore.UpdatePasswordReset(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -6,31 +6,99 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	BlockSession(ctx context.Context, id uuid.UUID) (Session, error)
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) ([]Session, error)
+	BlockUserSessions(ctx context.Context, username string) ([]Session, error)
+	ClaimOutboxMessages(ctx context.Context, arg ClaimOutboxMessagesParams) ([]Outbox, error)
+	ConfirmUserEmail(ctx context.Context, arg ConfirmUserEmailParams) (User, error)
+	CountLoginAttempt(ctx context.Context, arg CountLoginAttemptParams) (LoginAttempt, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountStatusChange(ctx context.Context, arg CreateAccountStatusChangeParams) (AccountStatusChange, error)
+	CreateAuditEvent(ctx context.Context, arg CreateAuditEventParams) (AuditEvent, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateFxQuote(ctx context.Context, arg CreateFxQuoteParams) (FxQuote, error)
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) (Outbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateRevokedToken(ctx context.Context, arg CreateRevokedTokenParams) error
+	CreateRoleChange(ctx context.Context, arg CreateRoleChangeParams) (RoleChange, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
-	DeleteAccount(ctx context.Context, id int64) error
+	DeleteExpiredIdempotencyKey(ctx context.Context, arg DeleteExpiredIdempotencyKeyParams) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+	DeleteExpiredRevokedTokens(ctx context.Context, expiredAt time.Time) error
+	DeleteFxRate(ctx context.Context, arg DeleteFxRateParams) error
+	DeleteLoginAttempt(ctx context.Context, arg DeleteLoginAttemptParams) error
+	DeleteSentOutboxMessages(ctx context.Context, sentBefore time.Time) (int64, error)
+	DeleteStaleLoginAttempts(ctx context.Context, lastFailedAt time.Time) error
+	DeleteUserRecoveryCodes(ctx context.Context, username string) error
+	DisableUserTotp(ctx context.Context, username string) (User, error)
+	EnableUserTotp(ctx context.Context, username string) (User, error)
+	ExpireUserVerifyEmails(ctx context.Context, username string) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetFxQuote(ctx context.Context, id uuid.UUID) (FxQuote, error)
+	GetFxRate(ctx context.Context, arg GetFxRateParams) (FxRate, error)
+	GetFxRateForUpdate(ctx context.Context, arg GetFxRateForUpdateParams) (FxRate, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLoginAttempt(ctx context.Context, arg GetLoginAttemptParams) (LoginAttempt, error)
+	GetOutboxMessage(ctx context.Context, id int64) (Outbox, error)
+	GetPasswordReset(ctx context.Context, id int64) (PasswordReset, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetUserForUpdate(ctx context.Context, username string) (User, error)
+	GetUserTokensRevokedAt(ctx context.Context, username string) (GetUserTokensRevokedAtRow, error)
+	GetVerifyEmailByTaskID(ctx context.Context, taskID pgtype.Text) (VerifyEmail, error)
+	IsEmailTaken(ctx context.Context, arg IsEmailTakenParams) (bool, error)
+	IsTokenRevoked(ctx context.Context, id uuid.UUID) (bool, error)
+	ListAccountCurrencies(ctx context.Context, ids []int64) ([]ListAccountCurrenciesRow, error)
+	ListAccountStatementEntries(ctx context.Context, arg ListAccountStatementEntriesParams) ([]ListAccountStatementEntriesRow, error)
+	ListAccountStatusChanges(ctx context.Context, accountID int64) ([]AccountStatusChange, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
+	ListActiveSessions(ctx context.Context, username string) ([]Session, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListFxRates(ctx context.Context) ([]FxRate, error)
+	ListLoginAttempts(ctx context.Context, lastFailedAt time.Time) ([]LoginAttempt, error)
+	ListRoleChanges(ctx context.Context, username string) ([]RoleChange, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListUserTransfersAfter(ctx context.Context, arg ListUserTransfersAfterParams) ([]Transfer, error)
+	MarkOutboxMessageFailed(ctx context.Context, arg MarkOutboxMessageFailedParams) error
+	MarkOutboxMessageSent(ctx context.Context, id int64) error
+	RecordPasswordResetFailure(ctx context.Context, arg RecordPasswordResetFailureParams) error
+	RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error
+	RotateSession(ctx context.Context, id uuid.UUID) (Session, error)
+	// the wildcards and the escape character of LIKE in name and email are escaped, so they match themselves
+	SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error)
+	StartCooldown(ctx context.Context, arg StartCooldownParams) (Cooldown, error)
+	UncountLoginAttempt(ctx context.Context, arg UncountLoginAttemptParams) error
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateAccountOverdraftLimit(ctx context.Context, arg UpdateAccountOverdraftLimitParams) (Account, error)
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	UpdatePasswordReset(ctx context.Context, arg UpdatePasswordResetParams) (PasswordReset, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
+	UpdateUserTotpSecret(ctx context.Context, arg UpdateUserTotpSecretParams) (User, error)
 	UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailParams) (VerifyEmail, error)
+	UpsertFxRate(ctx context.Context, arg UpsertFxRateParams) (FxRate, error)
+	UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (RecoveryCode, error)
+	UseUserTotpStep(ctx context.Context, arg UseUserTotpStepParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
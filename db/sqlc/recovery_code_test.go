@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomRecoveryCode(t *testing.T, user User) (RecoveryCode, string) {
+	codes, err := util.GenerateRecoveryCodes(1)
+	require.NoError(t, err)
+
+	arg := CreateRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashRecoveryCode(codes[0]),
+	}
+
+	recoveryCode, err := testStore.CreateRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, recoveryCode.ID)
+	require.Equal(t, arg.Username, recoveryCode.Username)
+	require.Equal(t, arg.HashedCode, recoveryCode.HashedCode)
+	require.False(t, recoveryCode.UsedAt.Valid)
+	require.NotZero(t, recoveryCode.CreatedAt)
+
+	return recoveryCode, codes[0]
+}
+
+func TestCreateRecoveryCode(t *testing.T) {
+	createRandomRecoveryCode(t, createRandomUser(t))
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+	recoveryCode, code := createRandomRecoveryCode(t, user)
+
+	arg := UseRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashRecoveryCode(code),
+	}
+
+	usedCode, err := testStore.UseRecoveryCode(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, recoveryCode.ID, usedCode.ID)
+	require.True(t, usedCode.UsedAt.Valid)
+
+	// a recovery code works once
+	_, err = testStore.UseRecoveryCode(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// and only for its user
+	otherUser := createRandomUser(t)
+	_, code = createRandomRecoveryCode(t, user)
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username:   otherUser.Username,
+		HashedCode: util.HashRecoveryCode(code),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestDeleteUserRecoveryCodes(t *testing.T) {
+	user := createRandomUser(t)
+	_, code := createRandomRecoveryCode(t, user)
+
+	err := testStore.DeleteUserRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashRecoveryCode(code),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
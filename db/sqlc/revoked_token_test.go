@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRevokedToken(t *testing.T) {
+	user := createRandomUser(t)
+	tokenID := uuid.New()
+
+	revoked, err := testStore.IsTokenRevoked(context.Background(), tokenID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	arg := CreateRevokedTokenParams{
+		ID:        tokenID,
+		Username:  user.Username,
+		ExpiredAt: time.Now().Add(time.Minute),
+	}
+	err = testStore.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	// revoking twice is not an error
+	err = testStore.CreateRevokedToken(context.Background(), arg)
+	require.NoError(t, err)
+
+	revoked, err = testStore.IsTokenRevoked(context.Background(), tokenID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+func TestDeleteExpiredRevokedTokens(t *testing.T) {
+	user := createRandomUser(t)
+
+	expired := CreateRevokedTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiredAt: time.Now().Add(-time.Minute),
+	}
+	active := CreateRevokedTokenParams{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiredAt: time.Now().Add(time.Minute),
+	}
+	require.NoError(t, testStore.CreateRevokedToken(context.Background(), expired))
+	require.NoError(t, testStore.CreateRevokedToken(context.Background(), active))
+
+	err := testStore.DeleteExpiredRevokedTokens(context.Background(), time.Now())
+	require.NoError(t, err)
+
+	revoked, err := testStore.IsTokenRevoked(context.Background(), expired.ID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = testStore.IsTokenRevoked(context.Background(), active.ID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
@@ -11,16 +11,23 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func createRandomSession(t *testing.T, user User) Session {
-	arg := CreateSessionParams{
-		ID:           uuid.New(),
+// createRandomSessionParams returns the parameters of a random login session of the user
+func createRandomSessionParams(user User) CreateSessionParams {
+	id := uuid.New()
+	return CreateSessionParams{
+		ID:           id,
 		Username:     user.Username,
 		RefreshToken: util.RandomString(32),
 		UserAgent:    util.RandomString(10),
 		ClientIp:     util.RandomString(15),
 		IsBlocked:    false,
 		ExpiresAt:    time.Now().Add(time.Hour * 24),
+		FamilyID:     id,
 	}
+}
+
+func createRandomSession(t *testing.T, user User) Session {
+	arg := createRandomSessionParams(user)
 
 	session, err := testStore.CreateSession(context.Background(), arg)
 
@@ -34,6 +41,9 @@ func createRandomSession(t *testing.T, user User) Session {
 	require.Equal(t, arg.ClientIp, session.ClientIp)
 	require.Equal(t, arg.IsBlocked, session.IsBlocked)
 	require.WithinDuration(t, arg.ExpiresAt, session.ExpiresAt, time.Second)
+	require.Equal(t, arg.FamilyID, session.FamilyID)
+	require.False(t, session.ParentID.Valid)
+	require.False(t, session.RotatedAt.Valid)
 
 	require.NotZero(t, session.CreatedAt)
 
@@ -62,3 +72,92 @@ func TestGetSession(t *testing.T) {
 	require.WithinDuration(t, session1.ExpiresAt, session2.ExpiresAt, time.Second)
 	require.WithinDuration(t, session1.CreatedAt, session2.CreatedAt, time.Second)
 }
+
+func TestBlockSession(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+
+	session2, err := testStore.BlockSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.True(t, session2.IsBlocked)
+
+	_, err = testStore.BlockSession(context.Background(), uuid.New())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestListActiveSessions(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+	blockedSession := createRandomSession(t, user)
+
+	_, err := testStore.BlockSession(context.Background(), blockedSession.ID)
+	require.NoError(t, err)
+
+	sessions, err := testStore.ListActiveSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+
+	// newest first
+	require.Equal(t, session2.ID, sessions[0].ID)
+	require.Equal(t, session1.ID, sessions[1].ID)
+	for _, session := range sessions {
+		require.Equal(t, user.Username, session.Username)
+		require.False(t, session.IsBlocked)
+	}
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+	otherSession := createRandomSession(t, createRandomUser(t))
+
+	blockedSessions, err := testStore.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, blockedSessions, 2)
+
+	for _, session := range []Session{session1, session2} {
+		blockedSession, err := testStore.GetSession(context.Background(), session.ID)
+		require.NoError(t, err)
+		require.True(t, blockedSession.IsBlocked)
+	}
+
+	otherSession, err = testStore.GetSession(context.Background(), otherSession.ID)
+	require.NoError(t, err)
+	require.False(t, otherSession.IsBlocked)
+}
+
+func TestRotateSession(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+
+	session2, err := testStore.RotateSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session2.ID)
+	require.True(t, session2.RotatedAt.Valid)
+	require.WithinDuration(t, time.Now(), session2.RotatedAt.Time, time.Second)
+
+	// a session is rotated only once
+	_, err = testStore.RotateSession(context.Background(), session1.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestBlockSessionFamily(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	otherSession := createRandomSession(t, user)
+
+	blockedSessions, err := testStore.BlockSessionFamily(context.Background(), session1.FamilyID)
+	require.NoError(t, err)
+	require.Len(t, blockedSessions, 1)
+
+	session1, err = testStore.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, session1.IsBlocked)
+
+	otherSession, err = testStore.GetSession(context.Background(), otherSession.ID)
+	require.NoError(t, err)
+	require.False(t, otherSession.IsBlocked)
+}
@@ -10,8 +10,32 @@ import (
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error)
+	ReplayIdempotentTransfer(ctx context.Context, arg ReplayIdempotentTransferParams) (IdempotentTransferTxResult, error)
+	DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error)
+	WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error)
+	AccountStatementTx(ctx context.Context, arg AccountStatementTxParams) (AccountStatementTxResult, error)
 	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error)
 	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error)
+	CreateVerifyEmailTx(ctx context.Context, arg CreateVerifyEmailTxParams) (CreateVerifyEmailTxResult, error)
+	ResendVerifyEmailTx(ctx context.Context, arg ResendVerifyEmailTxParams) (ResendVerifyEmailTxResult, error)
+	CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetTxParams) (CreatePasswordResetTxResult, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error)
+	EnableTotpTx(ctx context.Context, arg EnableTotpTxParams) (EnableTotpTxResult, error)
+	DisableTotpTx(ctx context.Context, arg DisableTotpTxParams) (DisableTotpTxResult, error)
+	BlockSessionTx(ctx context.Context, arg BlockSessionTxParams) (BlockSessionTxResult, error)
+	BlockUserSessionsTx(ctx context.Context, arg BlockUserSessionsTxParams) (BlockUserSessionsTxResult, error)
+	BlockSessionFamilyTx(ctx context.Context, arg BlockSessionFamilyTxParams) (BlockSessionFamilyTxResult, error)
+	ChangeRoleTx(ctx context.Context, arg ChangeRoleTxParams) (ChangeRoleTxResult, error)
+	ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error)
+	CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error)
+	UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error)
+	CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (CreateSessionTxResult, error)
+	UpdateOverdraftLimitTx(ctx context.Context, arg UpdateOverdraftLimitTxParams) (UpdateOverdraftLimitTxResult, error)
+	UpsertFxRateTx(ctx context.Context, arg UpsertFxRateTxParams) (UpsertFxRateTxResult, error)
+	DeleteFxRateTx(ctx context.Context, arg DeleteFxRateTxParams) (DeleteFxRateTxResult, error)
+	RecordAuditEvent(ctx context.Context, arg RecordAuditEventParams) error
 }
 
 // store provides all functions to execute SQL db queries and transactions
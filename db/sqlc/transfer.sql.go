@@ -7,27 +7,42 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
   from_account_id,
   to_account_id,
-  amount
+  amount,
+  converted_amount,
+  fx_rate,
+  fx_quote_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5, $6
 )
-RETURNING id, from_account_id, to_account_id, amount, created_at
+RETURNING id, from_account_id, to_account_id, amount, created_at, converted_amount, fx_rate, fx_quote_id
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID   int64       `json:"from_account_id"`
+	ToAccountID     int64       `json:"to_account_id"`
+	Amount          int64       `json:"amount"`
+	ConvertedAmount int64       `json:"converted_amount"`
+	FxRate          pgtype.Int8 `json:"fx_rate"`
+	FxQuoteID       pgtype.UUID `json:"fx_quote_id"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRow(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ConvertedAmount,
+		arg.FxRate,
+		arg.FxQuoteID,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -35,12 +50,15 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ConvertedAmount,
+		&i.FxRate,
+		&i.FxQuoteID,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, converted_amount, fx_rate, fx_quote_id FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -53,31 +71,50 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ConvertedAmount,
+		&i.FxRate,
+		&i.FxQuoteID,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
-WHERE 
-    from_account_id = $1 OR
-    to_account_id = $2
+SELECT id, from_account_id, to_account_id, amount, created_at, converted_amount, fx_rate, fx_quote_id FROM transfers
+WHERE
+    (
+      ($1::boolean AND from_account_id = $2) OR
+      ($3::boolean AND to_account_id = $2)
+    )
+    AND ($4::timestamptz IS NULL OR created_at >= $4)
+    AND ($5::timestamptz IS NULL OR created_at < $5)
+    AND ($6::bigint IS NULL OR amount >= $6)
+    AND ($7::bigint IS NULL OR amount <= $7)
 ORDER BY id
-LIMIT $3
-OFFSET $4
+LIMIT $8
+OFFSET $9
 `
 
 type ListTransfersParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Limit         int32 `json:"limit"`
-	Offset        int32 `json:"offset"`
+	Outgoing  bool               `json:"outgoing"`
+	AccountID int64              `json:"account_id"`
+	Incoming  bool               `json:"incoming"`
+	StartTime pgtype.Timestamptz `json:"start_time"`
+	EndTime   pgtype.Timestamptz `json:"end_time"`
+	MinAmount pgtype.Int8        `json:"min_amount"`
+	MaxAmount pgtype.Int8        `json:"max_amount"`
+	Limit     int32              `json:"limit"`
+	Offset    int32              `json:"offset"`
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
 	rows, err := q.db.Query(ctx, listTransfers,
-		arg.FromAccountID,
-		arg.ToAccountID,
+		arg.Outgoing,
+		arg.AccountID,
+		arg.Incoming,
+		arg.StartTime,
+		arg.EndTime,
+		arg.MinAmount,
+		arg.MaxAmount,
 		arg.Limit,
 		arg.Offset,
 	)
@@ -94,6 +131,56 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ConvertedAmount,
+			&i.FxRate,
+			&i.FxQuoteID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTransfersAfter = `-- name: ListUserTransfersAfter :many
+SELECT id, from_account_id, to_account_id, amount, created_at, converted_amount, fx_rate, fx_quote_id FROM transfers
+WHERE
+    (
+      from_account_id IN (SELECT id FROM accounts WHERE owner = $1) OR
+      to_account_id IN (SELECT id FROM accounts WHERE owner = $1)
+    )
+    AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListUserTransfersAfterParams struct {
+	Owner   string `json:"owner"`
+	AfterID int64  `json:"after_id"`
+	Limit   int32  `json:"limit"`
+}
+
+func (q *Queries) ListUserTransfersAfter(ctx context.Context, arg ListUserTransfersAfterParams) ([]Transfer, error) {
+	rows, err := q.db.Query(ctx, listUserTransfersAfter, arg.Owner, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ConvertedAmount,
+			&i.FxRate,
+			&i.FxQuoteID,
 		); err != nil {
 			return nil, err
 		}
@@ -6,14 +6,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
 func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
+	amount := util.RandomMoney()
 	arg := CreateTransferParams{
-		FromAccountID: account1.ID,
-		ToAccountID:   account2.ID,
-		Amount:        util.RandomMoney(),
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          amount,
+		ConvertedAmount: amount,
 	}
 
 	transfer, err := testStore.CreateTransfer(context.Background(), arg)
@@ -25,6 +28,8 @@ func createRandomTransfer(t *testing.T, account1, account2 Account) Transfer {
 	require.Equal(t, transfer.Amount, arg.Amount)
 	require.Equal(t, transfer.FromAccountID, arg.FromAccountID)
 	require.Equal(t, transfer.ToAccountID, arg.ToAccountID)
+	require.Equal(t, transfer.ConvertedAmount, arg.ConvertedAmount)
+	require.False(t, transfer.FxRate.Valid)
 
 	require.NotZero(t, transfer.ID)
 	require.NotZero(t, transfer.FromAccountID)
@@ -68,13 +73,15 @@ func TestListTransfers(t *testing.T) {
 
 	for i := 0; i < 10; i++ {
 		createRandomTransfer(t, account1, account2)
+		createRandomTransfer(t, account2, account1)
 	}
 
 	arg := ListTransfersParams{
-		FromAccountID: account1.ID,
-		ToAccountID:   account2.ID,
-		Limit:         5,
-		Offset:        5,
+		Outgoing:  true,
+		AccountID: account1.ID,
+		Incoming:  true,
+		Limit:     5,
+		Offset:    5,
 	}
 
 	transfers, err := testStore.ListTransfers(context.Background(), arg)
@@ -85,5 +92,104 @@ func TestListTransfers(t *testing.T) {
 
 	for _, transfer := range transfers {
 		require.NotEmpty(t, transfer)
+		require.True(t, transfer.FromAccountID == account1.ID || transfer.ToAccountID == account1.ID)
 	}
 }
+
+func TestListTransfersDirection(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 5; i++ {
+		createRandomTransfer(t, account1, account2)
+		createRandomTransfer(t, account2, account1)
+	}
+
+	// outgoing only
+	transfers, err := testStore.ListTransfers(context.Background(), ListTransfersParams{
+		Outgoing:  true,
+		AccountID: account1.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 5)
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.FromAccountID)
+	}
+
+	// incoming only
+	transfers, err = testStore.ListTransfers(context.Background(), ListTransfersParams{
+		AccountID: account1.ID,
+		Incoming:  true,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 5)
+	for _, transfer := range transfers {
+		require.Equal(t, account1.ID, transfer.ToAccountID)
+	}
+}
+
+func TestListTransfersFilters(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	for i := 0; i < 10; i++ {
+		createRandomTransfer(t, account1, account2)
+	}
+
+	arg := ListTransfersParams{
+		Outgoing:  true,
+		AccountID: account1.ID,
+		Incoming:  true,
+		StartTime: pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true},
+		EndTime:   pgtype.Timestamptz{Time: time.Now().Add(time.Minute), Valid: true},
+		MinAmount: pgtype.Int8{Int64: 100, Valid: true},
+		MaxAmount: pgtype.Int8{Int64: 500, Valid: true},
+		Limit:     10,
+	}
+
+	transfers, err := testStore.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+
+	for _, transfer := range transfers {
+		require.GreaterOrEqual(t, transfer.Amount, int64(100))
+		require.LessOrEqual(t, transfer.Amount, int64(500))
+	}
+
+	// a time range in the past matches nothing
+	arg.StartTime = pgtype.Timestamptz{Time: time.Now().Add(-time.Hour), Valid: true}
+	arg.EndTime = pgtype.Timestamptz{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	transfers, err = testStore.ListTransfers(context.Background(), arg)
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
+
+func TestListUserTransfersAfter(t *testing.T) {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account3 := createRandomAccount(t)
+
+	outgoing := createRandomTransfer(t, account1, account2)
+	incoming := createRandomTransfer(t, account3, account1)
+	createRandomTransfer(t, account2, account3)
+
+	transfers, err := testStore.ListUserTransfersAfter(context.Background(), ListUserTransfersAfterParams{
+		Owner: account1.Owner,
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, outgoing.ID, transfers[0].ID)
+	require.Equal(t, incoming.ID, transfers[1].ID)
+
+	transfers, err = testStore.ListUserTransfersAfter(context.Background(), ListUserTransfersAfterParams{
+		Owner:   account1.Owner,
+		AfterID: outgoing.ID,
+		Limit:   5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+	require.Equal(t, incoming.ID, transfers[0].ID)
+}
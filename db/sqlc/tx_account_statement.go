@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// AccountStatementTxParams contains the input parameters of the account statement transaction
+type AccountStatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// AccountStatementLine is one entry of a statement with the balance of the account right after it
+type AccountStatementLine struct {
+	ListAccountStatementEntriesRow
+	RunningBalance int64 `json:"running_balance"`
+}
+
+// AccountStatementTxResult is the result of the account statement transaction
+type AccountStatementTxResult struct {
+	Account        Account                `json:"account"`
+	StartTime      time.Time              `json:"start_time"`
+	EndTime        time.Time              `json:"end_time"`
+	OpeningBalance int64                  `json:"opening_balance"`
+	ClosingBalance int64                  `json:"closing_balance"`
+	Lines          []AccountStatementLine `json:"lines"`
+}
+
+// AccountStatementTx builds the statement of an account for the period [StartTime, EndTime)
+// The opening balance is derived from the current balance minus every entry made since StartTime,
+// so the balance and the entries are read within a single database transaction
+func (store *SQLStore) AccountStatementTx(ctx context.Context, arg AccountStatementTxParams) (AccountStatementTxResult, error) {
+	result := AccountStatementTxResult{
+		StartTime: arg.StartTime,
+		EndTime:   arg.EndTime,
+	}
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Account, err = q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		result.OpeningBalance, err = q.GetAccountBalanceAt(ctx, GetAccountBalanceAtParams{
+			At:        arg.StartTime,
+			AccountID: arg.AccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		entries, err := q.ListAccountStatementEntries(ctx, ListAccountStatementEntriesParams{
+			AccountID: arg.AccountID,
+			StartTime: arg.StartTime,
+			EndTime:   arg.EndTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		balance := result.OpeningBalance
+		result.Lines = make([]AccountStatementLine, 0, len(entries))
+		for _, entry := range entries {
+			balance += entry.Amount
+			result.Lines = append(result.Lines, AccountStatementLine{
+				ListAccountStatementEntriesRow: entry,
+				RunningBalance:                 balance,
+			})
+		}
+		result.ClosingBalance = balance
+
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountStatementTx(t *testing.T) {
+	account1 := createFundedAccount(t, 1000)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	startTime := account1.CreatedAt.Add(-time.Minute)
+
+	_, err := testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account1.ID,
+		Amount:    100,
+	})
+	require.NoError(t, err)
+
+	transfer, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account1.ID,
+		StartTime: startTime,
+		EndTime:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account1.ID, result.Account.ID)
+	require.Equal(t, int64(1000), result.OpeningBalance)
+	require.Equal(t, int64(1070), result.ClosingBalance)
+	require.Equal(t, result.Account.Balance, result.ClosingBalance)
+	require.Len(t, result.Lines, 2)
+
+	// the deposit has no counterparty
+	deposit := result.Lines[0]
+	require.Equal(t, int64(100), deposit.Amount)
+	require.Equal(t, int64(1100), deposit.RunningBalance)
+	require.False(t, deposit.TransferID.Valid)
+	require.False(t, deposit.CounterpartyAccountID.Valid)
+
+	// the transfer links to the receiving account
+	line := result.Lines[1]
+	require.Equal(t, transfer.FromEntry.ID, line.ID)
+	require.Equal(t, int64(-30), line.Amount)
+	require.Equal(t, int64(1070), line.RunningBalance)
+	require.Equal(t, transfer.Transfer.ID, line.TransferID.Int64)
+	require.Equal(t, account2.ID, line.CounterpartyAccountID.Int64)
+	require.Equal(t, account2.Owner, line.CounterpartyOwner.String)
+
+	// the receiving side sees the sender as counterparty
+	result, err = testStore.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account2.ID,
+		StartTime: startTime,
+		EndTime:   time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, result.Lines, 1)
+	require.Equal(t, account1.ID, result.Lines[0].CounterpartyAccountID.Int64)
+	require.Equal(t, account2.Balance, result.OpeningBalance)
+	require.Equal(t, account2.Balance+30, result.ClosingBalance)
+}
+
+func TestAccountStatementTxPeriod(t *testing.T) {
+	account := createFundedAccount(t, 500)
+
+	_, err := testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account.ID,
+		Amount:    50,
+	})
+	require.NoError(t, err)
+
+	// a period that ends before the deposit keeps it out of the statement
+	result, err := testStore.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account.ID,
+		StartTime: account.CreatedAt.Add(-time.Hour),
+		EndTime:   account.CreatedAt.Add(-time.Minute),
+	})
+	require.NoError(t, err)
+	require.Empty(t, result.Lines)
+	require.Equal(t, int64(500), result.OpeningBalance)
+	require.Equal(t, int64(500), result.ClosingBalance)
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// BlockSessionTxParams contains the input parameters of the BlockSession transaction
+type BlockSessionTxParams struct {
+	ID uuid.UUID
+}
+
+// BlockSessionTxResult is the result of the BlockSession transaction
+type BlockSessionTxResult struct {
+	Session Session
+}
+
+// BlockSessionTx ends a session, as on a logout, and records it in the audit log within a single database transaction.
+// ErrRecordNotFound is returned when the session doesn't exist.
+func (store *SQLStore) BlockSessionTx(ctx context.Context, arg BlockSessionTxParams) (BlockSessionTxResult, error) {
+	var result BlockSessionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		session, err := q.GetSession(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Session, err = q.BlockSession(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionBlockSession, AuditTargetSession, arg.ID.String(), newAuditedSession(session), newAuditedSession(result.Session))
+	})
+
+	return result, err
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// BlockSessionFamilyTxParams contains the input parameters of the BlockSessionFamily transaction
+type BlockSessionFamilyTxParams struct {
+	FamilyID uuid.UUID
+}
+
+// BlockSessionFamilyTxResult is the result of the BlockSessionFamily transaction
+type BlockSessionFamilyTxResult struct {
+	Sessions []Session
+}
+
+// BlockSessionFamilyTx ends every session of a refresh token family that is not blocked yet, as when one of its refresh tokens is reused,
+// and records the sessions it blocked in the audit log within a single database transaction.
+func (store *SQLStore) BlockSessionFamilyTx(ctx context.Context, arg BlockSessionFamilyTxParams) (BlockSessionFamilyTxResult, error) {
+	var result BlockSessionFamilyTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Sessions, err = q.BlockSessionFamily(ctx, arg.FamilyID)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionBlockSessionFamily, AuditTargetSessionFamily, arg.FamilyID.String(), nil, newAuditedSessions(result.Sessions))
+	})
+
+	return result, err
+}
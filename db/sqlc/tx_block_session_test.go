@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockSessionTx(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t))
+
+	result, err := testStore.BlockSessionTx(context.Background(), BlockSessionTxParams{ID: session.ID})
+	require.NoError(t, err)
+	require.True(t, result.Session.IsBlocked)
+
+	event := lastAuditEvent(t, AuditTargetSession, session.ID.String())
+	require.Equal(t, AuditActionBlockSession, event.Action)
+
+	var before, after map[string]any
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, false, before["is_blocked"])
+	require.Equal(t, true, after["is_blocked"])
+
+	_, err = testStore.BlockSessionTx(context.Background(), BlockSessionTxParams{ID: uuid.New()})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestBlockUserSessionsTx(t *testing.T) {
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+
+	result, err := testStore.BlockUserSessionsTx(context.Background(), BlockUserSessionsTxParams{Username: user.Username})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 2)
+
+	event := lastAuditEvent(t, AuditTargetUser, user.Username)
+	require.Equal(t, AuditActionBlockUserSessions, event.Action)
+
+	var after []map[string]any
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Len(t, after, 2)
+	require.ElementsMatch(t, []any{session1.ID.String(), session2.ID.String()}, []any{after[0]["id"], after[1]["id"]})
+}
+
+func TestBlockSessionFamilyTx(t *testing.T) {
+	session := createRandomSession(t, createRandomUser(t))
+
+	result, err := testStore.BlockSessionFamilyTx(context.Background(), BlockSessionFamilyTxParams{FamilyID: session.FamilyID})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 1)
+	require.Equal(t, session.ID, result.Sessions[0].ID)
+
+	event := lastAuditEvent(t, AuditTargetSessionFamily, session.FamilyID.String())
+	require.Equal(t, AuditActionBlockSessionFamily, event.Action)
+}
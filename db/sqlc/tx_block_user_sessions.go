@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+)
+
+// BlockUserSessionsTxParams contains the input parameters of the BlockUserSessions transaction
+type BlockUserSessionsTxParams struct {
+	Username string
+}
+
+// BlockUserSessionsTxResult is the result of the BlockUserSessions transaction
+type BlockUserSessionsTxResult struct {
+	Sessions []Session
+}
+
+// BlockUserSessionsTx ends every session of a user that is not blocked yet
+// and records the sessions it blocked in the audit log within a single database transaction.
+func (store *SQLStore) BlockUserSessionsTx(ctx context.Context, arg BlockUserSessionsTxParams) (BlockUserSessionsTxResult, error) {
+	var result BlockUserSessionsTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Sessions, err = q.BlockUserSessions(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionBlockUserSessions, AuditTargetUser, arg.Username, nil, newAuditedSessions(result.Sessions))
+	})
+
+	return result, err
+}
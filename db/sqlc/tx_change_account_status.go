@@ -0,0 +1,149 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"simplebank/util"
+	"strconv"
+)
+
+// accountStatusTransitions lists the statuses each account status can move to.
+// A closed account can only be reopened, and a frozen account has to be unfrozen before it is closed.
+var accountStatusTransitions = map[string][]string{
+	util.AccountStatusActive: {util.AccountStatusFrozen, util.AccountStatusClosed},
+	util.AccountStatusFrozen: {util.AccountStatusActive},
+	util.AccountStatusClosed: {util.AccountStatusActive},
+}
+
+// ChangeAccountStatusTxParams contains the input parameters of the ChangeAccountStatus transaction
+// FromStatus, when set, is the only status the account may be changed from.
+// SweepAccountID is only read when closing an account that still holds money.
+type ChangeAccountStatusTxParams struct {
+	AccountID      int64
+	Status         string
+	FromStatus     string
+	ChangedBy      string
+	SweepAccountID int64
+}
+
+// ChangeAccountStatusTxResult is the result of the ChangeAccountStatus transaction
+// Sweep is only set when the remaining balance was moved to the sweep account.
+type ChangeAccountStatusTxResult struct {
+	Account      Account
+	StatusChange AccountStatusChange
+	Sweep        *TransferTxResult
+}
+
+// ChangeAccountStatusTx freezes, unfreezes, closes or reopens an account.
+// It checks the transition is allowed, updates the status and records who changed it from what in the status history and the audit log
+// within a single database transaction.
+// Closing needs a zero balance, unless a sweep account of the same owner in the same currency is given to receive the remainder by a transfer.
+func (store *SQLStore) ChangeAccountStatusTx(ctx context.Context, arg ChangeAccountStatusTxParams) (ChangeAccountStatusTxResult, error) {
+	var result ChangeAccountStatusTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := lockStatusChange(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		// unfreezing and reopening both make an account active, each one only undoes its own status
+		if arg.FromStatus != "" && account.Status != arg.FromStatus {
+			return fmt.Errorf("%w: account (%d) is %s, not %s", ErrInvalidStatusTransition, account.ID, account.Status, arg.FromStatus)
+		}
+
+		err = checkStatusTransition(account.Status, arg.Status)
+		if err != nil {
+			return err
+		}
+
+		if arg.Status == util.AccountStatusClosed && account.Balance != 0 {
+			result.Sweep, err = sweepAccount(ctx, q, account, arg.SweepAccountID)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     arg.AccountID,
+			Status: arg.Status,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.StatusChange, err = q.CreateAccountStatusChange(ctx, CreateAccountStatusChangeParams{
+			AccountID: arg.AccountID,
+			OldStatus: account.Status,
+			NewStatus: arg.Status,
+			ChangedBy: arg.ChangedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionChangeAccountStatus, AuditTargetAccount, strconv.FormatInt(account.ID, 10), account, result.Account)
+	})
+
+	return result, err
+}
+
+// lockStatusChange locks the account whose status changes.
+// When it is being closed into a sweep account, both rows are locked in the same ID order as a transfer to avoid deadlocks.
+func lockStatusChange(ctx context.Context, q *Queries, arg ChangeAccountStatusTxParams) (Account, error) {
+	if arg.Status != util.AccountStatusClosed || arg.SweepAccountID == 0 || arg.SweepAccountID == arg.AccountID {
+		return q.GetAccountForUpdate(ctx, arg.AccountID)
+	}
+
+	_, err := q.GetAccount(ctx, arg.SweepAccountID)
+	if err != nil {
+		return Account{}, fmt.Errorf("%w: %w", ErrInvalidSweepAccount, err)
+	}
+
+	account, _, err := lockAccounts(ctx, q, arg.AccountID, arg.SweepAccountID)
+	return account, err
+}
+
+// checkStatusTransition makes sure an account can go from one status to the other
+func checkStatusTransition(from string, to string) error {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: from %s to %s", ErrInvalidStatusTransition, from, to)
+}
+
+// sweepAccount transfers the whole positive balance of an account that is being closed to the sweep account.
+// An overdrawn account can't be swept, its debt has to be paid back first,
+// and the money never leaves its owner: the sweep account must belong to them and hold the same currency.
+func sweepAccount(ctx context.Context, q *Queries, account Account, sweepAccountID int64) (*TransferTxResult, error) {
+	if sweepAccountID == 0 || account.Balance < 0 {
+		return nil, fmt.Errorf("%w: %d left in account (%d)", ErrAccountBalanceNotZero, account.Balance, account.ID)
+	}
+	if sweepAccountID == account.ID {
+		return nil, fmt.Errorf("%w: account (%d) can't be swept into itself", ErrInvalidSweepAccount, account.ID)
+	}
+
+	// the sweep account row is already locked by lockStatusChange
+	sweep, err := q.GetAccount(ctx, sweepAccountID)
+	if err != nil {
+		return nil, err
+	}
+	if sweep.Owner != account.Owner {
+		return nil, fmt.Errorf("%w: account (%d) doesn't belong to %s", ErrInvalidSweepAccount, sweep.ID, account.Owner)
+	}
+	if sweep.Currency != account.Currency {
+		return nil, fmt.Errorf("%w: account (%d) is in %s, not %s", ErrInvalidSweepAccount, sweep.ID, sweep.Currency, account.Currency)
+	}
+
+	result, err := transfer(ctx, q, TransferTxParams{
+		FromAccountID: account.ID,
+		ToAccountID:   sweepAccountID,
+		Amount:        account.Balance,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
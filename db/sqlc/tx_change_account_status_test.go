@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestChangeAccountStatusTx tests freezing, unfreezing, closing and reopening an account
+func TestChangeAccountStatusTx(t *testing.T) {
+	banker := createRandomUser(t)
+	account := createFundedAccount(t, 0)
+
+	arg := ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusFrozen,
+		ChangedBy: banker.Username,
+	}
+
+	result, err := testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusFrozen, result.Account.Status)
+	require.Nil(t, result.Sweep)
+
+	// Verify the change is recorded with its author
+	require.Equal(t, account.ID, result.StatusChange.AccountID)
+	require.Equal(t, util.AccountStatusActive, result.StatusChange.OldStatus)
+	require.Equal(t, util.AccountStatusFrozen, result.StatusChange.NewStatus)
+	require.Equal(t, banker.Username, result.StatusChange.ChangedBy)
+	require.WithinDuration(t, time.Now(), result.StatusChange.ChangedAt, time.Second)
+
+	// Verify the change is in the audit log
+	event := lastAuditEvent(t, AuditTargetAccount, strconv.FormatInt(account.ID, 10))
+	require.Equal(t, AuditActionChangeAccountStatus, event.Action)
+
+	var before, after map[string]any
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, util.AccountStatusActive, before["status"])
+	require.Equal(t, util.AccountStatusFrozen, after["status"])
+
+	// A frozen account has to be unfrozen before it is closed
+	arg.Status = util.AccountStatusClosed
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	// Reopening only undoes a closing, not a freeze
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID:  account.ID,
+		Status:     util.AccountStatusActive,
+		FromStatus: util.AccountStatusClosed,
+		ChangedBy:  banker.Username,
+	})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	for _, status := range []string{util.AccountStatusActive, util.AccountStatusClosed, util.AccountStatusActive} {
+		arg.Status = status
+		result, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+		require.NoError(t, err)
+		require.Equal(t, status, result.Account.Status)
+	}
+
+	changes, err := testStore.ListAccountStatusChanges(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+	require.Equal(t, util.AccountStatusClosed, changes[3].OldStatus)
+	require.Equal(t, util.AccountStatusActive, changes[3].NewStatus)
+
+	// Unknown accounts are not found
+	arg.AccountID = 0
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+// TestChangeAccountStatusTxCloseWithBalance tests an account with money left is only closed into a sweep account
+// of the same owner in the same currency
+func TestChangeAccountStatusTxCloseWithBalance(t *testing.T) {
+	banker := createRandomUser(t)
+	account := createFundedAccountWithCurrency(t, util.USD, 100)
+	otherOwner := createFundedAccountWithCurrency(t, util.USD, 0)
+
+	otherCurrency, err := testStore.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    account.Owner,
+		Currency: util.EUR,
+	})
+	require.NoError(t, err)
+
+	arg := ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusClosed,
+		ChangedBy: banker.Username,
+	}
+
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrAccountBalanceNotZero)
+
+	arg.SweepAccountID = otherCurrency.ID
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	// the money of a closed account never goes to someone else
+	arg.SweepAccountID = otherOwner.ID
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	arg.SweepAccountID = account.ID
+	_, err = testStore.ChangeAccountStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvalidSweepAccount)
+
+	// nothing moved and the account is still open
+	account, err = testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, util.AccountStatusActive, account.Status)
+	require.Equal(t, int64(100), account.Balance)
+
+	otherOwner, err = testStore.GetAccount(context.Background(), otherOwner.ID)
+	require.NoError(t, err)
+	require.Zero(t, otherOwner.Balance)
+}
+
+// TestChangeAccountStatusTxClosedAccount tests no money moves in or out of a closed account
+func TestChangeAccountStatusTxClosedAccount(t *testing.T) {
+	banker := createRandomUser(t)
+	account := createFundedAccountWithCurrency(t, util.USD, 0)
+	other := createFundedAccountWithCurrency(t, util.USD, 100)
+
+	_, err := testStore.ChangeAccountStatusTx(context.Background(), ChangeAccountStatusTxParams{
+		AccountID: account.ID,
+		Status:    util.AccountStatusClosed,
+		ChangedBy: banker.Username,
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: other.ID,
+		ToAccountID:   account.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+
+	_, err = testStore.DepositTx(context.Background(), DepositTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.ErrorIs(t, err, ErrAccountNotActive)
+}
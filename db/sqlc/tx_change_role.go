@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ChangeRoleTxParams contains the input parameters of the ChangeRole transaction
+type ChangeRoleTxParams struct {
+	Username  string
+	Role      string
+	ChangedBy string
+}
+
+// ChangeRoleTxResult is the result of the ChangeRole transaction
+type ChangeRoleTxResult struct {
+	User       User
+	RoleChange RoleChange
+}
+
+// ChangeRoleTx promotes or demotes a user.
+// It updates the role, records who changed it from what in the role history and the audit log, and blocks every session of the user within a single database transaction,
+// so that no refresh token can renew an access token carrying the old role.
+// ErrRoleUnchanged is returned when the user already has the role.
+func (store *SQLStore) ChangeRoleTx(ctx context.Context, arg ChangeRoleTxParams) (ChangeRoleTxResult, error) {
+	var result ChangeRoleTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		if user.Role == arg.Role {
+			return ErrRoleUnchanged
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			Role: pgtype.Text{
+				String: arg.Role,
+				Valid:  true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		result.RoleChange, err = q.CreateRoleChange(ctx, CreateRoleChangeParams{
+			Username:  arg.Username,
+			OldRole:   user.Role,
+			NewRole:   arg.Role,
+			ChangedBy: arg.ChangedBy,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(ctx, q, AuditActionChangeUserRole, AuditTargetUser, arg.Username, newAuditedUser(user), newAuditedUser(result.User))
+		if err != nil {
+			return err
+		}
+
+		_, err = q.BlockUserSessions(ctx, arg.Username)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestChangeRoleTx tests the ChangeRoleTx function
+func TestChangeRoleTx(t *testing.T) {
+	banker := createRandomUser(t)
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	arg := ChangeRoleTxParams{
+		Username:  user.Username,
+		Role:      util.BankerRole,
+		ChangedBy: banker.Username,
+	}
+
+	result, err := testStore.ChangeRoleTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.BankerRole, result.User.Role)
+
+	// Verify the change is recorded with its author
+	require.Equal(t, user.Username, result.RoleChange.Username)
+	require.Equal(t, user.Role, result.RoleChange.OldRole)
+	require.Equal(t, util.BankerRole, result.RoleChange.NewRole)
+	require.Equal(t, banker.Username, result.RoleChange.ChangedBy)
+	require.WithinDuration(t, time.Now(), result.RoleChange.ChangedAt, time.Second)
+
+	// Verify the change is in the audit log
+	event := lastAuditEvent(t, AuditTargetUser, user.Username)
+	require.Equal(t, AuditActionChangeUserRole, event.Action)
+
+	var before, after map[string]any
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, user.Role, before["role"])
+	require.Equal(t, util.BankerRole, after["role"])
+
+	// Verify the sessions carrying the old role are blocked
+	blockedSession, err := testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blockedSession.IsBlocked)
+
+	// Giving the same role again changes nothing
+	_, err = testStore.ChangeRoleTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRoleUnchanged)
+
+	arg.Role = util.DepositorRole
+	_, err = testStore.ChangeRoleTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	changes, err := testStore.ListRoleChanges(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	require.Equal(t, util.BankerRole, changes[1].OldRole)
+	require.Equal(t, util.DepositorRole, changes[1].NewRole)
+
+	// Unknown users are not found
+	arg.Username = util.RandomOwner()
+	_, err = testStore.ChangeRoleTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
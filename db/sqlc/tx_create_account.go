@@ -0,0 +1,34 @@
+package db
+
+import (
+	"context"
+	"strconv"
+)
+
+// CreateAccountTxParams contains the input parameters of the CreateAccount transaction
+type CreateAccountTxParams struct {
+	CreateAccountParams
+}
+
+// CreateAccountTxResult is the result of the CreateAccount transaction
+type CreateAccountTxResult struct {
+	Account Account
+}
+
+// CreateAccountTx opens an account and records it in the audit log within a single database transaction
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error) {
+	var result CreateAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionCreateAccount, AuditTargetAccount, strconv.FormatInt(result.Account.ID, 10), nil, result.Account)
+	})
+
+	return result, err
+}
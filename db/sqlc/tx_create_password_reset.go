@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// CreatePasswordResetTxParams contains the input parameters of the CreatePasswordReset transaction
+type CreatePasswordResetTxParams struct {
+	CreatePasswordResetParams
+	// Cooldown is how long the user waits before asking another reset
+	Cooldown time.Duration
+	// Outbox builds the tasks to publish once the reset is committed, such as the email carrying its code
+	Outbox func(passwordReset PasswordReset) ([]CreateOutboxMessageParams, error)
+}
+
+// CreatePasswordResetTxResult is the result of the CreatePasswordReset transaction
+type CreatePasswordResetTxResult struct {
+	PasswordReset PasswordReset
+	Outbox        []Outbox
+}
+
+// CreatePasswordResetTx creates a single-use password reset and writes its outbox tasks, such as the reset email,
+// within a single database transaction. The tasks are only published once the reset exists.
+// It returns ErrCooldown when the user asked a reset too recently, and then writes nothing.
+func (store *SQLStore) CreatePasswordResetTx(ctx context.Context, arg CreatePasswordResetTxParams) (CreatePasswordResetTxResult, error) {
+	var result CreatePasswordResetTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := enterCooldown(ctx, q, CooldownPasswordReset, arg.Username, arg.Cooldown)
+		if err != nil {
+			return err
+		}
+
+		result.PasswordReset, err = q.CreatePasswordReset(ctx, arg.CreatePasswordResetParams)
+		if err != nil {
+			return err
+		}
+
+		if arg.Outbox == nil {
+			return nil
+		}
+
+		messages, err := arg.Outbox(result.PasswordReset)
+		if err != nil {
+			return err
+		}
+
+		result.Outbox, err = createOutboxMessages(ctx, q, messages)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePasswordResetTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreatePasswordResetTxParams{
+		CreatePasswordResetParams: CreatePasswordResetParams{
+			Username:   user.Username,
+			Email:      user.Email,
+			SecretCode: util.RandomString(32),
+		},
+		Outbox: func(passwordReset PasswordReset) ([]CreateOutboxMessageParams, error) {
+			payload, err := json.Marshal(map[string]any{"username": passwordReset.Username, "reset_id": passwordReset.ID})
+			if err != nil {
+				return nil, err
+			}
+			return []CreateOutboxMessageParams{{
+				TaskType: "task:send_reset_password",
+				Payload:  payload,
+				Queue:    "critical",
+				MaxRetry: 10,
+			}}, nil
+		},
+	}
+
+	ctx := context.Background()
+	result, err := testStore.CreatePasswordResetTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.PasswordReset.Username)
+	require.Equal(t, arg.SecretCode, result.PasswordReset.SecretCode)
+
+	// the outbox task is committed with the reset and names it
+	require.Len(t, result.Outbox, 1)
+	message, err := testStore.GetOutboxMessage(ctx, result.Outbox[0].ID)
+	require.NoError(t, err)
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal(message.Payload, &payload))
+	require.EqualValues(t, result.PasswordReset.ID, payload["reset_id"])
+
+	// the transaction fails when its outbox task can't be built
+	arg.Outbox = func(PasswordReset) ([]CreateOutboxMessageParams, error) {
+		return nil, errors.New("broken payload")
+	}
+	_, err = testStore.CreatePasswordResetTx(ctx, arg)
+	require.Error(t, err)
+}
+
+func TestCreatePasswordResetTxCooldown(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := CreatePasswordResetTxParams{
+		CreatePasswordResetParams: CreatePasswordResetParams{
+			Username:   user.Username,
+			Email:      user.Email,
+			SecretCode: util.RandomString(32),
+		},
+		Cooldown: time.Minute,
+	}
+
+	_, err := testStore.CreatePasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// a second reset within the cooldown is not created
+	arg.SecretCode = util.RandomString(32)
+	_, err = testStore.CreatePasswordResetTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrCooldown)
+
+	// the cooldown of password resets doesn't hold back verification emails
+	_, err = testStore.ResendVerifyEmailTx(context.Background(), ResendVerifyEmailTxParams{
+		Username: user.Username,
+		Cooldown: time.Minute,
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+)
+
+// CreateSessionTxParams contains the input parameters of the CreateSession transaction
+type CreateSessionTxParams struct {
+	CreateSessionParams
+}
+
+// CreateSessionTxResult is the result of the CreateSession transaction
+type CreateSessionTxResult struct {
+	Session Session
+}
+
+// CreateSessionTx stores the session of a login and records it in the audit log within a single database transaction
+func (store *SQLStore) CreateSessionTx(ctx context.Context, arg CreateSessionTxParams) (CreateSessionTxResult, error) {
+	var result CreateSessionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Session, err = q.CreateSession(ctx, arg.CreateSessionParams)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionCreateSession, AuditTargetSession, result.Session.ID.String(), nil, newAuditedSession(result.Session))
+	})
+
+	return result, err
+}
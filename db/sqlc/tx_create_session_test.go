@@ -0,0 +1,22 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSessionTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	result, err := testStore.CreateSessionTx(context.Background(), CreateSessionTxParams{
+		CreateSessionParams: createRandomSessionParams(user),
+	})
+	require.NoError(t, err)
+
+	event := lastAuditEvent(t, AuditTargetSession, result.Session.ID.String())
+	require.Equal(t, AuditActionCreateSession, event.Action)
+	require.False(t, event.ActorUsername.Valid)
+	require.NotContains(t, string(event.After), result.Session.RefreshToken)
+}
@@ -7,16 +7,19 @@ import (
 // CreateUserTxParams contains the input parameters of the CreateUser transaction
 type CreateUserTxParams struct {
 	CreateUserParams
-	AfterCreate func(user User) error
+	// Outbox holds the tasks to publish once the user is committed, such as the verification email
+	Outbox []CreateOutboxMessageParams
 }
 
 // CreateUserTxResult is the result of the CreateUser transaction
 type CreateUserTxResult struct {
-	User User
+	User   User
+	Outbox []Outbox
 }
 
-// CreateUserTx performs a monez CreateUser from one account to another
-// It creates a CreateUser record, add account entries, update accounts balance within a single datybase transaction
+// CreateUserTx creates a user, records it in the audit log
+// and writes its outbox tasks, such as the verification email, within a single database transaction.
+// The tasks are only published once the user exists.
 func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (CreateUserTxResult, error) {
 	var result CreateUserTxResult
 
@@ -29,9 +32,13 @@ func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams)
 			return err
 		}
 
-		// execute a callback function
-		return arg.AfterCreate(result.User)
+		err = recordAuditEvent(ctx, q, AuditActionCreateUser, AuditTargetUser, result.User.Username, nil, newAuditedUser(result.User))
+		if err != nil {
+			return err
+		}
 
+		result.Outbox, err = createOutboxMessages(ctx, q, arg.Outbox)
+		return err
 	})
 
 	return result, err
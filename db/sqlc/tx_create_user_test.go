@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"simplebank/util"
 	"testing"
 	"time"
@@ -27,12 +28,15 @@ func createRandomUserParams(t *testing.T) CreateUserParams {
 func TestCreateUserTx(t *testing.T) {
 	arg := CreateUserTxParams{
 		CreateUserParams: createRandomUserParams(t),
-		AfterCreate: func(user User) error {
-			// Placeholder for additional operations after user creation
-			// For now, we just return nil to indicate success
-			return nil
-		},
 	}
+	payload, err := json.Marshal(map[string]string{"username": arg.Username})
+	require.NoError(t, err)
+	arg.Outbox = []CreateOutboxMessageParams{{
+		TaskType: "task:send_verify_email",
+		Payload:  payload,
+		Queue:    "critical",
+		MaxRetry: 10,
+	}}
 
 	ctx := context.Background()
 	result, err := testStore.CreateUserTx(ctx, arg)
@@ -47,4 +51,32 @@ func TestCreateUserTx(t *testing.T) {
 	require.Equal(t, arg.Email, user.Email)
 	require.WithinDuration(t, time.Now(), user.CreatedAt, time.Second)
 	require.False(t, user.IsEmailVerified)
+
+	// the outbox task is committed with the user, pending until the relay sends it
+	require.Len(t, result.Outbox, 1)
+	message, err := testStore.GetOutboxMessage(ctx, result.Outbox[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Outbox[0].TaskType, message.TaskType)
+	require.JSONEq(t, string(payload), string(message.Payload))
+	require.Equal(t, arg.Outbox[0].Queue, message.Queue)
+	require.Equal(t, arg.Outbox[0].MaxRetry, message.MaxRetry)
+	require.Zero(t, message.Attempts)
+	require.False(t, message.SentAt.Valid)
+}
+
+// TestCreateUserTxAudit tests the new user is audited without the actor of a signup nor the password hash
+func TestCreateUserTxAudit(t *testing.T) {
+	arg := CreateUserTxParams{
+		CreateUserParams: createRandomUserParams(t),
+	}
+
+	ctx := WithAuditActor(context.Background(), AuditActor{ClientIP: "10.0.0.1"})
+	result, err := testStore.CreateUserTx(ctx, arg)
+	require.NoError(t, err)
+
+	event := lastAuditEvent(t, AuditTargetUser, result.User.Username)
+	require.Equal(t, AuditActionCreateUser, event.Action)
+	require.False(t, event.ActorUsername.Valid)
+	require.Equal(t, "10.0.0.1", event.ClientIp)
+	require.NotContains(t, string(event.After), result.User.HashedPassword)
 }
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// CreateVerifyEmailTxParams contains the input parameters of the CreateVerifyEmail transaction.
+// TaskID is the task creating the code, set so that the same task delivered again reuses it.
+type CreateVerifyEmailTxParams struct {
+	CreateVerifyEmailParams
+}
+
+// CreateVerifyEmailTxResult is the result of the CreateVerifyEmail transaction
+type CreateVerifyEmailTxResult struct {
+	VerifyEmail VerifyEmail
+	// Reused is true when the code was created by an earlier delivery of the same task
+	Reused bool
+}
+
+// CreateVerifyEmailTx creates the verification code of a user and expires the older ones within a single database transaction,
+// so only the code of the latest email can verify the address.
+// A task delivered again gets the code it already created back, the code it emailed the first time keeps working.
+func (store *SQLStore) CreateVerifyEmailTx(ctx context.Context, arg CreateVerifyEmailTxParams) (CreateVerifyEmailTxResult, error) {
+	var result CreateVerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		if arg.TaskID.Valid {
+			result.VerifyEmail, err = q.GetVerifyEmailByTaskID(ctx, arg.TaskID)
+			if err == nil {
+				result.Reused = true
+				return nil
+			}
+			if !errors.Is(err, ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		err = q.ExpireUserVerifyEmails(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.VerifyEmail, err = q.CreateVerifyEmail(ctx, arg.CreateVerifyEmailParams)
+		return err
+	})
+
+	return result, err
+}
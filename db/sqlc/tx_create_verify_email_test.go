@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateVerifyEmailTx(t *testing.T) {
+	older := createRandomVerifyEmail(t)
+	taskID := pgtype.Text{String: "outbox:" + util.RandomString(12), Valid: true}
+
+	arg := CreateVerifyEmailTxParams{
+		CreateVerifyEmailParams: CreateVerifyEmailParams{
+			Username:   older.Username,
+			Email:      older.Email,
+			SecretCode: util.RandomString(32),
+			TaskID:     taskID,
+		},
+	}
+
+	result1, err := testStore.CreateVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result1.Reused)
+	require.Equal(t, arg.SecretCode, result1.VerifyEmail.SecretCode)
+	require.Equal(t, taskID, result1.VerifyEmail.TaskID)
+
+	// the older code expired with the creation of the new one
+	_, err = testStore.UpdateVerifyEmail(context.Background(), UpdateVerifyEmailParams{
+		ID:         older.ID,
+		SecretCode: older.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// the same task delivered again gets its code back and doesn't expire it
+	arg.SecretCode = util.RandomString(32)
+	result2, err := testStore.CreateVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result2.Reused)
+	require.Equal(t, result1.VerifyEmail.ID, result2.VerifyEmail.ID)
+	require.Equal(t, result1.VerifyEmail.SecretCode, result2.VerifyEmail.SecretCode)
+
+	_, err = testStore.UpdateVerifyEmail(context.Background(), UpdateVerifyEmailParams{
+		ID:         result1.VerifyEmail.ID,
+		SecretCode: result1.VerifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+}
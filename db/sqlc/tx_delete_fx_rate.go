@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// DeleteFxRateTxParams contains the input parameters of the DeleteFxRate transaction
+type DeleteFxRateTxParams struct {
+	DeleteFxRateParams
+}
+
+// DeleteFxRateTxResult is the result of the DeleteFxRate transaction
+// FxRate is the deleted rate, it is empty when the pair had none.
+type DeleteFxRateTxResult struct {
+	FxRate FxRate
+}
+
+// DeleteFxRateTx removes the rate of a currency pair and records it in the audit log within a single database transaction.
+// Deleting a pair without a rate changes nothing and records nothing.
+func (store *SQLStore) DeleteFxRateTx(ctx context.Context, arg DeleteFxRateTxParams) (DeleteFxRateTxResult, error) {
+	var result DeleteFxRateTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		rate, err := q.GetFxRateForUpdate(ctx, GetFxRateForUpdateParams{
+			BaseCurrency:  arg.BaseCurrency,
+			QuoteCurrency: arg.QuoteCurrency,
+		})
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		err = q.DeleteFxRate(ctx, arg.DeleteFxRateParams)
+		if err != nil {
+			return err
+		}
+		result.FxRate = rate
+
+		return recordAuditEvent(ctx, q, AuditActionDeleteFxRate, AuditTargetFxRate, fxRateTargetID(arg.BaseCurrency, arg.QuoteCurrency), rate, nil)
+	})
+
+	return result, err
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"simplebank/util"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteFxRateTx(t *testing.T) {
+	rate := createRandomFxRate(t)
+	banker := util.RandomOwner()
+
+	arg := DeleteFxRateTxParams{
+		DeleteFxRateParams: DeleteFxRateParams{
+			BaseCurrency:  rate.BaseCurrency,
+			QuoteCurrency: rate.QuoteCurrency,
+		},
+	}
+
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: banker, Role: util.BankerRole})
+	result, err := testStore.DeleteFxRateTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, rate.Rate, result.FxRate.Rate)
+
+	_, err = testStore.GetFxRate(context.Background(), GetFxRateParams(arg.DeleteFxRateParams))
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	targetID := fxRateTargetID(rate.BaseCurrency, rate.QuoteCurrency)
+	event := lastAuditEvent(t, AuditTargetFxRate, targetID)
+	require.Equal(t, AuditActionDeleteFxRate, event.Action)
+	require.Equal(t, banker, event.ActorUsername.String)
+	require.Empty(t, event.After)
+
+	var before FxRate
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.Equal(t, rate.Rate, before.Rate)
+
+	// deleting it again changes nothing and records nothing
+	result, err = testStore.DeleteFxRateTx(ctx, arg)
+	require.NoError(t, err)
+	require.Empty(t, result.FxRate)
+
+	events, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		TargetType: pgtype.Text{String: AuditTargetFxRate, Valid: true},
+		TargetID:   pgtype.Text{String: targetID, Valid: true},
+		BeforeID:   math.MaxInt64,
+		Limit:      10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"strconv"
+)
+
+// DepositTxParams contains the input parameters of the deposit transaction
+type DepositTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// DepositTxResult is the result of the deposit transaction
+type DepositTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// DepositTx adds money to an account
+// It creates an account entry, updates the account balance and records the deposit in the audit log within a single database transaction.
+// It fails with ErrAccountNotActive when the account is frozen or closed.
+func (store *SQLStore) DepositTx(ctx context.Context, arg DepositTxParams) (DepositTxResult, error) {
+	var result DepositTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		err = checkActive(account)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.AccountID,
+			Amount:    arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionDeposit, AuditTargetAccount, strconv.FormatInt(account.ID, 10), account, result.Account)
+	})
+
+	return result, err
+}
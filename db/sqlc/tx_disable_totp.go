@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+)
+
+// DisableTotpTxParams contains the input parameters of the DisableTotp transaction
+type DisableTotpTxParams struct {
+	Username string
+}
+
+// DisableTotpTxResult is the result of the DisableTotp transaction
+type DisableTotpTxResult struct {
+	User User
+}
+
+// DisableTotpTx turns off two-factor authentication.
+// It clears the TOTP secret, deletes the recovery codes and records it in the audit log within a single database transaction.
+func (store *SQLStore) DisableTotpTx(ctx context.Context, arg DisableTotpTxParams) (DisableTotpTxResult, error) {
+	var result DisableTotpTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.DisableUserTotp(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteUserRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionDisableUserTotp, AuditTargetUser, arg.Username, newAuditedUser(user), newAuditedUser(result.User))
+	})
+
+	return result, err
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+)
+
+// EnableTotpTxParams contains the input parameters of the EnableTotp transaction
+type EnableTotpTxParams struct {
+	Username            string
+	Step                int64    // period of the code confirming the enrollment
+	HashedRecoveryCodes []string // replace the recovery codes of a previous enrollment
+}
+
+// EnableTotpTxResult is the result of the EnableTotp transaction
+type EnableTotpTxResult struct {
+	User          User
+	RecoveryCodes []RecoveryCode
+}
+
+// EnableTotpTx turns on two-factor authentication once the first code confirmed the enrollment.
+// It consumes the period of that code, enables TOTP, replaces the recovery codes and records it in the audit log within a single database transaction.
+// ErrRecordNotFound is returned when the period was already used.
+func (store *SQLStore) EnableTotpTx(ctx context.Context, arg EnableTotpTxParams) (EnableTotpTxResult, error) {
+	var result EnableTotpTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.UseUserTotpStep(ctx, UseUserTotpStepParams{
+			Username:     arg.Username,
+			TotpLastStep: arg.Step,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.EnableUserTotp(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteUserRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.RecoveryCodes = make([]RecoveryCode, 0, len(arg.HashedRecoveryCodes))
+		for _, hashedCode := range arg.HashedRecoveryCodes {
+			recoveryCode, err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username:   arg.Username,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, recoveryCode)
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionEnableUserTotp, AuditTargetUser, arg.Username, newAuditedUser(user), newAuditedUser(result.User))
+	})
+
+	return result, err
+}
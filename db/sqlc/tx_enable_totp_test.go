@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnableTotpTx(t *testing.T) {
+	user := enrollRandomTotp(t, createRandomUser(t))
+	_, oldCode := createRandomRecoveryCode(t, user)
+
+	codes, err := util.GenerateRecoveryCodes(3)
+	require.NoError(t, err)
+	hashedCodes := make([]string, len(codes))
+	for i, code := range codes {
+		hashedCodes[i] = util.HashRecoveryCode(code)
+	}
+
+	arg := EnableTotpTxParams{
+		Username:            user.Username,
+		Step:                util.TOTPStep(time.Now()),
+		HashedRecoveryCodes: hashedCodes,
+	}
+
+	result, err := testStore.EnableTotpTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.User.IsTotpEnabled)
+	require.Len(t, result.RecoveryCodes, len(codes))
+	for i, recoveryCode := range result.RecoveryCodes {
+		require.Equal(t, hashedCodes[i], recoveryCode.HashedCode)
+	}
+
+	event := lastAuditEvent(t, AuditTargetUser, user.Username)
+	require.Equal(t, AuditActionEnableUserTotp, event.Action)
+
+	// the recovery codes of a previous enrollment are gone
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashRecoveryCode(oldCode),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// the confirming code can't be replayed
+	_, err = testStore.EnableTotpTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestDisableTotpTx(t *testing.T) {
+	user := enrollRandomTotp(t, createRandomUser(t))
+	_, code := createRandomRecoveryCode(t, user)
+
+	result, err := testStore.DisableTotpTx(context.Background(), DisableTotpTxParams{Username: user.Username})
+	require.NoError(t, err)
+	require.False(t, result.User.IsTotpEnabled)
+	require.False(t, result.User.TotpSecret.Valid)
+
+	event := lastAuditEvent(t, AuditTargetUser, user.Username)
+	require.Equal(t, AuditActionDisableUserTotp, event.Action)
+
+	_, err = testStore.UseRecoveryCode(context.Background(), UseRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.HashRecoveryCode(code),
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdempotentTransferTxParams contains the input parameters of the idempotent transfer transaction
+type IdempotentTransferTxParams struct {
+	TransferTxParams
+	Username       string    `json:"username"`
+	IdempotencyKey string    `json:"idempotency_key"`
+	RequestHash    string    `json:"request_hash"`
+	ExpiresAt      time.Time `json:"expires_at"`
+}
+
+// IdempotentTransferTxResult is the result of the idempotent transfer transaction
+type IdempotentTransferTxResult struct {
+	TransferTxResult
+	// Replayed is true when the result comes from an earlier request with the same key
+	Replayed bool `json:"-"`
+}
+
+// IdempotentTransferTx performs a money transfer at most once per idempotency key.
+// The key is claimed in the same database transaction as the transfer, so a failed transfer leaves no key behind.
+// A key already used with the same request hash returns the stored result, a different hash returns ErrIdempotencyKeyReused.
+func (store *SQLStore) IdempotentTransferTx(ctx context.Context, arg IdempotentTransferTxParams) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		// an expired key can be claimed again
+		err = q.DeleteExpiredIdempotencyKey(ctx, DeleteExpiredIdempotencyKeyParams{
+			Username: arg.Username,
+			Key:      arg.IdempotencyKey,
+		})
+		if err != nil {
+			return err
+		}
+
+		// a concurrent request with the same key makes this insert wait until it commits or rolls back
+		_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+			Username:    arg.Username,
+			Key:         arg.IdempotencyKey,
+			RequestHash: arg.RequestHash,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		if errors.Is(err, ErrRecordNotFound) {
+			result, err = replayTransfer(ctx, q, ReplayIdempotentTransferParams{
+				Username:       arg.Username,
+				IdempotencyKey: arg.IdempotencyKey,
+				RequestHash:    arg.RequestHash,
+			})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		result.TransferTxResult, err = transfer(ctx, q, arg.TransferTxParams)
+		if err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result.TransferTxResult)
+		if err != nil {
+			return fmt.Errorf("failed to marshal transfer result: %w", err)
+		}
+
+		_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+			Response: response,
+			Username: arg.Username,
+			Key:      arg.IdempotencyKey,
+		})
+		return err
+	})
+
+	return result, err
+}
+
+// ReplayIdempotentTransferParams contains the input parameters of ReplayIdempotentTransfer
+type ReplayIdempotentTransferParams struct {
+	Username       string `json:"username"`
+	IdempotencyKey string `json:"idempotency_key"`
+	RequestHash    string `json:"request_hash"`
+}
+
+// ReplayIdempotentTransfer returns the stored result of an idempotency key that was already used and has not expired,
+// so a retry gets it back whatever happened to the accounts since.
+// It returns ErrRecordNotFound when the key is unused or expired, and ErrIdempotencyKeyReused when it came with another request.
+func (store *SQLStore) ReplayIdempotentTransfer(ctx context.Context, arg ReplayIdempotentTransferParams) (IdempotentTransferTxResult, error) {
+	idempotencyKey, err := store.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.IdempotencyKey,
+	})
+	if err != nil {
+		return IdempotentTransferTxResult{}, err
+	}
+
+	if !idempotencyKey.ExpiresAt.After(time.Now()) {
+		return IdempotentTransferTxResult{}, ErrRecordNotFound
+	}
+
+	return replayedTransfer(idempotencyKey, arg.RequestHash)
+}
+
+// replayTransfer loads the stored result of an idempotency key that was already used
+func replayTransfer(ctx context.Context, q *Queries, arg ReplayIdempotentTransferParams) (IdempotentTransferTxResult, error) {
+	idempotencyKey, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.IdempotencyKey,
+	})
+	if err != nil {
+		return IdempotentTransferTxResult{}, err
+	}
+
+	return replayedTransfer(idempotencyKey, arg.RequestHash)
+}
+
+// replayedTransfer decodes the result stored with an idempotency key, refusing it for another request
+func replayedTransfer(idempotencyKey IdempotencyKey, requestHash string) (IdempotentTransferTxResult, error) {
+	var result IdempotentTransferTxResult
+	if idempotencyKey.RequestHash != requestHash {
+		return result, ErrIdempotencyKeyReused
+	}
+
+	err := json.Unmarshal(idempotencyKey.Response, &result.TransferTxResult)
+	if err != nil {
+		return result, fmt.Errorf("failed to unmarshal transfer result: %w", err)
+	}
+
+	result.Replayed = true
+	return result, nil
+}
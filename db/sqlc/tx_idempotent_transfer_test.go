@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomIdempotentTransferParams(t *testing.T) IdempotentTransferTxParams {
+	account1 := createFundedAccount(t, 1000)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	return IdempotentTransferTxParams{
+		TransferTxParams: TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		},
+		Username:       account1.Owner,
+		IdempotencyKey: util.RandomString(32),
+		RequestHash:    util.RandomString(64),
+		ExpiresAt:      time.Now().Add(time.Hour),
+	}
+}
+
+func TestIdempotentTransferTx(t *testing.T) {
+	arg := createRandomIdempotentTransferParams(t)
+
+	result1, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result1.Replayed)
+	require.NotZero(t, result1.Transfer.ID)
+	require.Equal(t, int64(990), result1.FromAccount.Balance)
+
+	// the same key and request returns the first result without moving money again
+	result2, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+
+	account1, err := testStore.GetAccount(context.Background(), arg.FromAccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(990), account1.Balance)
+}
+
+func TestIdempotentTransferTxConcurrent(t *testing.T) {
+	arg := createRandomIdempotentTransferParams(t)
+
+	n := 5
+	errs := make(chan error)
+	results := make(chan IdempotentTransferTxResult)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := testStore.IdempotentTransferTx(context.Background(), arg)
+
+			errs <- err
+			results <- result
+		}()
+	}
+
+	replayed := 0
+	var transferID int64
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+
+		result := <-results
+		if transferID == 0 {
+			transferID = result.Transfer.ID
+		}
+		require.Equal(t, transferID, result.Transfer.ID)
+		if result.Replayed {
+			replayed++
+		}
+	}
+	require.Equal(t, n-1, replayed)
+
+	account1, err := testStore.GetAccount(context.Background(), arg.FromAccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(990), account1.Balance)
+}
+
+func TestIdempotentTransferTxKeyReused(t *testing.T) {
+	arg := createRandomIdempotentTransferParams(t)
+
+	_, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	arg.RequestHash = util.RandomString(64)
+	arg.Amount = 20
+	_, err = testStore.IdempotentTransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+
+	account1, err := testStore.GetAccount(context.Background(), arg.FromAccountID)
+	require.NoError(t, err)
+	require.Equal(t, int64(990), account1.Balance)
+}
+
+func TestIdempotentTransferTxExpiredKey(t *testing.T) {
+	arg := createRandomIdempotentTransferParams(t)
+	arg.ExpiresAt = time.Now().Add(-time.Second)
+
+	result1, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// an expired key is claimed again and the transfer runs a second time
+	result2, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result2.Replayed)
+	require.NotEqual(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, int64(980), result2.FromAccount.Balance)
+}
+
+func TestReplayIdempotentTransfer(t *testing.T) {
+	arg := createRandomIdempotentTransferParams(t)
+	replayArg := ReplayIdempotentTransferParams{
+		Username:       arg.Username,
+		IdempotencyKey: arg.IdempotencyKey,
+		RequestHash:    arg.RequestHash,
+	}
+
+	_, err := testStore.ReplayIdempotentTransfer(context.Background(), replayArg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	result1, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	result2, err := testStore.ReplayIdempotentTransfer(context.Background(), replayArg)
+	require.NoError(t, err)
+	require.True(t, result2.Replayed)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+
+	replayArg.RequestHash = util.RandomString(64)
+	_, err = testStore.ReplayIdempotentTransfer(context.Background(), replayArg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyReused)
+}
+
+func TestReplayIdempotentTransferExpiredKey(t *testing.T) {
+	arg := createRandomIdempotentTransferParams(t)
+	arg.ExpiresAt = time.Now().Add(-time.Second)
+
+	_, err := testStore.IdempotentTransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	_, err = testStore.ReplayIdempotentTransfer(context.Background(), ReplayIdempotentTransferParams{
+		Username:       arg.Username,
+		IdempotencyKey: arg.IdempotencyKey,
+		RequestHash:    arg.RequestHash,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestDeleteExpiredIdempotencyKeys(t *testing.T) {
+	expired := createRandomIdempotentTransferParams(t)
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	_, err := testStore.IdempotentTransferTx(context.Background(), expired)
+	require.NoError(t, err)
+
+	valid := createRandomIdempotentTransferParams(t)
+	_, err = testStore.IdempotentTransferTx(context.Background(), valid)
+	require.NoError(t, err)
+
+	deleted, err := testStore.DeleteExpiredIdempotencyKeys(context.Background())
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+
+	_, err = testStore.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: expired.Username,
+		Key:      expired.IdempotencyKey,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = testStore.GetIdempotencyKey(context.Background(), GetIdempotencyKeyParams{
+		Username: valid.Username,
+		Key:      valid.IdempotencyKey,
+	})
+	require.NoError(t, err)
+}
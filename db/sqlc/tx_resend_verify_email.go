@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// ResendVerifyEmailTxParams contains the input parameters of the ResendVerifyEmail transaction
+type ResendVerifyEmailTxParams struct {
+	Username string
+	// Cooldown is how long the user waits before asking again
+	Cooldown time.Duration
+	// Outbox holds the tasks to publish once the request is committed, the verification email
+	Outbox []CreateOutboxMessageParams
+}
+
+// ResendVerifyEmailTxResult is the result of the ResendVerifyEmail transaction
+type ResendVerifyEmailTxResult struct {
+	Outbox []Outbox
+}
+
+// ResendVerifyEmailTx writes the tasks sending a new verification email to the user, at most once per cooldown.
+// It returns ErrCooldown when the user asked too recently, and then writes nothing.
+func (store *SQLStore) ResendVerifyEmailTx(ctx context.Context, arg ResendVerifyEmailTxParams) (ResendVerifyEmailTxResult, error) {
+	var result ResendVerifyEmailTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		err := enterCooldown(ctx, q, CooldownVerifyEmail, arg.Username, arg.Cooldown)
+		if err != nil {
+			return err
+		}
+
+		result.Outbox, err = createOutboxMessages(ctx, q, arg.Outbox)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResendVerifyEmailTx(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := ResendVerifyEmailTxParams{
+		Username: user.Username,
+		Cooldown: time.Minute,
+		Outbox: []CreateOutboxMessageParams{{
+			TaskType: "task:send_verify_email",
+			Payload:  []byte(`{"username":"` + user.Username + `"}`),
+			Queue:    "critical",
+			MaxRetry: 10,
+		}},
+	}
+
+	result, err := testStore.ResendVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, result.Outbox, 1)
+
+	// a second request within the cooldown writes no task
+	_, err = testStore.ResendVerifyEmailTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrCooldown)
+
+	// the cooldown is per user
+	other := createRandomUser(t)
+	arg.Username = other.Username
+	_, err = testStore.ResendVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+}
+
+func TestResendVerifyEmailTxCooldownOver(t *testing.T) {
+	user := createRandomUser(t)
+
+	arg := ResendVerifyEmailTxParams{
+		Username: user.Username,
+		Cooldown: 10 * time.Millisecond,
+	}
+
+	_, err := testStore.ResendVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+	_, err = testStore.ResendVerifyEmailTx(context.Background(), arg)
+	require.NoError(t, err)
+}
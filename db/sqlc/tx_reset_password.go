@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ResetPasswordTxParams contains the input parameters of the ResetPassword transaction
+type ResetPasswordTxParams struct {
+	ResetID        int64
+	SecretCode     string
+	HashedPassword string
+}
+
+// ResetPasswordTxResult is the result of the ResetPassword transaction
+type ResetPasswordTxResult struct {
+	User          User
+	PasswordReset PasswordReset
+}
+
+// maxPasswordResetFailures is how many wrong codes use up a password reset, so that its code can't be guessed
+const maxPasswordResetFailures = 5
+
+// ResetPasswordTx sets a new password with a single-use reset code.
+// It marks the code as used, updates the password, records it in the audit log and blocks every session of the user
+// within a single database transaction.
+// ErrRecordNotFound is returned when the code is wrong, expired or already used.
+// A wrong code is counted against the reset, which is used up after maxPasswordResetFailures of them.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UpdatePasswordReset(ctx, UpdatePasswordResetParams{
+			ID:         arg.ResetID,
+			SecretCode: arg.SecretCode,
+		})
+		if err != nil {
+			return err
+		}
+
+		user, err := q.GetUserForUpdate(ctx, result.PasswordReset.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: result.PasswordReset.Username,
+			HashedPassword: pgtype.Text{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: pgtype.Timestamptz{
+				Time:  time.Now(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(ctx, q, AuditActionResetUserPassword, AuditTargetUser, user.Username, newAuditedUser(user), newAuditedUser(result.User))
+		if err != nil {
+			return err
+		}
+
+		// refresh tokens issued with the old password must not be renewed anymore
+		_, err = q.BlockUserSessions(ctx, result.User.Username)
+		return err
+	})
+	if errors.Is(err, ErrRecordNotFound) {
+		// counted outside of the transaction, which rolled back
+		failureErr := store.RecordPasswordResetFailure(ctx, RecordPasswordResetFailureParams{
+			ID:          arg.ResetID,
+			MaxAttempts: maxPasswordResetFailures,
+		})
+		if failureErr != nil {
+			return result, failureErr
+		}
+	}
+
+	return result, err
+}
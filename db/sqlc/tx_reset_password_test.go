@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestResetPasswordTx tests the ResetPasswordTx function
+func TestResetPasswordTx(t *testing.T) {
+	passwordReset := createRandomPasswordReset(t)
+	user, err := testStore.GetUser(context.Background(), passwordReset.Username)
+	require.NoError(t, err)
+
+	session1 := createRandomSession(t, user)
+	session2 := createRandomSession(t, user)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		ResetID:        passwordReset.ID,
+		SecretCode:     passwordReset.SecretCode,
+		HashedPassword: hashedPassword,
+	}
+
+	result, err := testStore.ResetPasswordTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, result)
+
+	// Verify PasswordReset fields
+	require.Equal(t, passwordReset.ID, result.PasswordReset.ID)
+	require.True(t, result.PasswordReset.IsUsed)
+
+	// Verify User fields
+	require.Equal(t, user.Username, result.User.Username)
+	require.Equal(t, hashedPassword, result.User.HashedPassword)
+	require.NotEqual(t, user.HashedPassword, result.User.HashedPassword)
+	require.WithinDuration(t, time.Now(), result.User.PasswordChangedAt, time.Second)
+
+	// Verify the reset is in the audit log without the password hash
+	event := lastAuditEvent(t, AuditTargetUser, user.Username)
+	require.Equal(t, AuditActionResetUserPassword, event.Action)
+	require.NotEmpty(t, event.Before)
+	require.NotContains(t, string(event.Before), user.HashedPassword)
+	require.NotContains(t, string(event.After), hashedPassword)
+
+	// Verify every session of the user is blocked
+	for _, session := range []Session{session1, session2} {
+		blockedSession, err := testStore.GetSession(context.Background(), session.ID)
+		require.NoError(t, err)
+		require.True(t, blockedSession.IsBlocked)
+	}
+
+	// The code can only be used once
+	_, err = testStore.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+// TestResetPasswordTxWrongCodes tests a reset is used up after too many wrong codes, even the right one is refused then
+func TestResetPasswordTxWrongCodes(t *testing.T) {
+	passwordReset := createRandomPasswordReset(t)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		ResetID:        passwordReset.ID,
+		SecretCode:     util.RandomString(32),
+		HashedPassword: hashedPassword,
+	}
+
+	for i := 0; i < maxPasswordResetFailures; i++ {
+		_, err = testStore.ResetPasswordTx(context.Background(), arg)
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	}
+
+	arg.SecretCode = passwordReset.SecretCode
+	_, err = testStore.ResetPasswordTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	user, err := testStore.GetUser(context.Background(), passwordReset.Username)
+	require.NoError(t, err)
+	require.NotEqual(t, hashedPassword, user.HashedPassword)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RotateSessionTxParams contains the input parameters of the RotateSession transaction.
+// FamilyID and ParentID of NewSession are taken from the rotated session.
+type RotateSessionTxParams struct {
+	SessionID  uuid.UUID
+	NewSession CreateSessionParams
+}
+
+// RotateSessionTxResult is the result of the RotateSession transaction
+type RotateSessionTxResult struct {
+	OldSession Session
+	Session    Session
+}
+
+// RotateSessionTx exchanges a refresh token session for a new one of the same family.
+// The old session is marked as rotated so that presenting its refresh token again can be detected.
+// The new session is recorded in the audit log within the same transaction.
+// ErrRefreshTokenReused is returned when the old session was already rotated or is blocked.
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		// the row lock makes concurrent renewals with the same token wait, only the first one succeeds
+		result.OldSession, err = q.RotateSession(ctx, arg.SessionID)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrRefreshTokenReused
+			}
+			return err
+		}
+
+		newSession := arg.NewSession
+		newSession.FamilyID = result.OldSession.FamilyID
+		newSession.ParentID = pgtype.UUID{
+			Bytes: result.OldSession.ID,
+			Valid: true,
+		}
+
+		result.Session, err = q.CreateSession(ctx, newSession)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionCreateSession, AuditTargetSession, result.Session.ID.String(), nil, newAuditedSession(result.Session))
+	})
+
+	return result, err
+}
@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomNewSession(user User) CreateSessionParams {
+	return CreateSessionParams{
+		ID:           uuid.New(),
+		Username:     user.Username,
+		RefreshToken: util.RandomString(32),
+		UserAgent:    util.RandomString(10),
+		ClientIp:     util.RandomString(15),
+		ExpiresAt:    time.Now().Add(time.Hour * 24),
+	}
+}
+
+// TestRotateSessionTx tests the RotateSessionTx function
+func TestRotateSessionTx(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	arg := RotateSessionTxParams{
+		SessionID:  session.ID,
+		NewSession: randomNewSession(user),
+	}
+
+	result, err := testStore.RotateSessionTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, session.ID, result.OldSession.ID)
+	require.True(t, result.OldSession.RotatedAt.Valid)
+
+	// the new session joins the family of the old one
+	require.Equal(t, arg.NewSession.ID, result.Session.ID)
+	require.Equal(t, arg.NewSession.RefreshToken, result.Session.RefreshToken)
+	require.Equal(t, session.FamilyID, result.Session.FamilyID)
+	require.True(t, result.Session.ParentID.Valid)
+	require.Equal(t, session.ID, uuid.UUID(result.Session.ParentID.Bytes))
+	require.False(t, result.Session.RotatedAt.Valid)
+
+	// rotating the same session again is a reuse
+	arg.NewSession = randomNewSession(user)
+	_, err = testStore.RotateSessionTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// the failed rotation created nothing
+	_, err = testStore.GetSession(context.Background(), arg.NewSession.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+// TestRotateSessionTxConcurrent checks that only one of several concurrent renewals wins
+func TestRotateSessionTxConcurrent(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	n := 5
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := testStore.RotateSessionTx(context.Background(), RotateSessionTxParams{
+				SessionID:  session.ID,
+				NewSession: randomNewSession(user),
+			})
+			errs <- err
+		}()
+	}
+
+	succeeded := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			succeeded++
+			continue
+		}
+		require.ErrorIs(t, err, ErrRefreshTokenReused)
+	}
+	require.Equal(t, 1, succeeded)
+}
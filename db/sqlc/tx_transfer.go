@@ -2,13 +2,23 @@ package db
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"simplebank/util"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // TransferTxParams contains the input parameters of the transfer transaction
+// Amount is debited in the currency of the from account. FxQuoteID is only needed when the accounts have different currencies.
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64     `json:"from_account_id"`
+	ToAccountID   int64     `json:"to_account_id"`
+	Amount        int64     `json:"amount"`
+	FxQuoteID     uuid.UUID `json:"fx_quote_id"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -22,54 +32,194 @@ type TransferTxResult struct {
 
 // TransferTx performs a monez transfer from one account to another
 // It creates a transfer record, add account entries, update accounts balance within a single datybase transaction
+// It fails with ErrInsufficientFunds when the from account would go past its overdraft limit
+// and with ErrAccountNotActive when either account is frozen or closed
+// Between different currencies the to account is credited the amount converted at the rate of the fx quote
+// The transfer is recorded in the audit log within the same transaction
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+		result, err = transfer(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// transfer moves money between two accounts using the queries of an already open database transaction
+func transfer(ctx context.Context, q *Queries, arg TransferTxParams) (result TransferTxResult, err error) {
+	// lock both accounts before checking the funds, so no concurrent debit can slip in between
+	fromAccount, toAccount, err := lockAccounts(ctx, q, arg.FromAccountID, arg.ToAccountID)
+	if err != nil {
+		return
+	}
+
+	err = checkActive(fromAccount)
+	if err != nil {
+		return
+	}
+	err = checkActive(toAccount)
+	if err != nil {
+		return
+	}
+
+	err = checkFunds(fromAccount, arg.Amount)
+	if err != nil {
+		return
+	}
+
+	transferArg := CreateTransferParams{
+		FromAccountID:   arg.FromAccountID,
+		ToAccountID:     arg.ToAccountID,
+		Amount:          arg.Amount,
+		ConvertedAmount: arg.Amount,
+	}
+	if fromAccount.Currency != toAccount.Currency {
+		err = applyFxQuote(ctx, q, arg.FxQuoteID, fromAccount, toAccount, &transferArg)
 		if err != nil {
-			return err
+			return
 		}
+	}
 
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
+	result.Transfer, err = q.CreateTransfer(ctx, transferArg)
+	if err != nil {
+		// a quote can only back one transfer
+		if transferArg.FxQuoteID.Valid && ErrorCode(err) == UniqueViolation {
+			err = ErrFxQuoteUsed
 		}
+		return
+	}
+	convertedAmount := result.Transfer.ConvertedAmount
+
+	transferID := pgtype.Int8{Int64: result.Transfer.ID, Valid: true}
+
+	result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.FromAccountID,
+		Amount:     -arg.Amount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return
+	}
+
+	result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  arg.ToAccountID,
+		Amount:     convertedAmount,
+		TransferID: transferID,
+	})
+	if err != nil {
+		return
+	}
+
+	//get account > update its balance
+	// PS 1: however... we need to avoid DB been accessed and concurrent
+	// transactions get the same value
+	// PS 2: create a getAccountForUpdate when we lock the next operations until previous is done
+	// PS 3: avoid deadlock due to concurrent operations, make sure getAccountforUpdate has this after its where> FOR NO KEY UPDATE;
+	// PS 4: I created an IF condition to check the accountID and perform the correct order to update the DB without deadlock
 
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
+	if arg.FromAccountID < arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, convertedAmount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, convertedAmount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return
+	}
+
+	err = recordAuditEvent(ctx, q, AuditActionCreateTransfer, AuditTargetTransfer, strconv.FormatInt(result.Transfer.ID, 10), nil, result)
+	return
+}
+
+// lockAccounts locks the rows of both accounts, always in the same ID order to avoid deadlocks
+func lockAccounts(
+	ctx context.Context,
+	q *Queries,
+	fromAccountID int64,
+	toAccountID int64,
+) (fromAccount Account, toAccount Account, err error) {
+	if fromAccountID < toAccountID {
+		fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
 		if err != nil {
-			return err
+			return
 		}
+		toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+		return
+	}
+
+	toAccount, err = q.GetAccountForUpdate(ctx, toAccountID)
+	if err != nil {
+		return
+	}
+	fromAccount, err = q.GetAccountForUpdate(ctx, fromAccountID)
+	return
+}
 
-		//get account > update its balance
-		// PS 1: however... we need to avoid DB been accessed and concurrent
-		// transactions get the same value
-		// PS 2: create a getAccountForUpdate when we lock the next operations until previous is done
-		// PS 3: avoid deadlock due to concurrent operations, make sure getAccountforUpdate has this after its where> FOR NO KEY UPDATE;
-		// PS 4: I created an IF condition to check the accountID and perform the correct order to update the DB without deadlock
-
-		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+// applyFxQuote converts the amount of a transfer between accounts in different currencies.
+// The quote must belong to the owner of the from account, cover the currency pair of the accounts and not be expired.
+func applyFxQuote(
+	ctx context.Context,
+	q *Queries,
+	quoteID uuid.UUID,
+	fromAccount Account,
+	toAccount Account,
+	arg *CreateTransferParams,
+) error {
+	if quoteID == uuid.Nil {
+		return ErrFxQuoteRequired
+	}
+
+	quote, err := q.GetFxQuote(ctx, quoteID)
+	if err != nil {
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrInvalidFxQuote
 		}
+		return err
+	}
 
-		return nil
-	})
+	if quote.Username != fromAccount.Owner ||
+		quote.FromCurrency != fromAccount.Currency ||
+		quote.ToCurrency != toAccount.Currency {
+		return ErrInvalidFxQuote
+	}
 
-	return result, err
+	if time.Now().After(quote.ExpiresAt) {
+		return ErrFxQuoteExpired
+	}
+
+	arg.ConvertedAmount, err = util.ConvertAmount(arg.Amount, quote.Rate, fromAccount.Currency, toAccount.Currency)
+	if err != nil {
+		// debiting the from account without crediting the to account would make the money disappear
+		if errors.Is(err, util.ErrConvertedAmountTooSmall) {
+			return ErrFxAmountTooSmall
+		}
+		return err
+	}
+	arg.FxRate = pgtype.Int8{Int64: quote.Rate, Valid: true}
+	arg.FxQuoteID = pgtype.UUID{Bytes: quote.ID, Valid: true}
+
+	return nil
+}
+
+// checkActive makes sure money can move in or out of the account.
+// The account row must be locked by the caller.
+func checkActive(account Account) error {
+	if account.Status != util.AccountStatusActive {
+		return fmt.Errorf("%w: account (%d) is %s", ErrAccountNotActive, account.ID, account.Status)
+	}
+	return nil
+}
+
+// checkFunds makes sure the account can be debited by amount without going past its overdraft limit.
+// The account row must be locked by the caller.
+func checkFunds(account Account, amount int64) error {
+	if account.Balance+account.OverdraftLimit < amount {
+		return ErrInsufficientFunds
+	}
+	return nil
 }
 
 func addMoney(
@@ -3,14 +3,18 @@ package db
 import (
 	"context"
 	// "fmt"
+	"simplebank/util"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
 func TestTransferTx(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
 	// fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// run n concurrent transfer transaction
@@ -64,6 +68,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, -amount, FromEntry.Amount)
 		require.NotZero(t, FromEntry.ID)
 		require.NotZero(t, FromEntry.CreatedAt)
+		require.Equal(t, transfer.ID, FromEntry.TransferID.Int64)
 		_, err = testStore.GetEntry(context.Background(), FromEntry.ID)
 		require.NoError(t, err)
 
@@ -73,6 +78,7 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, amount, ToEntry.Amount)
 		require.NotZero(t, ToEntry.ID)
 		require.NotZero(t, ToEntry.CreatedAt)
+		require.Equal(t, transfer.ID, ToEntry.TransferID.Int64)
 		_, err = testStore.GetEntry(context.Background(), ToEntry.ID)
 		require.NoError(t, err)
 
@@ -115,8 +121,8 @@ func TestTransferTx(t *testing.T) {
 
 // testing deadlock when transactions are done back and forth from account 1 and 2
 func TestTransferTxDeadlock(t *testing.T) {
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccountWithCurrency(t, account1.Currency, 1000)
 	// fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// run n concurrent transfer transaction
@@ -166,3 +172,139 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updatedAccount1.Balance)
 	require.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	account1 := createFundedAccount(t, 10)
+	account2 := createRandomAccountWithCurrency(t, account1.Currency)
+
+	_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        11,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing should have moved
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testStore.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+
+	// the overdraft limit allows the balance to go below zero
+	_, err = testStore.UpdateAccountOverdraftLimit(context.Background(), UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: 5,
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        15,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(-5), result.FromAccount.Balance)
+}
+
+func TestTransferTxFx(t *testing.T) {
+	account1 := createFundedAccountWithCurrency(t, util.USD, 1000)
+	account2 := createRandomAccountWithCurrency(t, util.EUR)
+
+	// 0.9 EUR per USD
+	rate := int64(90_000_000)
+	quote := createFxQuoteForUser(t, account1.Owner, util.USD, util.EUR, rate, time.Now().Add(time.Minute))
+
+	result, err := testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		FxQuoteID:     quote.ID,
+	})
+	require.NoError(t, err)
+
+	// the transfer records the rate and both amounts
+	transfer := result.Transfer
+	require.Equal(t, int64(100), transfer.Amount)
+	require.Equal(t, int64(90), transfer.ConvertedAmount)
+	require.Equal(t, rate, transfer.FxRate.Int64)
+	require.Equal(t, quote.ID, uuid.UUID(transfer.FxQuoteID.Bytes))
+
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(90), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-100, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+90, result.ToAccount.Balance)
+
+	// a quote backs a single transfer
+	_, err = testStore.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		FxQuoteID:     quote.ID,
+	})
+	require.ErrorIs(t, err, ErrFxQuoteUsed)
+}
+
+func TestTransferTxFxQuoteErrors(t *testing.T) {
+	account1 := createFundedAccountWithCurrency(t, util.USD, 1000)
+	account2 := createRandomAccountWithCurrency(t, util.EUR)
+	other := createRandomUser(t)
+
+	expired := createFxQuoteForUser(t, account1.Owner, util.USD, util.EUR, util.FxRateScale, time.Now().Add(-time.Minute))
+	otherUser := createFxQuoteForUser(t, other.Username, util.USD, util.EUR, util.FxRateScale, time.Now().Add(time.Minute))
+	otherPair := createFxQuoteForUser(t, account1.Owner, util.USD, util.CAD, util.FxRateScale, time.Now().Add(time.Minute))
+	tinyRate := createFxQuoteForUser(t, account1.Owner, util.USD, util.EUR, 1, time.Now().Add(time.Minute))
+
+	testCases := []struct {
+		name      string
+		fxQuoteID uuid.UUID
+		err       error
+	}{
+		{name: "NoQuote", fxQuoteID: uuid.Nil, err: ErrFxQuoteRequired},
+		{name: "UnknownQuote", fxQuoteID: uuid.New(), err: ErrInvalidFxQuote},
+		{name: "Expired", fxQuoteID: expired.ID, err: ErrFxQuoteExpired},
+		{name: "OtherUser", fxQuoteID: otherUser.ID, err: ErrInvalidFxQuote},
+		{name: "OtherCurrencyPair", fxQuoteID: otherPair.ID, err: ErrInvalidFxQuote},
+		{name: "AmountTooSmall", fxQuoteID: tinyRate.ID, err: ErrFxAmountTooSmall},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := testStore.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: account1.ID,
+				ToAccountID:   account2.ID,
+				Amount:        10,
+				FxQuoteID:     tc.fxQuoteID,
+			})
+			require.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	// nothing should have moved
+	updatedAccount1, err := testStore.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+}
+
+func TestTransferTxAudit(t *testing.T) {
+	account1 := createFundedAccountWithCurrency(t, util.USD, 100)
+	account2 := createFundedAccountWithCurrency(t, util.USD, 0)
+
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: account1.Owner, Role: util.DepositorRole})
+	result, err := testStore.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	event := lastAuditEvent(t, AuditTargetTransfer, strconv.FormatInt(result.Transfer.ID, 10))
+	require.Equal(t, AuditActionCreateTransfer, event.Action)
+	require.Equal(t, account1.Owner, event.ActorUsername.String)
+	require.Equal(t, util.DepositorRole, event.ActorRole.String)
+	require.NotEmpty(t, event.After)
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"strconv"
+)
+
+// UpdateOverdraftLimitTxParams contains the input parameters of the UpdateOverdraftLimit transaction
+type UpdateOverdraftLimitTxParams struct {
+	UpdateAccountOverdraftLimitParams
+}
+
+// UpdateOverdraftLimitTxResult is the result of the UpdateOverdraftLimit transaction
+type UpdateOverdraftLimitTxResult struct {
+	Account Account
+}
+
+// UpdateOverdraftLimitTx sets how far the balance of an account may go below zero
+// and records the change in the audit log within a single database transaction.
+// A limit below the current debt of the account fails on the CHECK of the accounts table with a check violation.
+func (store *SQLStore) UpdateOverdraftLimitTx(ctx context.Context, arg UpdateOverdraftLimitTxParams) (UpdateOverdraftLimitTxResult, error) {
+	var result UpdateOverdraftLimitTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.ID)
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.UpdateAccountOverdraftLimit(ctx, arg.UpdateAccountOverdraftLimitParams)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionChangeOverdraft, AuditTargetAccount, strconv.FormatInt(account.ID, 10), account, result.Account)
+	})
+
+	return result, err
+}
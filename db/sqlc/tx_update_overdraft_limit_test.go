@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateOverdraftLimitTx(t *testing.T) {
+	account := createRandomAccount(t)
+	banker := util.RandomOwner()
+
+	arg := UpdateOverdraftLimitTxParams{
+		UpdateAccountOverdraftLimitParams: UpdateAccountOverdraftLimitParams{
+			ID:             account.ID,
+			OverdraftLimit: util.RandomMoney(),
+		},
+	}
+
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: banker, Role: util.BankerRole})
+	result, err := testStore.UpdateOverdraftLimitTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, account.ID, result.Account.ID)
+	require.Equal(t, account.Balance, result.Account.Balance)
+	require.Equal(t, arg.OverdraftLimit, result.Account.OverdraftLimit)
+
+	event := lastAuditEvent(t, AuditTargetAccount, strconv.FormatInt(account.ID, 10))
+	require.Equal(t, AuditActionChangeOverdraft, event.Action)
+	require.Equal(t, banker, event.ActorUsername.String)
+
+	var before, after Account
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, account.OverdraftLimit, before.OverdraftLimit)
+	require.Equal(t, arg.OverdraftLimit, after.OverdraftLimit)
+}
+
+func TestUpdateOverdraftLimitTxNotFound(t *testing.T) {
+	_, err := testStore.UpdateOverdraftLimitTx(context.Background(), UpdateOverdraftLimitTxParams{
+		UpdateAccountOverdraftLimitParams: UpdateAccountOverdraftLimitParams{
+			ID:             -1,
+			OverdraftLimit: 100,
+		},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUpdateOverdraftLimitTxBelowDebt(t *testing.T) {
+	account := createRandomAccount(t)
+
+	_, err := testStore.UpdateOverdraftLimitTx(context.Background(), UpdateOverdraftLimitTxParams{
+		UpdateAccountOverdraftLimitParams: UpdateAccountOverdraftLimitParams{
+			ID:             account.ID,
+			OverdraftLimit: 100,
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    account.Balance + 50,
+	})
+	require.NoError(t, err)
+
+	// the account owes 50, a limit of 10 would leave it past its overdraft
+	_, err = testStore.UpdateOverdraftLimitTx(context.Background(), UpdateOverdraftLimitTxParams{
+		UpdateAccountOverdraftLimitParams: UpdateAccountOverdraftLimitParams{
+			ID:             account.ID,
+			OverdraftLimit: 10,
+		},
+	})
+	require.Equal(t, CheckViolation, ErrorCode(err))
+
+	updatedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), updatedAccount.OverdraftLimit)
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+)
+
+// UpdateUserTxParams contains the input parameters of the UpdateUser transaction
+type UpdateUserTxParams struct {
+	UpdateUserParams
+	// Outbox holds the tasks to publish once the change is committed, such as the emails of an email change
+	Outbox []CreateOutboxMessageParams
+}
+
+// UpdateUserTxResult is the result of the UpdateUser transaction
+type UpdateUserTxResult struct {
+	User   User
+	Outbox []Outbox
+}
+
+// UpdateUserTx updates a user, records the user before and after the change in the audit log
+// and writes its outbox tasks within a single database transaction.
+// A new password also blocks every session of the user, as ResetPasswordTx does,
+// and a new pending email expires the codes sent for the addresses before it.
+func (store *SQLStore) UpdateUserTx(ctx context.Context, arg UpdateUserTxParams) (UpdateUserTxResult, error) {
+	var result UpdateUserTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		user, err := q.GetUserForUpdate(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, arg.UpdateUserParams)
+		if err != nil {
+			return err
+		}
+
+		err = recordAuditEvent(ctx, q, AuditActionUpdateUser, AuditTargetUser, user.Username, newAuditedUser(user), newAuditedUser(result.User))
+		if err != nil {
+			return err
+		}
+
+		// refresh tokens issued with the old password must not be renewed anymore
+		if arg.HashedPassword.Valid {
+			_, err = q.BlockUserSessions(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		// only the code sent to the new address may confirm it
+		if arg.PendingEmail.Valid {
+			err = q.ExpireUserVerifyEmails(ctx, arg.Username)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.Outbox, err = createOutboxMessages(ctx, q, arg.Outbox)
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateUserTx(t *testing.T) {
+	user := createRandomUser(t)
+	newFullName := util.RandomOwner()
+
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: user.Username, Role: user.Role})
+	result, err := testStore.UpdateUserTx(ctx, UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: user.Username,
+			FullName: pgtype.Text{String: newFullName, Valid: true},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, result.User.FullName)
+
+	event := lastAuditEvent(t, AuditTargetUser, user.Username)
+	require.Equal(t, AuditActionUpdateUser, event.Action)
+	require.Equal(t, user.Username, event.ActorUsername.String)
+
+	var before, after map[string]any
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, user.FullName, before["full_name"])
+	require.Equal(t, newFullName, after["full_name"])
+
+	// secrets never reach the audit log
+	require.NotContains(t, after, "hashed_password")
+	require.NotContains(t, after, "totp_secret")
+
+	// unknown users are not found, and nothing is audited
+	_, err = testStore.UpdateUserTx(ctx, UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{Username: util.RandomOwner()},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUpdateUserTxOutbox(t *testing.T) {
+	user := createRandomUser(t)
+	newEmail := util.RandomEmail()
+
+	arg := UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username:     user.Username,
+			PendingEmail: pgtype.Text{String: newEmail, Valid: true},
+		},
+		Outbox: []CreateOutboxMessageParams{{
+			TaskType: "task:send_verify_email",
+			Payload:  []byte(`{"username":"` + user.Username + `","email":"` + newEmail + `"}`),
+			Queue:    "critical",
+			MaxRetry: 10,
+		}},
+	}
+
+	result, err := testStore.UpdateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, newEmail, result.User.PendingEmail.String)
+
+	// the task is pending with the committed change
+	require.Len(t, result.Outbox, 1)
+	message, err := testStore.GetOutboxMessage(context.Background(), result.Outbox[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, arg.Outbox[0].TaskType, message.TaskType)
+	require.False(t, message.SentAt.Valid)
+}
+
+func TestUpdateUserTxPassword(t *testing.T) {
+	user := createRandomUser(t)
+	session := createRandomSession(t, user)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+
+	_, err = testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username:          user.Username,
+			HashedPassword:    pgtype.Text{String: hashedPassword, Valid: true},
+			PasswordChangedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		},
+	})
+	require.NoError(t, err)
+
+	// a refresh token issued with the old password can't be renewed
+	blockedSession, err := testStore.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blockedSession.IsBlocked)
+
+	// a change of name keeps the sessions
+	other := createRandomUser(t)
+	otherSession := createRandomSession(t, other)
+
+	_, err = testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username: other.Username,
+			FullName: pgtype.Text{String: util.RandomOwner(), Valid: true},
+		},
+	})
+	require.NoError(t, err)
+
+	otherSession, err = testStore.GetSession(context.Background(), otherSession.ID)
+	require.NoError(t, err)
+	require.False(t, otherSession.IsBlocked)
+}
+
+func TestUpdateUserTxExpiresVerifyEmails(t *testing.T) {
+	verifyEmail := createRandomVerifyEmail(t)
+
+	_, err := testStore.UpdateUserTx(context.Background(), UpdateUserTxParams{
+		UpdateUserParams: UpdateUserParams{
+			Username:     verifyEmail.Username,
+			PendingEmail: pgtype.Text{String: util.RandomEmail(), Valid: true},
+		},
+	})
+	require.NoError(t, err)
+
+	// the code sent before the change can't be used anymore
+	_, err = testStore.UpdateVerifyEmail(context.Background(), UpdateVerifyEmailParams{
+		ID:         verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
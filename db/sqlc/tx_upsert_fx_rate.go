@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// UpsertFxRateTxParams contains the input parameters of the UpsertFxRate transaction
+type UpsertFxRateTxParams struct {
+	UpsertFxRateParams
+}
+
+// UpsertFxRateTxResult is the result of the UpsertFxRate transaction
+type UpsertFxRateTxResult struct {
+	FxRate FxRate
+}
+
+// UpsertFxRateTx creates or replaces the rate of a currency pair
+// and records the old and new rates in the audit log within a single database transaction
+func (store *SQLStore) UpsertFxRateTx(ctx context.Context, arg UpsertFxRateTxParams) (UpsertFxRateTxResult, error) {
+	var result UpsertFxRateTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var before any
+
+		rate, err := q.GetFxRateForUpdate(ctx, GetFxRateForUpdateParams{
+			BaseCurrency:  arg.BaseCurrency,
+			QuoteCurrency: arg.QuoteCurrency,
+		})
+		if err == nil {
+			before = rate
+		} else if !errors.Is(err, ErrRecordNotFound) {
+			return err
+		}
+
+		result.FxRate, err = q.UpsertFxRate(ctx, arg.UpsertFxRateParams)
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionSetFxRate, AuditTargetFxRate, fxRateTargetID(arg.BaseCurrency, arg.QuoteCurrency), before, result.FxRate)
+	})
+
+	return result, err
+}
+
+// fxRateTargetID identifies a currency pair in the audit log, e.g. USD/EUR
+func fxRateTargetID(baseCurrency string, quoteCurrency string) string {
+	return baseCurrency + "/" + quoteCurrency
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertFxRateTx(t *testing.T) {
+	user := createRandomUser(t)
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: user.Username, Role: util.BankerRole})
+
+	arg := UpsertFxRateTxParams{
+		UpsertFxRateParams: UpsertFxRateParams{
+			BaseCurrency:  util.RandomString(3),
+			QuoteCurrency: util.RandomString(3),
+			Rate:          util.RandomInt(1, 2*util.FxRateScale),
+			UpdatedBy:     user.Username,
+		},
+	}
+
+	// a new pair has no rate before
+	result1, err := testStore.UpsertFxRateTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Rate, result1.FxRate.Rate)
+
+	targetID := fxRateTargetID(arg.BaseCurrency, arg.QuoteCurrency)
+	event := lastAuditEvent(t, AuditTargetFxRate, targetID)
+	require.Equal(t, AuditActionSetFxRate, event.Action)
+	require.Equal(t, user.Username, event.ActorUsername.String)
+	require.Empty(t, event.Before)
+
+	// replacing it keeps the old rate in the audit log
+	arg.Rate++
+	result2, err := testStore.UpsertFxRateTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Rate, result2.FxRate.Rate)
+
+	event = lastAuditEvent(t, AuditTargetFxRate, targetID)
+	var before, after FxRate
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, result1.FxRate.Rate, before.Rate)
+	require.Equal(t, result2.FxRate.Rate, after.Rate)
+}
@@ -2,8 +2,6 @@ package db
 
 import (
 	"context"
-
-	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // VerifyEmailTxParams contains the input parameters of the VerifyEmail transaction
@@ -18,8 +16,10 @@ type VerifyEmailTxResult struct {
 	VerifyEmail VerifyEmail
 }
 
-// VerifyEmailTx performs a monez VerifyEmail from one account to another
-// It creates a VerifyEmail record, add account entries, update accounts balance within a single datybase transaction
+// VerifyEmailTx uses a verification code and confirms the address it was sent to,
+// records the user before and after the confirmation in the audit log within a single database transaction.
+// It fails with ErrRecordNotFound when the code is wrong, used or expired,
+// or when it was sent to an address that is neither the email nor the pending email of the user anymore.
 func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (VerifyEmailTxResult, error) {
 	var result VerifyEmailTxResult
 
@@ -34,15 +34,22 @@ func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParam
 			return err
 		}
 
-		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+		user, err := q.GetUserForUpdate(ctx, result.VerifyEmail.Username)
+		if err != nil {
+			return err
+		}
+
+		// the code was sent to this address, for a new email it replaces the old one.
+		// A code sent to an address that is neither the email nor the pending email anymore confirms nothing.
+		result.User, err = q.ConfirmUserEmail(ctx, ConfirmUserEmailParams{
 			Username: result.VerifyEmail.Username,
-			IsEmailVerified: pgtype.Bool{
-				Bool:  true,
-				Valid: true,
-			},
+			Email:    result.VerifyEmail.Email,
 		})
+		if err != nil {
+			return err
+		}
 
-		return err
+		return recordAuditEvent(ctx, q, AuditActionConfirmUserEmail, AuditTargetUser, user.Username, newAuditedUser(user), newAuditedUser(result.User))
 	})
 
 	return result, err
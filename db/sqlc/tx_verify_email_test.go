@@ -2,9 +2,12 @@ package db
 
 import (
 	"context"
+	"encoding/json"
+	"simplebank/util"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 )
 
@@ -33,3 +36,77 @@ func TestVerifyEmailTx(t *testing.T) {
 	require.Equal(t, verifyEmail.Username, result.User.Username)
 	require.True(t, result.User.IsEmailVerified)
 }
+
+func TestVerifyEmailTxNewEmail(t *testing.T) {
+	user := createRandomUser(t)
+	newEmail := util.RandomEmail()
+
+	_, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username:     user.Username,
+		PendingEmail: pgtype.Text{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+
+	verifyEmail, err := testStore.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      newEmail,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	result, err := testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailId:    verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.NoError(t, err)
+
+	// the address the code was sent to replaces the old one
+	require.Equal(t, newEmail, result.User.Email)
+	require.True(t, result.User.IsEmailVerified)
+	require.False(t, result.User.PendingEmail.Valid)
+
+	// Verify the new address is in the audit log
+	event := lastAuditEvent(t, AuditTargetUser, result.User.Username)
+	require.Equal(t, AuditActionConfirmUserEmail, event.Action)
+
+	var after map[string]any
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, newEmail, after["email"])
+}
+
+func TestVerifyEmailTxStaleCode(t *testing.T) {
+	user := createRandomUser(t)
+	oldEmail := util.RandomEmail()
+	newEmail := util.RandomEmail()
+
+	_, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username:     user.Username,
+		PendingEmail: pgtype.Text{String: oldEmail, Valid: true},
+	})
+	require.NoError(t, err)
+
+	oldCode, err := testStore.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:   user.Username,
+		Email:      oldEmail,
+		SecretCode: util.RandomString(32),
+	})
+	require.NoError(t, err)
+
+	// the user asks for another address before confirming the first one
+	_, err = testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username:     user.Username,
+		PendingEmail: pgtype.Text{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.VerifyEmailTx(context.Background(), VerifyEmailTxParams{
+		EmailId:    oldCode.ID,
+		SecretCode: oldCode.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	updatedUser, err := testStore.GetUser(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, updatedUser.Email)
+	require.Equal(t, newEmail, updatedUser.PendingEmail.String)
+}
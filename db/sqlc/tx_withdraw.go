@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"strconv"
+)
+
+// WithdrawTxParams contains the input parameters of the withdraw transaction
+type WithdrawTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// WithdrawTxResult is the result of the withdraw transaction
+type WithdrawTxResult struct {
+	Account Account `json:"account"`
+	Entry   Entry   `json:"entry"`
+}
+
+// WithdrawTx takes money out of an account
+// It creates a negative account entry, updates the account balance and records the withdrawal in the audit log within a single database transaction.
+// It fails with ErrInsufficientFunds when the balance would go past the overdraft limit
+// and with ErrAccountNotActive when the account is frozen or closed.
+func (store *SQLStore) WithdrawTx(ctx context.Context, arg WithdrawTxParams) (WithdrawTxResult, error) {
+	var result WithdrawTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		err = checkActive(account)
+		if err != nil {
+			return err
+		}
+
+		err = checkFunds(account, arg.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.Entry, err = q.CreateEntry(ctx, CreateEntryParams{
+			AccountID: arg.AccountID,
+			Amount:    -arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Account, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
+			ID:     arg.AccountID,
+			Amount: -arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		return recordAuditEvent(ctx, q, AuditActionWithdraw, AuditTargetAccount, strconv.FormatInt(account.ID, 10), account, result.Account)
+	})
+
+	return result, err
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"simplebank/util"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawTx(t *testing.T) {
+	account := createFundedAccount(t, 1000)
+
+	// run n concurrent withdraw transactions
+	n := 5
+	amount := int64(10)
+
+	errs := make(chan error)
+	results := make(chan WithdrawTxResult)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			result, err := testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+				AccountID: account.ID,
+				Amount:    amount,
+			})
+
+			errs <- err
+			results <- result
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		err := <-errs
+		require.NoError(t, err)
+
+		result := <-results
+		require.NotEmpty(t, result)
+
+		// check entry
+		entry := result.Entry
+		require.Equal(t, account.ID, entry.AccountID)
+		require.Equal(t, -amount, entry.Amount)
+		require.NotZero(t, entry.ID)
+		require.NotZero(t, entry.CreatedAt)
+		_, err = testStore.GetEntry(context.Background(), entry.ID)
+		require.NoError(t, err)
+
+		// check account
+		require.Equal(t, account.ID, result.Account.ID)
+		diff := account.Balance - result.Account.Balance
+		require.True(t, diff > 0)
+		require.True(t, diff%amount == 0)
+	}
+
+	// check the final updated balance
+	updatedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance-int64(n)*amount, updatedAccount.Balance)
+}
+
+func TestWithdrawTxInsufficientFunds(t *testing.T) {
+	account := createFundedAccount(t, 10)
+
+	_, err := testStore.WithdrawTx(context.Background(), WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    11,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	updatedAccount, err := testStore.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updatedAccount.Balance)
+}
+
+func TestWithdrawTxAudit(t *testing.T) {
+	account := createFundedAccount(t, 100)
+
+	ctx := WithAuditActor(context.Background(), AuditActor{Username: util.RandomOwner(), Role: util.BankerRole})
+	result, err := testStore.WithdrawTx(ctx, WithdrawTxParams{
+		AccountID: account.ID,
+		Amount:    10,
+	})
+	require.NoError(t, err)
+
+	event := lastAuditEvent(t, AuditTargetAccount, strconv.FormatInt(account.ID, 10))
+	require.Equal(t, AuditActionWithdraw, event.Action)
+	require.Equal(t, util.BankerRole, event.ActorRole.String)
+
+	var before, after Account
+	require.NoError(t, json.Unmarshal(event.Before, &before))
+	require.NoError(t, json.Unmarshal(event.After, &after))
+	require.Equal(t, int64(100), before.Balance)
+	require.Equal(t, result.Account.Balance, after.Balance)
+}
@@ -7,10 +7,49 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const confirmUserEmail = `-- name: ConfirmUserEmail :one
+UPDATE users
+SET
+  email = $1,
+  is_email_verified = TRUE,
+  pending_email = CASE WHEN pending_email = $1 THEN NULL ELSE pending_email END
+WHERE
+  username = $2
+  AND (email = $1 OR pending_email = $1)
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
+`
+
+type ConfirmUserEmailParams struct {
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+func (q *Queries) ConfirmUserEmail(ctx context.Context, arg ConfirmUserEmailParams) (User, error) {
+	row := q.db.QueryRow(ctx, confirmUserEmail, arg.Email, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
   username,
@@ -21,7 +60,7 @@ INSERT INTO users (
 ) VALUES (
   $1, $2, $3, $4, $5
 )
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
 `
 
 type CreateUserParams struct {
@@ -50,12 +89,80 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const disableUserTotp = `-- name: DisableUserTotp :one
+UPDATE users
+SET
+  totp_secret = NULL,
+  is_totp_enabled = FALSE,
+  totp_last_step = 0
+WHERE
+  username = $1
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
+`
+
+func (q *Queries) DisableUserTotp(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, disableUserTotp, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const enableUserTotp = `-- name: EnableUserTotp :one
+UPDATE users
+SET
+  is_totp_enabled = TRUE
+WHERE
+  username = $1
+  AND totp_secret IS NOT NULL
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
+`
+
+func (q *Queries) EnableUserTotp(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, enableUserTotp, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -71,10 +178,187 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email FROM users
+WHERE username = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserForUpdate, username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
 	)
 	return i, err
 }
 
+const getUserTokensRevokedAt = `-- name: GetUserTokensRevokedAt :one
+SELECT password_changed_at, tokens_revoked_at FROM users
+WHERE username = $1 LIMIT 1
+`
+
+type GetUserTokensRevokedAtRow struct {
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	TokensRevokedAt   time.Time `json:"tokens_revoked_at"`
+}
+
+func (q *Queries) GetUserTokensRevokedAt(ctx context.Context, username string) (GetUserTokensRevokedAtRow, error) {
+	row := q.db.QueryRow(ctx, getUserTokensRevokedAt, username)
+	var i GetUserTokensRevokedAtRow
+	err := row.Scan(&i.PasswordChangedAt, &i.TokensRevokedAt)
+	return i, err
+}
+
+const isEmailTaken = `-- name: IsEmailTaken :one
+SELECT EXISTS (
+  SELECT 1 FROM users
+  WHERE username <> $1
+    AND (email = $2 OR pending_email = $2)
+)
+`
+
+type IsEmailTakenParams struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+func (q *Queries) IsEmailTaken(ctx context.Context, arg IsEmailTakenParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isEmailTaken, arg.Username, arg.Email)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE users
+SET
+  tokens_revoked_at = $2
+WHERE
+  username = $1
+  AND tokens_revoked_at < $2
+`
+
+type RevokeUserTokensParams struct {
+	Username        string    `json:"username"`
+	TokensRevokedAt time.Time `json:"tokens_revoked_at"`
+}
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error {
+	_, err := q.db.Exec(ctx, revokeUserTokens, arg.Username, arg.TokensRevokedAt)
+	return err
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email FROM users
+WHERE
+  ($1::text IS NULL
+    OR full_name ILIKE '%' || replace(replace(replace($1, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\'
+    OR username ILIKE '%' || replace(replace(replace($1, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\')
+  AND ($2::text IS NULL
+    OR email ILIKE '%' || replace(replace(replace($2, '\', '\\'), '%', '\%'), '_', '\_') || '%' ESCAPE '\')
+  AND ($3::boolean IS NULL OR is_email_verified = $3)
+  AND username > $4
+ORDER BY username
+LIMIT $5
+`
+
+type SearchUsersParams struct {
+	Name            pgtype.Text `json:"name"`
+	Email           pgtype.Text `json:"email"`
+	IsEmailVerified pgtype.Bool `json:"is_email_verified"`
+	AfterUsername   string      `json:"after_username"`
+	Limit           int32       `json:"limit"`
+}
+
+// the wildcards and the escape character of LIKE in name and email are escaped, so they match themselves
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, searchUsers,
+		arg.Name,
+		arg.Email,
+		arg.IsEmailVerified,
+		arg.AfterUsername,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []User{}
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.Username,
+			&i.HashedPassword,
+			&i.FullName,
+			&i.Email,
+			&i.PasswordChangedAt,
+			&i.CreatedAt,
+			&i.IsEmailVerified,
+			&i.Role,
+			&i.TokensRevokedAt,
+			&i.TotpSecret,
+			&i.IsTotpEnabled,
+			&i.TotpLastStep,
+			&i.PendingEmail,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
 SET
@@ -83,10 +367,11 @@ SET
   full_name = COALESCE($3, full_name),
   email = COALESCE($4, email),
   role = COALESCE($5, role),
-  is_email_verified = COALESCE($6, is_email_verified)
+  is_email_verified = COALESCE($6, is_email_verified),
+  pending_email = COALESCE($7, pending_email)
 WHERE
-  username = $7
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+  username = $8
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
 `
 
 type UpdateUserParams struct {
@@ -96,6 +381,7 @@ type UpdateUserParams struct {
 	Email             pgtype.Text        `json:"email"`
 	Role              pgtype.Text        `json:"role"`
 	IsEmailVerified   pgtype.Bool        `json:"is_email_verified"`
+	PendingEmail      pgtype.Text        `json:"pending_email"`
 	Username          string             `json:"username"`
 }
 
@@ -107,6 +393,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.Email,
 		arg.Role,
 		arg.IsEmailVerified,
+		arg.PendingEmail,
 		arg.Username,
 	)
 	var i User
@@ -119,6 +406,84 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const updateUserTotpSecret = `-- name: UpdateUserTotpSecret :one
+UPDATE users
+SET
+  totp_secret = $2,
+  totp_last_step = 0
+WHERE
+  username = $1
+  AND is_totp_enabled = FALSE
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
+`
+
+type UpdateUserTotpSecretParams struct {
+	Username   string      `json:"username"`
+	TotpSecret pgtype.Text `json:"totp_secret"`
+}
+
+func (q *Queries) UpdateUserTotpSecret(ctx context.Context, arg UpdateUserTotpSecretParams) (User, error) {
+	row := q.db.QueryRow(ctx, updateUserTotpSecret, arg.Username, arg.TotpSecret)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
+	)
+	return i, err
+}
+
+const useUserTotpStep = `-- name: UseUserTotpStep :one
+UPDATE users
+SET
+  totp_last_step = $2
+WHERE
+  username = $1
+  AND totp_last_step < $2
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, tokens_revoked_at, totp_secret, is_totp_enabled, totp_last_step, pending_email
+`
+
+type UseUserTotpStepParams struct {
+	Username     string `json:"username"`
+	TotpLastStep int64  `json:"totp_last_step"`
+}
+
+func (q *Queries) UseUserTotpStep(ctx context.Context, arg UseUserTotpStepParams) (User, error) {
+	row := q.db.QueryRow(ctx, useUserTotpStep, arg.Username, arg.TotpLastStep)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TokensRevokedAt,
+		&i.TotpSecret,
+		&i.IsTotpEnabled,
+		&i.TotpLastStep,
+		&i.PendingEmail,
 	)
 	return i, err
 }
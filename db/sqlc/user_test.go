@@ -58,6 +58,54 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
 
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2, err := testStore.GetUserByEmail(context.Background(), user1.Email)
+
+	require.NoError(t, err)
+	require.NotEmpty(t, user2)
+
+	require.Equal(t, user1.Username, user2.Username)
+	require.Equal(t, user1.Email, user2.Email)
+
+	_, err = testStore.GetUserByEmail(context.Background(), util.RandomEmail())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestIsEmailTaken(t *testing.T) {
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+	pendingEmail := util.RandomEmail()
+
+	_, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username:     other.Username,
+		PendingEmail: pgtype.Text{String: pendingEmail, Valid: true},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		email string
+		taken bool
+	}{
+		{name: "Free", email: util.RandomEmail(), taken: false},
+		{name: "OwnEmail", email: user.Email, taken: false},
+		{name: "EmailOfOther", email: other.Email, taken: true},
+		{name: "PendingEmailOfOther", email: pendingEmail, taken: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			taken, err := testStore.IsEmailTaken(context.Background(), IsEmailTakenParams{
+				Username: user.Username,
+				Email:    tc.email,
+			})
+			require.NoError(t, err)
+			require.Equal(t, tc.taken, taken)
+		})
+	}
+}
+
 func TestUpdateUser(t *testing.T) {
 	user1 := createRandomUser(t)
 	hashedPassword, err := util.HashPassword(util.RandomString(6))
@@ -105,3 +153,214 @@ func TestUpdateUser(t *testing.T) {
 	require.Equal(t, arg.IsEmailVerified.Bool, user2.IsEmailVerified)
 	require.WithinDuration(t, arg.PasswordChangedAt.Time, user2.PasswordChangedAt, time.Second)
 }
+
+func TestRevokeUserTokens(t *testing.T) {
+	user := createRandomUser(t)
+
+	revokedAt, err := testStore.GetUserTokensRevokedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, user.PasswordChangedAt, revokedAt.PasswordChangedAt, time.Second)
+	require.True(t, revokedAt.TokensRevokedAt.Before(user.CreatedAt))
+
+	now := time.Now()
+	err = testStore.RevokeUserTokens(context.Background(), RevokeUserTokensParams{
+		Username:        user.Username,
+		TokensRevokedAt: now,
+	})
+	require.NoError(t, err)
+
+	// the watermark never moves back
+	err = testStore.RevokeUserTokens(context.Background(), RevokeUserTokensParams{
+		Username:        user.Username,
+		TokensRevokedAt: now.Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	revokedAt, err = testStore.GetUserTokensRevokedAt(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.WithinDuration(t, now, revokedAt.TokensRevokedAt, time.Second)
+}
+
+func enrollRandomTotp(t *testing.T, user User) User {
+	encryptedSecret, err := util.EncryptString(util.RandomString(util.EncryptionKeySize), util.RandomString(32))
+	require.NoError(t, err)
+
+	user, err = testStore.UpdateUserTotpSecret(context.Background(), UpdateUserTotpSecretParams{
+		Username:   user.Username,
+		TotpSecret: pgtype.Text{String: encryptedSecret, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, encryptedSecret, user.TotpSecret.String)
+	require.False(t, user.IsTotpEnabled)
+
+	return user
+}
+
+func TestUpdateUserTotpSecret(t *testing.T) {
+	user := enrollRandomTotp(t, createRandomUser(t))
+
+	user, err := testStore.EnableUserTotp(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.True(t, user.IsTotpEnabled)
+
+	// an enabled secret can't be replaced
+	_, err = testStore.UpdateUserTotpSecret(context.Background(), UpdateUserTotpSecretParams{
+		Username:   user.Username,
+		TotpSecret: pgtype.Text{String: util.RandomString(32), Valid: true},
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestEnableUserTotpWithoutSecret(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testStore.EnableUserTotp(context.Background(), user.Username)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestUseUserTotpStep(t *testing.T) {
+	user := enrollRandomTotp(t, createRandomUser(t))
+	step := util.TOTPStep(time.Now())
+
+	user, err := testStore.UseUserTotpStep(context.Background(), UseUserTotpStepParams{
+		Username:     user.Username,
+		TotpLastStep: step,
+	})
+	require.NoError(t, err)
+	require.Equal(t, step, user.TotpLastStep)
+
+	// a code is never accepted twice, nor an older one
+	for _, usedStep := range []int64{step, step - 1} {
+		_, err = testStore.UseUserTotpStep(context.Background(), UseUserTotpStepParams{
+			Username:     user.Username,
+			TotpLastStep: usedStep,
+		})
+		require.ErrorIs(t, err, ErrRecordNotFound)
+	}
+}
+
+func TestDisableUserTotp(t *testing.T) {
+	user := enrollRandomTotp(t, createRandomUser(t))
+
+	user, err := testStore.DisableUserTotp(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.False(t, user.TotpSecret.Valid)
+	require.False(t, user.IsTotpEnabled)
+	require.Zero(t, user.TotpLastStep)
+}
+
+func TestConfirmUserEmail(t *testing.T) {
+	user := createRandomUser(t)
+	newEmail := util.RandomEmail()
+
+	pending, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username:     user.Username,
+		PendingEmail: pgtype.Text{String: newEmail, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Email, pending.Email)
+	require.Equal(t, newEmail, pending.PendingEmail.String)
+
+	// confirming the current email keeps the pending one
+	confirmed, err := testStore.ConfirmUserEmail(context.Background(), ConfirmUserEmailParams{
+		Username: user.Username,
+		Email:    user.Email,
+	})
+	require.NoError(t, err)
+	require.True(t, confirmed.IsEmailVerified)
+	require.Equal(t, user.Email, confirmed.Email)
+	require.Equal(t, newEmail, confirmed.PendingEmail.String)
+
+	confirmed, err = testStore.ConfirmUserEmail(context.Background(), ConfirmUserEmailParams{
+		Username: user.Username,
+		Email:    newEmail,
+	})
+	require.NoError(t, err)
+	require.True(t, confirmed.IsEmailVerified)
+	require.Equal(t, newEmail, confirmed.Email)
+	require.False(t, confirmed.PendingEmail.Valid)
+
+	// an address the user moved away from can't be confirmed anymore
+	_, err = testStore.ConfirmUserEmail(context.Background(), ConfirmUserEmailParams{
+		Username: user.Username,
+		Email:    user.Email,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestSearchUsers(t *testing.T) {
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	users, err := testStore.SearchUsers(context.Background(), SearchUsersParams{
+		Name:  pgtype.Text{String: user1.FullName, Valid: true},
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user1.Username, users[0].Username)
+
+	users, err = testStore.SearchUsers(context.Background(), SearchUsersParams{
+		Email:           pgtype.Text{String: user2.Email, Valid: true},
+		IsEmailVerified: pgtype.Bool{Bool: false, Valid: true},
+		Limit:           5,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user2.Username, users[0].Username)
+
+	// the cursor skips the users up to the given username
+	users, err = testStore.SearchUsers(context.Background(), SearchUsersParams{
+		Email:         pgtype.Text{String: user2.Email, Valid: true},
+		AfterUsername: user2.Username,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, users)
+
+	users, err = testStore.SearchUsers(context.Background(), SearchUsersParams{
+		Email:           pgtype.Text{String: user2.Email, Valid: true},
+		IsEmailVerified: pgtype.Bool{Bool: true, Valid: true},
+		Limit:           5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, users)
+}
+
+func TestSearchUsersLiteralWildcards(t *testing.T) {
+	prefix := util.RandomOwner()
+	suffix := util.RandomOwner()
+
+	user1 := createRandomUser(t)
+	user2 := createRandomUser(t)
+
+	_, err := testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: user1.Username,
+		FullName: pgtype.Text{String: prefix + "_" + suffix, Valid: true},
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.UpdateUser(context.Background(), UpdateUserParams{
+		Username: user2.Username,
+		FullName: pgtype.Text{String: prefix + "x" + suffix, Valid: true},
+	})
+	require.NoError(t, err)
+
+	// the underscore only matches itself, not any character
+	users, err := testStore.SearchUsers(context.Background(), SearchUsersParams{
+		Name:  pgtype.Text{String: prefix + "_" + suffix, Valid: true},
+		Limit: 5,
+	})
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	require.Equal(t, user1.Username, users[0].Username)
+
+	for _, name := range []string{prefix + "%" + suffix, prefix + `\` + suffix} {
+		users, err = testStore.SearchUsers(context.Background(), SearchUsersParams{
+			Name:  pgtype.Text{String: name, Valid: true},
+			Limit: 5,
+		})
+		require.NoError(t, err)
+		require.Empty(t, users)
+	}
+}
@@ -7,27 +7,72 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const createVerifyEmail = `-- name: CreateVerifyEmail :one
 INSERT INTO verify_emails (
   username,
   email,
-  secret_code
+  secret_code,
+  task_id
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4
 )
-RETURNING id, username, email, secret_code, is_used, created_at, expired_at
+RETURNING id, username, email, secret_code, is_used, created_at, expired_at, task_id
 `
 
 type CreateVerifyEmailParams struct {
-	Username   string `json:"username"`
-	Email      string `json:"email"`
-	SecretCode string `json:"secret_code"`
+	Username   string      `json:"username"`
+	Email      string      `json:"email"`
+	SecretCode string      `json:"secret_code"`
+	TaskID     pgtype.Text `json:"task_id"`
 }
 
 func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error) {
-	row := q.db.QueryRow(ctx, createVerifyEmail, arg.Username, arg.Email, arg.SecretCode)
+	row := q.db.QueryRow(ctx, createVerifyEmail,
+		arg.Username,
+		arg.Email,
+		arg.SecretCode,
+		arg.TaskID,
+	)
+	var i VerifyEmail
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.SecretCode,
+		&i.IsUsed,
+		&i.CreatedAt,
+		&i.ExpiredAt,
+		&i.TaskID,
+	)
+	return i, err
+}
+
+const expireUserVerifyEmails = `-- name: ExpireUserVerifyEmails :exec
+UPDATE verify_emails
+SET
+  expired_at = now()
+WHERE
+  username = $1
+  AND is_used = FALSE
+  AND expired_at > now()
+`
+
+func (q *Queries) ExpireUserVerifyEmails(ctx context.Context, username string) error {
+	_, err := q.db.Exec(ctx, expireUserVerifyEmails, username)
+	return err
+}
+
+const getVerifyEmailByTaskID = `-- name: GetVerifyEmailByTaskID :one
+SELECT id, username, email, secret_code, is_used, created_at, expired_at, task_id FROM verify_emails
+WHERE task_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetVerifyEmailByTaskID(ctx context.Context, taskID pgtype.Text) (VerifyEmail, error) {
+	row := q.db.QueryRow(ctx, getVerifyEmailByTaskID, taskID)
 	var i VerifyEmail
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +82,7 @@ func (q *Queries) CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailPa
 		&i.IsUsed,
 		&i.CreatedAt,
 		&i.ExpiredAt,
+		&i.TaskID,
 	)
 	return i, err
 }
@@ -50,7 +96,7 @@ WHERE
   AND secret_code = $2
   AND is_used = FALSE
   AND expired_at > now()
-RETURNING id, username, email, secret_code, is_used, created_at, expired_at
+RETURNING id, username, email, secret_code, is_used, created_at, expired_at, task_id
 `
 
 type UpdateVerifyEmailParams struct {
@@ -69,6 +115,7 @@ func (q *Queries) UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailPa
 		&i.IsUsed,
 		&i.CreatedAt,
 		&i.ExpiredAt,
+		&i.TaskID,
 	)
 	return i, err
 }
@@ -57,3 +57,17 @@ func TestUpdateVerifyEmail(t *testing.T) {
 	require.WithinDuration(t, verifyEmail.CreatedAt, updatedVerifyEmail.CreatedAt, time.Second)
 	require.WithinDuration(t, verifyEmail.ExpiredAt, updatedVerifyEmail.ExpiredAt, time.Second)
 }
+
+func TestExpireUserVerifyEmails(t *testing.T) {
+	verifyEmail := createRandomVerifyEmail(t)
+
+	err := testStore.ExpireUserVerifyEmails(context.Background(), verifyEmail.Username)
+	require.NoError(t, err)
+
+	// the older code can't be used anymore
+	_, err = testStore.UpdateVerifyEmail(context.Background(), UpdateVerifyEmailParams{
+		ID:         verifyEmail.ID,
+		SecretCode: verifyEmail.SecretCode,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,81 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Backends that can be chosen with the LOGIN_LOCKOUT_BACKEND config
+const (
+	BackendPostgres = "postgres" // shared by every instance of the API
+	BackendMemory   = "memory"   // local to one instance, lost on restart
+)
+
+// Kinds of key the failed logins are counted for
+const (
+	KindUsername = "username"
+	KindIP       = "ip"
+)
+
+// Attempts are the consecutive failed logins of a username or a client IP
+type Attempts struct {
+	Kind         string    `json:"kind"`
+	Value        string    `json:"value"`
+	Failures     int       `json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+}
+
+// Limit is when the failed logins of a key block its next attempt
+type Limit struct {
+	MaxFailures     int           // failures locking the key out
+	BackoffBase     time.Duration // wait after the first failure, doubled after each next one, none when zero
+	LockoutDuration time.Duration // length of a lockout, failures older than it are forgotten
+}
+
+// blockedUntil works out until when the failed logins block the key, zero when they don't
+func (limit Limit) blockedUntil(attempts Attempts) time.Time {
+	if attempts.Failures <= 0 {
+		return time.Time{}
+	}
+
+	if attempts.Failures >= limit.MaxFailures {
+		return attempts.LastFailedAt.Add(limit.LockoutDuration)
+	}
+	if limit.BackoffBase <= 0 {
+		return time.Time{}
+	}
+	return attempts.LastFailedAt.Add(limit.backoff(attempts.Failures))
+}
+
+// backoff is the wait after the given number of failures, never longer than a lockout
+func (limit Limit) backoff(failures int) time.Duration {
+	wait := limit.BackoffBase
+	for i := 1; i < failures && wait < limit.LockoutDuration; i++ {
+		wait *= 2
+	}
+
+	if wait > limit.LockoutDuration {
+		return limit.LockoutDuration
+	}
+	return wait
+}
+
+// Backend stores the failed login counters
+type Backend interface {
+	// CountAttempt counts an attempt of the key as a failed login unless the limit blocks it, in a single step
+	// so that concurrent attempts can't all get through before the first one is counted.
+	// When the key is blocked, it returns false and its failures unchanged.
+	CountAttempt(ctx context.Context, kind, value string, limit Limit, attemptedAt time.Time) (Attempts, bool, error)
+
+	// UncountAttempt takes back an attempt counted by CountAttempt once the login turned out right
+	UncountAttempt(ctx context.Context, kind, value string) error
+
+	// GetAttempts returns the failed logins of the key, no failures when it has none
+	GetAttempts(ctx context.Context, kind, value string) (Attempts, error)
+
+	// ListAttempts returns every key whose last failed login is not before since, latest first
+	ListAttempts(ctx context.Context, since time.Time) ([]Attempts, error)
+
+	// ClearAttempts forgets the failed logins of the key
+	ClearAttempts(ctx context.Context, kind, value string) error
+}
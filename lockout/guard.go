@@ -0,0 +1,186 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrTooManyAttempts = errors.New("too many failed login attempts, try again later")
+
+// Policy sets how failed logins slow down and lock out a username or a client IP
+type Policy struct {
+	MaxUsernameFailures int           // consecutive failures locking a username out
+	MaxIPFailures       int           // consecutive failures locking a client IP out, higher as users share IPs
+	BackoffBase         time.Duration // wait after the first failure of a username, doubled after each next one
+	LockoutDuration     time.Duration // length of a lockout, failures older than it are forgotten
+}
+
+// DefaultPolicy fills the fields of a Policy that are not set
+var DefaultPolicy = Policy{
+	MaxUsernameFailures: 5,
+	MaxIPFailures:       20,
+	BackoffBase:         time.Second,
+	LockoutDuration:     15 * time.Minute,
+}
+
+// Lockout is a username or a client IP that may not try to log in before BlockedUntil
+type Lockout struct {
+	Attempts
+	Locked       bool      `json:"locked"` // false while only backing off
+	BlockedUntil time.Time `json:"blocked_until"`
+}
+
+// Guard counts the failed logins of a Backend and decides when a login may be tried again.
+// A username backs off exponentially until it gets locked out, a client IP only gets locked out.
+// Failures are counted whether the username exists or not, so the lockout tells nothing about it.
+type Guard struct {
+	backend Backend
+	policy  Policy
+}
+
+// NewGuard creates a Guard on top of the backend, the DefaultPolicy fills the fields of policy that are not positive
+func NewGuard(backend Backend, policy Policy) *Guard {
+	if policy.MaxUsernameFailures <= 0 {
+		policy.MaxUsernameFailures = DefaultPolicy.MaxUsernameFailures
+	}
+	if policy.MaxIPFailures <= 0 {
+		policy.MaxIPFailures = DefaultPolicy.MaxIPFailures
+	}
+	if policy.BackoffBase <= 0 {
+		policy.BackoffBase = DefaultPolicy.BackoffBase
+	}
+	if policy.LockoutDuration <= 0 {
+		policy.LockoutDuration = DefaultPolicy.LockoutDuration
+	}
+
+	return &Guard{
+		backend: backend,
+		policy:  policy,
+	}
+}
+
+// Attempt counts a login of the username from the client IP as failed before it is tried,
+// or returns ErrTooManyAttempts and how long to wait when either of them may not try yet.
+// Each counter is checked and increased in a single step of the backend, so a burst of logins can't skip the backoff.
+// The attempt is taken back by RegisterSuccess or Release once the credentials turn out right.
+func (guard *Guard) Attempt(ctx context.Context, username, clientIP string) (time.Duration, error) {
+	now := time.Now()
+	var counted []attemptsKey
+
+	for _, key := range guard.keys(username, clientIP) {
+		attempts, ok, err := guard.backend.CountAttempt(ctx, key.kind, key.value, guard.limit(key.kind), now)
+		if err == nil && ok {
+			counted = append(counted, key)
+			continue
+		}
+
+		// the counters already increased are not held against a login that isn't tried
+		for _, key := range counted {
+			if uncountErr := guard.backend.UncountAttempt(ctx, key.kind, key.value); uncountErr != nil && err == nil {
+				err = uncountErr
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		retryAfter := guard.newLockout(attempts).BlockedUntil.Sub(now)
+		if retryAfter < time.Second {
+			retryAfter = time.Second
+		}
+		return retryAfter, ErrTooManyAttempts
+	}
+	return 0, nil
+}
+
+// RegisterSuccess forgets the failed logins of the username and takes back the attempt counted for the client IP.
+// The earlier failures of the client IP are kept, or an attacker could reset its counter by logging into an account of its own.
+func (guard *Guard) RegisterSuccess(ctx context.Context, username, clientIP string) error {
+	err := guard.backend.ClearAttempts(ctx, KindUsername, username)
+	if err != nil {
+		return err
+	}
+
+	if clientIP != "" {
+		return guard.backend.UncountAttempt(ctx, KindIP, clientIP)
+	}
+	return nil
+}
+
+// Release takes back the attempt counted for the username and the client IP without forgetting their earlier failures,
+// as when the password is right but the login waits for a second factor
+func (guard *Guard) Release(ctx context.Context, username, clientIP string) error {
+	for _, key := range guard.keys(username, clientIP) {
+		err := guard.backend.UncountAttempt(ctx, key.kind, key.value)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Lockouts lists the usernames and client IPs that may not try to log in yet, latest failure first
+func (guard *Guard) Lockouts(ctx context.Context) ([]Lockout, error) {
+	now := time.Now()
+
+	list, err := guard.backend.ListAttempts(ctx, now.Add(-guard.policy.LockoutDuration))
+	if err != nil {
+		return nil, err
+	}
+
+	lockouts := []Lockout{}
+	for _, attempts := range list {
+		lockout := guard.newLockout(attempts)
+		if lockout.BlockedUntil.After(now) {
+			lockouts = append(lockouts, lockout)
+		}
+	}
+	return lockouts, nil
+}
+
+// Clear ends the lockout of a username or a client IP and forgets its failed logins, which it returns
+func (guard *Guard) Clear(ctx context.Context, kind, value string) (Attempts, error) {
+	attempts, err := guard.backend.GetAttempts(ctx, kind, value)
+	if err != nil {
+		return Attempts{}, err
+	}
+
+	return attempts, guard.backend.ClearAttempts(ctx, kind, value)
+}
+
+// newLockout works out until when the failed logins block the key
+func (guard *Guard) newLockout(attempts Attempts) Lockout {
+	limit := guard.limit(attempts.Kind)
+
+	return Lockout{
+		Attempts:     attempts,
+		Locked:       attempts.Failures >= limit.MaxFailures,
+		BlockedUntil: limit.blockedUntil(attempts),
+	}
+}
+
+// limit returns the Limit of the policy for a kind of key, a client IP only gets locked out
+func (guard *Guard) limit(kind string) Limit {
+	if kind == KindIP {
+		return Limit{
+			MaxFailures:     guard.policy.MaxIPFailures,
+			LockoutDuration: guard.policy.LockoutDuration,
+		}
+	}
+
+	return Limit{
+		MaxFailures:     guard.policy.MaxUsernameFailures,
+		BackoffBase:     guard.policy.BackoffBase,
+		LockoutDuration: guard.policy.LockoutDuration,
+	}
+}
+
+// keys returns the counters of the username and of the client IP when it is known
+func (guard *Guard) keys(username, clientIP string) []attemptsKey {
+	keys := []attemptsKey{{kind: KindUsername, value: username}}
+	if clientIP != "" {
+		keys = append(keys, attemptsKey{kind: KindIP, value: clientIP})
+	}
+	return keys
+}
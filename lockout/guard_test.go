@@ -0,0 +1,237 @@
+package lockout
+
+import (
+	"context"
+	"math"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = Policy{
+	MaxUsernameFailures: 3,
+	MaxIPFailures:       5,
+	BackoffBase:         time.Minute,
+	LockoutDuration:     time.Hour,
+}
+
+// failLogin counts a failed login of the username from the client IP, whatever the backoff or lockout
+func failLogin(t *testing.T, guard *Guard, username, clientIP string) {
+	noLimit := Limit{MaxFailures: math.MaxInt, LockoutDuration: time.Hour}
+
+	for _, key := range guard.keys(username, clientIP) {
+		_, ok, err := guard.backend.CountAttempt(context.Background(), key.kind, key.value, noLimit, time.Now())
+		require.NoError(t, err)
+		require.True(t, ok)
+	}
+}
+
+func TestGuardBackoff(t *testing.T) {
+	guard := NewGuard(NewMemoryBackend(), testPolicy)
+	username := util.RandomOwner()
+
+	_, err := guard.Attempt(context.Background(), username, "")
+	require.NoError(t, err)
+
+	// the attempt counts as a failure until the login turns out right
+	retryAfter, err := guard.Attempt(context.Background(), username, "")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	require.InDelta(t, time.Minute, retryAfter, float64(time.Second))
+
+	failLogin(t, guard, username, "")
+	retryAfter, err = guard.Attempt(context.Background(), username, "")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	require.InDelta(t, 2*time.Minute, retryAfter, float64(time.Second))
+
+	// the refused attempts are not counted
+	attempts, err := guard.backend.GetAttempts(context.Background(), KindUsername, username)
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts.Failures)
+
+	// other usernames are not slowed down
+	_, err = guard.Attempt(context.Background(), util.RandomOwner(), "")
+	require.NoError(t, err)
+}
+
+func TestGuardConcurrentAttempts(t *testing.T) {
+	guard := NewGuard(NewMemoryBackend(), testPolicy)
+	username := util.RandomOwner()
+
+	n := 20
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := guard.Attempt(context.Background(), username, "")
+			errs <- err
+		}()
+	}
+
+	// a burst gets a single attempt through, the others are backing off already
+	allowed := 0
+	for i := 0; i < n; i++ {
+		err := <-errs
+		if err == nil {
+			allowed++
+			continue
+		}
+		require.ErrorIs(t, err, ErrTooManyAttempts)
+	}
+	require.Equal(t, 1, allowed)
+}
+
+func TestGuardLockout(t *testing.T) {
+	guard := NewGuard(NewMemoryBackend(), testPolicy)
+	username := util.RandomOwner()
+
+	for i := 0; i < testPolicy.MaxUsernameFailures; i++ {
+		failLogin(t, guard, username, "")
+	}
+
+	retryAfter, err := guard.Attempt(context.Background(), username, "")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	require.InDelta(t, time.Hour, retryAfter, float64(time.Second))
+
+	lockouts, err := guard.Lockouts(context.Background())
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	require.Equal(t, KindUsername, lockouts[0].Kind)
+	require.Equal(t, username, lockouts[0].Value)
+	require.Equal(t, testPolicy.MaxUsernameFailures, lockouts[0].Failures)
+	require.True(t, lockouts[0].Locked)
+
+	attempts, err := guard.Clear(context.Background(), KindUsername, username)
+	require.NoError(t, err)
+	require.Equal(t, testPolicy.MaxUsernameFailures, attempts.Failures)
+
+	_, err = guard.Attempt(context.Background(), username, "")
+	require.NoError(t, err)
+	require.NoError(t, guard.RegisterSuccess(context.Background(), username, ""))
+
+	lockouts, err = guard.Lockouts(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, lockouts)
+}
+
+func TestGuardIPLockout(t *testing.T) {
+	guard := NewGuard(NewMemoryBackend(), testPolicy)
+	clientIP := "203.0.113.7"
+
+	// one failure per username, none of them backs off for long but the IP adds up
+	for i := 0; i < testPolicy.MaxIPFailures-1; i++ {
+		failLogin(t, guard, util.RandomOwner(), clientIP)
+	}
+
+	_, err := guard.Attempt(context.Background(), util.RandomOwner(), clientIP)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	retryAfter, err := guard.Attempt(context.Background(), username, clientIP)
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	require.InDelta(t, time.Hour, retryAfter, float64(time.Second))
+
+	// the username of the refused attempt is not held responsible
+	attempts, err := guard.backend.GetAttempts(context.Background(), KindUsername, username)
+	require.NoError(t, err)
+	require.Zero(t, attempts.Failures)
+
+	// the same username from another IP may go on
+	_, err = guard.Attempt(context.Background(), username, "198.51.100.1")
+	require.NoError(t, err)
+}
+
+func TestGuardRegisterSuccess(t *testing.T) {
+	guard := NewGuard(NewMemoryBackend(), testPolicy)
+	username := util.RandomOwner()
+	clientIP := "203.0.113.8"
+
+	failLogin(t, guard, util.RandomOwner(), clientIP)
+	_, err := guard.Attempt(context.Background(), username, clientIP)
+	require.NoError(t, err)
+	require.NoError(t, guard.RegisterSuccess(context.Background(), username, clientIP))
+
+	_, err = guard.Attempt(context.Background(), username, "")
+	require.NoError(t, err)
+
+	// the attempt is taken back from the IP, its earlier failure is still counted
+	attempts, err := guard.backend.GetAttempts(context.Background(), KindIP, clientIP)
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts.Failures)
+}
+
+func TestGuardRelease(t *testing.T) {
+	guard := NewGuard(NewMemoryBackend(), Policy{BackoffBase: time.Nanosecond})
+	username := util.RandomOwner()
+	clientIP := "203.0.113.9"
+
+	failLogin(t, guard, username, clientIP)
+	time.Sleep(time.Millisecond) // past the backoff
+	_, err := guard.Attempt(context.Background(), username, clientIP)
+	require.NoError(t, err)
+	require.NoError(t, guard.Release(context.Background(), username, clientIP))
+
+	// only the earlier failure is left on both counters
+	for _, key := range guard.keys(username, clientIP) {
+		attempts, err := guard.backend.GetAttempts(context.Background(), key.kind, key.value)
+		require.NoError(t, err)
+		require.Equal(t, 1, attempts.Failures)
+	}
+}
+
+func TestLimitBackoffIsCapped(t *testing.T) {
+	limit := Limit{MaxFailures: 100, BackoffBase: time.Second, LockoutDuration: time.Minute}
+
+	require.Equal(t, time.Second, limit.backoff(1))
+	require.Equal(t, 8*time.Second, limit.backoff(4))
+	require.Equal(t, time.Minute, limit.backoff(7))
+	require.Equal(t, time.Minute, limit.backoff(99))
+}
+
+func TestMemoryBackendCountAttempt(t *testing.T) {
+	backend := NewMemoryBackend()
+	username := util.RandomOwner()
+	limit := Limit{MaxFailures: 3, BackoffBase: time.Minute, LockoutDuration: time.Hour}
+	now := time.Now()
+
+	attempts, ok, err := backend.CountAttempt(context.Background(), KindUsername, username, limit, now)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 1, attempts.Failures)
+
+	attempts, ok, err = backend.CountAttempt(context.Background(), KindUsername, username, limit, now.Add(30*time.Second))
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 1, attempts.Failures)
+
+	attempts, ok, err = backend.CountAttempt(context.Background(), KindUsername, username, limit, now.Add(time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 2, attempts.Failures)
+
+	require.NoError(t, backend.UncountAttempt(context.Background(), KindUsername, username))
+	require.NoError(t, backend.UncountAttempt(context.Background(), KindUsername, username))
+	attempts, err = backend.GetAttempts(context.Background(), KindUsername, username)
+	require.NoError(t, err)
+	require.Zero(t, attempts.Failures)
+}
+
+func TestMemoryBackendResetsStaleFailures(t *testing.T) {
+	backend := NewMemoryBackend()
+	username := util.RandomOwner()
+	limit := Limit{MaxFailures: 100, LockoutDuration: time.Hour}
+	now := time.Now()
+
+	attempts, _, err := backend.CountAttempt(context.Background(), KindUsername, username, limit, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts.Failures)
+
+	attempts, _, err = backend.CountAttempt(context.Background(), KindUsername, username, limit, now)
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts.Failures)
+
+	// the last failure is older than the lockout duration, counting starts over
+	attempts, _, err = backend.CountAttempt(context.Background(), KindUsername, username, limit, now.Add(time.Hour+time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, attempts.Failures)
+}
@@ -0,0 +1,119 @@
+package lockout
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// attemptsKey identifies the counter of a username or a client IP
+type attemptsKey struct {
+	kind  string
+	value string
+}
+
+// MemoryBackend keeps the counters in memory, it only suits a single instance of the API
+type MemoryBackend struct {
+	mu       sync.RWMutex
+	attempts map[attemptsKey]Attempts
+}
+
+// NewMemoryBackend creates an empty MemoryBackend
+func NewMemoryBackend() Backend {
+	return &MemoryBackend{
+		attempts: make(map[attemptsKey]Attempts),
+	}
+}
+
+// CountAttempt counts an attempt of the key as a failed login unless the limit blocks it, under a single lock
+func (backend *MemoryBackend) CountAttempt(ctx context.Context, kind, value string, limit Limit, attemptedAt time.Time) (Attempts, bool, error) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	resetBefore := attemptedAt.Add(-limit.LockoutDuration)
+	backend.dropStale(resetBefore)
+
+	key := attemptsKey{kind: kind, value: value}
+	attempts, ok := backend.attempts[key]
+	if !ok {
+		attempts = Attempts{Kind: kind, Value: value}
+	}
+
+	if limit.blockedUntil(attempts).After(attemptedAt) {
+		return attempts, false, nil
+	}
+
+	attempts.Failures++
+	attempts.LastFailedAt = attemptedAt
+	backend.attempts[key] = attempts
+	return attempts, true, nil
+}
+
+// UncountAttempt takes back an attempt counted by CountAttempt, forgetting the key when no failure is left
+func (backend *MemoryBackend) UncountAttempt(ctx context.Context, kind, value string) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	key := attemptsKey{kind: kind, value: value}
+	attempts, ok := backend.attempts[key]
+	if !ok {
+		return nil
+	}
+
+	attempts.Failures--
+	if attempts.Failures <= 0 {
+		delete(backend.attempts, key)
+		return nil
+	}
+	backend.attempts[key] = attempts
+	return nil
+}
+
+// GetAttempts returns the failed logins of the key, no failures when it has none
+func (backend *MemoryBackend) GetAttempts(ctx context.Context, kind, value string) (Attempts, error) {
+	backend.mu.RLock()
+	defer backend.mu.RUnlock()
+
+	attempts, ok := backend.attempts[attemptsKey{kind: kind, value: value}]
+	if !ok {
+		return Attempts{Kind: kind, Value: value}, nil
+	}
+	return attempts, nil
+}
+
+// ListAttempts returns every key whose last failed login is not before since, latest first
+func (backend *MemoryBackend) ListAttempts(ctx context.Context, since time.Time) ([]Attempts, error) {
+	backend.mu.RLock()
+	defer backend.mu.RUnlock()
+
+	list := []Attempts{}
+	for _, attempts := range backend.attempts {
+		if !attempts.LastFailedAt.Before(since) {
+			list = append(list, attempts)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LastFailedAt.After(list[j].LastFailedAt)
+	})
+	return list, nil
+}
+
+// ClearAttempts forgets the failed logins of the key
+func (backend *MemoryBackend) ClearAttempts(ctx context.Context, kind, value string) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	delete(backend.attempts, attemptsKey{kind: kind, value: value})
+	return nil
+}
+
+// dropStale deletes the counters whose last failed login is before resetBefore, the lock must be held
+func (backend *MemoryBackend) dropStale(resetBefore time.Time) {
+	for key, attempts := range backend.attempts {
+		if attempts.LastFailedAt.Before(resetBefore) {
+			delete(backend.attempts, key)
+		}
+	}
+}
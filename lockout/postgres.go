@@ -0,0 +1,107 @@
+package lockout
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// PostgresBackend keeps the counters in the login_attempts table, so that every instance of the API sees them
+type PostgresBackend struct {
+	store db.Store
+}
+
+// NewPostgresBackend creates a PostgresBackend on top of the store
+func NewPostgresBackend(store db.Store) Backend {
+	return &PostgresBackend{
+		store: store,
+	}
+}
+
+// CountAttempt counts an attempt of the key as a failed login unless the limit blocks it.
+// The check and the count are a single upsert, whose row lock serializes the concurrent attempts.
+func (backend *PostgresBackend) CountAttempt(ctx context.Context, kind, value string, limit Limit, attemptedAt time.Time) (Attempts, bool, error) {
+	resetBefore := attemptedAt.Add(-limit.LockoutDuration)
+
+	attempt, err := backend.store.CountLoginAttempt(ctx, db.CountLoginAttemptParams{
+		Kind:              kind,
+		Value:             value,
+		AttemptedAt:       attemptedAt,
+		ResetBefore:       resetBefore,
+		MaxFailures:       int32(limit.MaxFailures),
+		LockoutDurationMs: limit.LockoutDuration.Milliseconds(),
+		BackoffBaseMs:     limit.BackoffBase.Milliseconds(),
+	})
+	if err != nil {
+		// no row is returned when the key is blocked
+		if errors.Is(err, db.ErrRecordNotFound) {
+			attempts, err := backend.GetAttempts(ctx, kind, value)
+			return attempts, false, err
+		}
+		return Attempts{}, false, err
+	}
+
+	err = backend.store.DeleteStaleLoginAttempts(ctx, resetBefore)
+	if err != nil {
+		return Attempts{}, false, err
+	}
+
+	return newAttempts(attempt), true, nil
+}
+
+// UncountAttempt takes back an attempt counted by CountAttempt
+func (backend *PostgresBackend) UncountAttempt(ctx context.Context, kind, value string) error {
+	return backend.store.UncountLoginAttempt(ctx, db.UncountLoginAttemptParams{
+		Kind:  kind,
+		Value: value,
+	})
+}
+
+// GetAttempts returns the failed logins of the key, no failures when it has no row
+func (backend *PostgresBackend) GetAttempts(ctx context.Context, kind, value string) (Attempts, error) {
+	attempt, err := backend.store.GetLoginAttempt(ctx, db.GetLoginAttemptParams{
+		Kind:  kind,
+		Value: value,
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return Attempts{Kind: kind, Value: value}, nil
+		}
+		return Attempts{}, err
+	}
+
+	return newAttempts(attempt), nil
+}
+
+// ListAttempts returns every key whose last failed login is not before since, latest first
+func (backend *PostgresBackend) ListAttempts(ctx context.Context, since time.Time) ([]Attempts, error) {
+	rows, err := backend.store.ListLoginAttempts(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]Attempts, len(rows))
+	for i, row := range rows {
+		list[i] = newAttempts(row)
+	}
+	return list, nil
+}
+
+// ClearAttempts deletes the row of the key
+func (backend *PostgresBackend) ClearAttempts(ctx context.Context, kind, value string) error {
+	return backend.store.DeleteLoginAttempt(ctx, db.DeleteLoginAttemptParams{
+		Kind:  kind,
+		Value: value,
+	})
+}
+
+// newAttempts converts a login_attempts row
+func newAttempts(attempt db.LoginAttempt) Attempts {
+	return Attempts{
+		Kind:         attempt.Kind,
+		Value:        attempt.Value,
+		Failures:     int(attempt.Failures),
+		LastFailedAt: attempt.LastFailedAt,
+	}
+}
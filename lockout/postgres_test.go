@@ -0,0 +1,166 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresBackendGetAttempts(t *testing.T) {
+	username := util.RandomOwner()
+	lastFailedAt := time.Now().Truncate(time.Microsecond)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, attempts Attempts, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{Kind: KindUsername, Value: username})).
+					Times(1).
+					Return(db.LoginAttempt{Kind: KindUsername, Value: username, Failures: 3, LastFailedAt: lastFailedAt}, nil)
+			},
+			check: func(t *testing.T, attempts Attempts, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Attempts{Kind: KindUsername, Value: username, Failures: 3, LastFailedAt: lastFailedAt}, attempts)
+			},
+		},
+		{
+			name: "NoFailures",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginAttempt{}, db.ErrRecordNotFound)
+			},
+			check: func(t *testing.T, attempts Attempts, err error) {
+				require.NoError(t, err)
+				require.Equal(t, Attempts{Kind: KindUsername, Value: username}, attempts)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginAttempt{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, attempts Attempts, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			attempts, err := NewPostgresBackend(store).GetAttempts(context.Background(), KindUsername, username)
+			tc.check(t, attempts, err)
+		})
+	}
+}
+
+func TestPostgresBackendCountAttempt(t *testing.T) {
+	username := util.RandomOwner()
+	attemptedAt := time.Now()
+	limit := Limit{MaxFailures: 3, BackoffBase: time.Second, LockoutDuration: time.Hour}
+
+	arg := db.CountLoginAttemptParams{
+		Kind:              KindUsername,
+		Value:             username,
+		AttemptedAt:       attemptedAt,
+		ResetBefore:       attemptedAt.Add(-time.Hour),
+		MaxFailures:       3,
+		LockoutDurationMs: time.Hour.Milliseconds(),
+		BackoffBaseMs:     time.Second.Milliseconds(),
+	}
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, attempts Attempts, ok bool, err error)
+	}{
+		{
+			name: "Counted",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountLoginAttempt(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.LoginAttempt{Kind: KindUsername, Value: username, Failures: 2, LastFailedAt: attemptedAt}, nil)
+				store.EXPECT().
+					DeleteStaleLoginAttempts(gomock.Any(), gomock.Eq(arg.ResetBefore)).
+					Times(1)
+			},
+			check: func(t *testing.T, attempts Attempts, ok bool, err error) {
+				require.NoError(t, err)
+				require.True(t, ok)
+				require.Equal(t, 2, attempts.Failures)
+			},
+		},
+		{
+			name: "Blocked",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountLoginAttempt(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.LoginAttempt{}, db.ErrRecordNotFound)
+				store.EXPECT().
+					GetLoginAttempt(gomock.Any(), gomock.Eq(db.GetLoginAttemptParams{Kind: KindUsername, Value: username})).
+					Times(1).
+					Return(db.LoginAttempt{Kind: KindUsername, Value: username, Failures: 3, LastFailedAt: attemptedAt}, nil)
+				store.EXPECT().DeleteStaleLoginAttempts(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, attempts Attempts, ok bool, err error) {
+				require.NoError(t, err)
+				require.False(t, ok)
+				require.Equal(t, 3, attempts.Failures)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CountLoginAttempt(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.LoginAttempt{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, attempts Attempts, ok bool, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.False(t, ok)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			attempts, ok, err := NewPostgresBackend(store).CountAttempt(context.Background(), KindUsername, username, limit, attemptedAt)
+			tc.check(t, attempts, ok, err)
+		})
+	}
+}
@@ -2,7 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"simplebank/api"
 	db "simplebank/db/sqlc"
@@ -15,6 +20,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	_ "github.com/lib/pq"
 	logz "github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
 )
 
 func main() {
@@ -23,7 +29,25 @@ func main() {
 		log.Fatal("cannot load configurations:", err)
 	}
 
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
+	if config.CurrenciesFile != "" {
+		err = util.LoadCurrencies(config.CurrenciesFile)
+		if err != nil {
+			log.Fatal("cannot load currencies:", err)
+		}
+	}
+
+	if config.RolesFile != "" {
+		err = util.LoadRoles(config.RolesFile)
+		if err != nil {
+			log.Fatal("cannot load roles:", err)
+		}
+	}
+
+	// cancelled on shutdown, the background routines stop with it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	connPool, err := pgxpool.New(ctx, config.DBSource)
 	if err != nil {
 		log.Fatal("cannot connect to the DB:", err)
 	}
@@ -36,23 +60,48 @@ func main() {
 	}
 	taskDistributor := worker.NewRedisTaskDistributor(redisOpt)
 
-	go runTaskProcessor(config, redisOpt, store)
-
-	// start http Server
+	// create http Server
 	server, err := api.NewServer(config, store, taskDistributor)
 	if err != nil {
 		log.Fatal("cannot create the server:", err)
 	}
 
-	err = server.Start(config.ServerAddress)
+	// each routine stops once ctx is done, main returns when all of them have finished their work in flight
+	group, ctx := errgroup.WithContext(ctx)
+
+	group.Go(func() error {
+		return runTaskProcessor(ctx, config, redisOpt, store)
+	})
+
+	// a task the relay claimed but could not mark sent before stopping is claimed again once its lease is over
+	group.Go(func() error {
+		runOutboxRelay(ctx, config, store, taskDistributor)
+		return nil
+	})
+
+	group.Go(func() error {
+		worker.NewIdempotencyKeyCleaner(store, time.Hour).Start(ctx)
+		return nil
+	})
+
+	group.Go(func() error {
+		logz.Info().Str("address", config.ServerAddress).Msg("start HTTP server")
+		err := server.Start(ctx, config.ServerAddress)
+		if err != nil {
+			return fmt.Errorf("HTTP server: %w", err)
+		}
+		logz.Info().Msg("HTTP server stopped")
+		return nil
+	})
+
+	err = group.Wait()
 	if err != nil {
-		log.Fatal("cannot start the server:", err)
+		log.Fatal("shut down on error:", err)
 	}
-
 }
 
-// REDIS queue
-func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store db.Store) {
+// REDIS queue, runs the task processor until ctx is done and waits for the tasks being processed
+func runTaskProcessor(ctx context.Context, config util.Config, redisOpt asynq.RedisClientOpt, store db.Store) error {
 	mailer := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
 
 	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailer)
@@ -61,6 +110,27 @@ func runTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store d
 
 	err := taskProcessor.Start()
 	if err != nil {
-		log.Fatal("cannot start the task processor:", err)
+		return fmt.Errorf("task processor: %w", err)
 	}
+
+	<-ctx.Done()
+	taskProcessor.Shutdown()
+	logz.Info().Msg("task processor stopped")
+	return nil
+}
+
+// moves the tasks written by the DB transactions to the REDIS queue
+func runOutboxRelay(ctx context.Context, config util.Config, store db.Store, taskDistributor worker.TaskDistributor) {
+	interval := config.OutboxRelayInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	retention := config.OutboxRetention
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
+
+	relay := worker.NewOutboxRelay(store, taskDistributor, interval, retention)
+	relay.Start(ctx)
 }
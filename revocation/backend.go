@@ -0,0 +1,29 @@
+package revocation
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Backends that can be chosen with the TOKEN_REVOCATION_BACKEND config
+const (
+	BackendPostgres = "postgres" // shared by every instance of the API
+	BackendMemory   = "memory"   // local to one instance, lost on restart
+)
+
+// Backend stores the denylist of access token IDs and the per-user watermarks
+type Backend interface {
+	// RevokeToken refuses the token ID until expiredAt, when the token is refused anyway
+	RevokeToken(ctx context.Context, tokenID uuid.UUID, username string, expiredAt time.Time) error
+
+	// IsTokenRevoked checks if the token ID is on the denylist
+	IsTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error)
+
+	// RevokeUserTokens refuses every token of the user issued before revokedAt
+	RevokeUserTokens(ctx context.Context, username string, revokedAt time.Time) error
+
+	// UserTokensRevokedAt returns the time before which the tokens of the user are refused
+	UserTokensRevokedAt(ctx context.Context, username string) (time.Time, error)
+}
@@ -0,0 +1,184 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"simplebank/token"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCacheTTL is how long a List trusts what it read from its backend
+const DefaultCacheTTL = 5 * time.Second
+
+// revokedTokenRetention is how long a token ID stays on the denylist after the token expired,
+// longer than the clock skew any token maker tolerates
+const revokedTokenRetention = time.Hour
+
+var ErrRevokedToken = errors.New("token has been revoked")
+
+// cachedToken is what a List knows about a token ID
+type cachedToken struct {
+	revoked bool
+	until   time.Time
+}
+
+// cachedWatermark is what a List knows about the watermark of a user
+type cachedWatermark struct {
+	revokedAt time.Time
+	until     time.Time
+}
+
+// List checks access tokens against the revocations of a Backend.
+// Answers are cached locally for cacheTTL, so a revocation made by another instance of the API
+// takes up to cacheTTL to be seen, while a revocation made through the List is seen at once.
+type List struct {
+	backend  Backend
+	cacheTTL time.Duration
+
+	mu         sync.Mutex
+	tokens     map[uuid.UUID]cachedToken
+	users      map[string]cachedWatermark
+	lastPruned time.Time
+}
+
+// NewList creates a List on top of the backend, DefaultCacheTTL is used when cacheTTL is not positive
+func NewList(backend Backend, cacheTTL time.Duration) *List {
+	if cacheTTL <= 0 {
+		cacheTTL = DefaultCacheTTL
+	}
+
+	return &List{
+		backend:    backend,
+		cacheTTL:   cacheTTL,
+		tokens:     make(map[uuid.UUID]cachedToken),
+		users:      make(map[string]cachedWatermark),
+		lastPruned: time.Now(),
+	}
+}
+
+// RevokeToken refuses the token until it expires, e.g. the access token of a logout
+func (list *List) RevokeToken(ctx context.Context, payload *token.Payload) error {
+	until := payload.ExpiredAt.Add(revokedTokenRetention)
+
+	err := list.backend.RevokeToken(ctx, payload.ID, payload.Username, until)
+	if err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	// a revoked token stays revoked, no need to ask the backend again
+	list.tokens[payload.ID] = cachedToken{revoked: true, until: until}
+	return nil
+}
+
+// RevokeUserTokens refuses every token of the user issued until now, e.g. after a password change
+func (list *List) RevokeUserTokens(ctx context.Context, username string) error {
+	revokedAt := time.Now()
+
+	err := list.backend.RevokeUserTokens(ctx, username, revokedAt)
+	if err != nil {
+		return err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	delete(list.users, username)
+	return nil
+}
+
+// Check returns ErrRevokedToken when the token ID is on the denylist
+// or when the token was not issued after the watermark of its user.
+// A token whose issue time was truncated, e.g. the whole seconds of a JWT, is compared to the watermark
+// truncated the same way, so a token issued in the second of the revocation is refused as well.
+func (list *List) Check(ctx context.Context, payload *token.Payload) error {
+	revoked, err := list.isTokenRevoked(ctx, payload.ID)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrRevokedToken
+	}
+
+	revokedAt, err := list.userTokensRevokedAt(ctx, payload.Username)
+	if err != nil {
+		return err
+	}
+	if !payload.IssuedAt.After(revokedAt.Truncate(payload.IssuedAtPrecision)) {
+		return ErrRevokedToken
+	}
+
+	return nil
+}
+
+// isTokenRevoked asks the backend about the token ID unless the cache knows
+func (list *List) isTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	now := time.Now()
+
+	list.mu.Lock()
+	cached, ok := list.tokens[tokenID]
+	list.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.revoked, nil
+	}
+
+	revoked, err := list.backend.IsTokenRevoked(ctx, tokenID)
+	if err != nil {
+		return false, err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.prune(now)
+	list.tokens[tokenID] = cachedToken{revoked: revoked, until: now.Add(list.cacheTTL)}
+	return revoked, nil
+}
+
+// userTokensRevokedAt asks the backend about the watermark of the user unless the cache knows
+func (list *List) userTokensRevokedAt(ctx context.Context, username string) (time.Time, error) {
+	now := time.Now()
+
+	list.mu.Lock()
+	cached, ok := list.users[username]
+	list.mu.Unlock()
+	if ok && now.Before(cached.until) {
+		return cached.revokedAt, nil
+	}
+
+	revokedAt, err := list.backend.UserTokensRevokedAt(ctx, username)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	list.mu.Lock()
+	defer list.mu.Unlock()
+
+	list.prune(now)
+	list.users[username] = cachedWatermark{revokedAt: revokedAt, until: now.Add(list.cacheTTL)}
+	return revokedAt, nil
+}
+
+// prune drops the stale cache entries, at most once per cacheTTL. The caller must hold list.mu.
+func (list *List) prune(now time.Time) {
+	if now.Sub(list.lastPruned) < list.cacheTTL {
+		return
+	}
+
+	for id, cached := range list.tokens {
+		if !now.Before(cached.until) {
+			delete(list.tokens, id)
+		}
+	}
+	for username, cached := range list.users {
+		if !now.Before(cached.until) {
+			delete(list.users, username)
+		}
+	}
+	list.lastPruned = now
+}
@@ -0,0 +1,126 @@
+package revocation
+
+import (
+	"context"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomPayload(t *testing.T, username string) *token.Payload {
+	payload, err := token.NewPayload(username, util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	return payload
+}
+
+func TestListRevokeToken(t *testing.T) {
+	list := NewList(NewMemoryBackend(), time.Minute)
+	username := util.RandomOwner()
+
+	payload1 := randomPayload(t, username)
+	payload2 := randomPayload(t, username)
+
+	// cache the answer before the revocation, it must not hide it
+	require.NoError(t, list.Check(context.Background(), payload1))
+
+	err := list.RevokeToken(context.Background(), payload1)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, list.Check(context.Background(), payload1), ErrRevokedToken)
+	require.NoError(t, list.Check(context.Background(), payload2))
+}
+
+func TestListRevokeUserTokens(t *testing.T) {
+	list := NewList(NewMemoryBackend(), time.Minute)
+	username := util.RandomOwner()
+
+	before := randomPayload(t, username)
+	other := randomPayload(t, util.RandomOwner())
+	require.NoError(t, list.Check(context.Background(), before))
+
+	err := list.RevokeUserTokens(context.Background(), username)
+	require.NoError(t, err)
+
+	after := randomPayload(t, username)
+
+	require.ErrorIs(t, list.Check(context.Background(), before), ErrRevokedToken)
+	require.NoError(t, list.Check(context.Background(), after))
+	require.NoError(t, list.Check(context.Background(), other))
+}
+
+func TestListRevokeUserTokensJustAfter(t *testing.T) {
+	backend := NewMemoryBackend()
+	list := NewList(backend, time.Minute)
+	username := util.RandomOwner()
+
+	revokedAt := time.Now().Truncate(time.Second).Add(100 * time.Millisecond)
+	err := backend.RevokeUserTokens(context.Background(), username, revokedAt)
+	require.NoError(t, err)
+
+	// a token keeping its full issue time is compared at full precision
+	payload := randomPayload(t, username)
+	payload.IssuedAt = revokedAt
+	require.ErrorIs(t, list.Check(context.Background(), payload), ErrRevokedToken)
+
+	payload.IssuedAt = revokedAt.Add(time.Microsecond)
+	require.NoError(t, list.Check(context.Background(), payload))
+
+	// issued after the revocation but truncated to the same second, the token can't be told apart from an older one
+	payload.IssuedAt = revokedAt.Truncate(time.Second)
+	payload.IssuedAtPrecision = time.Second
+	require.ErrorIs(t, list.Check(context.Background(), payload), ErrRevokedToken)
+
+	payload.IssuedAt = revokedAt.Truncate(time.Second).Add(time.Second)
+	require.NoError(t, list.Check(context.Background(), payload))
+}
+
+func TestListRevokeUserTokensJWT(t *testing.T) {
+	maker, err := token.NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	backend := NewMemoryBackend()
+	list := NewList(backend, time.Minute)
+	username := util.RandomOwner()
+
+	// the issue time of a JWT is truncated to the second
+	accessToken, _, err := maker.CreateToken(username, util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(accessToken)
+	require.NoError(t, err)
+	require.Equal(t, payload.IssuedAt, payload.IssuedAt.Truncate(time.Second))
+
+	// a revocation in the previous second keeps the token valid
+	err = backend.RevokeUserTokens(context.Background(), username, payload.IssuedAt.Add(-time.Millisecond))
+	require.NoError(t, err)
+	require.NoError(t, list.Check(context.Background(), payload))
+
+	// a revocation in the second the token was issued refuses it
+	other := util.RandomOwner()
+	payload.Username = other
+	err = backend.RevokeUserTokens(context.Background(), other, payload.IssuedAt.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	require.ErrorIs(t, list.Check(context.Background(), payload), ErrRevokedToken)
+}
+
+func TestListSharedBackend(t *testing.T) {
+	// two instances of the API sharing one backend
+	backend := NewMemoryBackend()
+	list1 := NewList(backend, 50*time.Millisecond)
+	list2 := NewList(backend, 50*time.Millisecond)
+
+	payload := randomPayload(t, util.RandomOwner())
+	require.NoError(t, list2.Check(context.Background(), payload))
+
+	err := list1.RevokeToken(context.Background(), payload)
+	require.NoError(t, err)
+
+	// list2 trusts its cache until it expires
+	require.NoError(t, list2.Check(context.Background(), payload))
+
+	time.Sleep(60 * time.Millisecond)
+	require.ErrorIs(t, list2.Check(context.Background(), payload), ErrRevokedToken)
+}
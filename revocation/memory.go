@@ -0,0 +1,68 @@
+package revocation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryBackend keeps the revocations in memory, it only suits a single instance of the API
+type MemoryBackend struct {
+	mu     sync.RWMutex
+	tokens map[uuid.UUID]time.Time // token ID to its expiry
+	users  map[string]time.Time    // username to its watermark
+}
+
+// NewMemoryBackend creates an empty MemoryBackend
+func NewMemoryBackend() Backend {
+	return &MemoryBackend{
+		tokens: make(map[uuid.UUID]time.Time),
+		users:  make(map[string]time.Time),
+	}
+}
+
+// RevokeToken adds the token ID to the denylist and drops the tokens that have expired since
+func (backend *MemoryBackend) RevokeToken(ctx context.Context, tokenID uuid.UUID, username string, expiredAt time.Time) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	now := time.Now()
+	for id, tokenExpiredAt := range backend.tokens {
+		if tokenExpiredAt.Before(now) {
+			delete(backend.tokens, id)
+		}
+	}
+
+	backend.tokens[tokenID] = expiredAt
+	return nil
+}
+
+// IsTokenRevoked checks if the token ID is on the denylist
+func (backend *MemoryBackend) IsTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	backend.mu.RLock()
+	defer backend.mu.RUnlock()
+
+	_, ok := backend.tokens[tokenID]
+	return ok, nil
+}
+
+// RevokeUserTokens moves the watermark of the user forward, never back
+func (backend *MemoryBackend) RevokeUserTokens(ctx context.Context, username string, revokedAt time.Time) error {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+
+	if revokedAt.After(backend.users[username]) {
+		backend.users[username] = revokedAt
+	}
+	return nil
+}
+
+// UserTokensRevokedAt returns the watermark of the user, the zero time when none was set
+func (backend *MemoryBackend) UserTokensRevokedAt(ctx context.Context, username string) (time.Time, error) {
+	backend.mu.RLock()
+	defer backend.mu.RUnlock()
+
+	return backend.users[username], nil
+}
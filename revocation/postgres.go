@@ -0,0 +1,73 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresBackend keeps the revocations in the revoked_tokens table and users.tokens_revoked_at,
+// so that every instance of the API sees them
+type PostgresBackend struct {
+	store db.Store
+}
+
+// NewPostgresBackend creates a PostgresBackend on top of the store
+func NewPostgresBackend(store db.Store) Backend {
+	return &PostgresBackend{
+		store: store,
+	}
+}
+
+// RevokeToken adds the token ID to the denylist and deletes the rows of the tokens that have expired since
+func (backend *PostgresBackend) RevokeToken(ctx context.Context, tokenID uuid.UUID, username string, expiredAt time.Time) error {
+	err := backend.store.CreateRevokedToken(ctx, db.CreateRevokedTokenParams{
+		ID:        tokenID,
+		Username:  username,
+		ExpiredAt: expiredAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	return backend.store.DeleteExpiredRevokedTokens(ctx, time.Now())
+}
+
+// IsTokenRevoked checks if the token ID is on the denylist
+func (backend *PostgresBackend) IsTokenRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	return backend.store.IsTokenRevoked(ctx, tokenID)
+}
+
+// RevokeUserTokens moves users.tokens_revoked_at forward, never back.
+// Postgres keeps microseconds, so the watermark is rounded up to the next one rather than truncated before the revocation.
+func (backend *PostgresBackend) RevokeUserTokens(ctx context.Context, username string, revokedAt time.Time) error {
+	if rounded := revokedAt.Truncate(time.Microsecond); !rounded.Equal(revokedAt) {
+		revokedAt = rounded.Add(time.Microsecond)
+	}
+
+	return backend.store.RevokeUserTokens(ctx, db.RevokeUserTokensParams{
+		Username:        username,
+		TokensRevokedAt: revokedAt,
+	})
+}
+
+// UserTokensRevokedAt returns the latest of users.tokens_revoked_at and users.password_changed_at,
+// a password change refusing every token issued before it
+func (backend *PostgresBackend) UserTokensRevokedAt(ctx context.Context, username string) (time.Time, error) {
+	result, err := backend.store.GetUserTokensRevokedAt(ctx, username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			// the user doesn't exist (anymore), none of its tokens is accepted
+			return time.Now(), nil
+		}
+		return time.Time{}, err
+	}
+
+	if result.PasswordChangedAt.After(result.TokensRevokedAt) {
+		return result.PasswordChangedAt, nil
+	}
+	return result.TokensRevokedAt, nil
+}
@@ -0,0 +1,151 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresBackendUserTokensRevokedAt(t *testing.T) {
+	username := util.RandomOwner()
+	passwordChangedAt := time.Now().Add(-time.Hour).Truncate(time.Microsecond)
+	tokensRevokedAt := time.Now().Add(-time.Minute).Truncate(time.Microsecond)
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, revokedAt time.Time, err error)
+	}{
+		{
+			name: "TokensRevokedLast",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+					Times(1).
+					Return(db.GetUserTokensRevokedAtRow{PasswordChangedAt: passwordChangedAt, TokensRevokedAt: tokensRevokedAt}, nil)
+			},
+			check: func(t *testing.T, revokedAt time.Time, err error) {
+				require.NoError(t, err)
+				require.Equal(t, tokensRevokedAt, revokedAt)
+			},
+		},
+		{
+			name: "PasswordChangedLast",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+					Times(1).
+					Return(db.GetUserTokensRevokedAtRow{PasswordChangedAt: tokensRevokedAt, TokensRevokedAt: passwordChangedAt}, nil)
+			},
+			check: func(t *testing.T, revokedAt time.Time, err error) {
+				require.NoError(t, err)
+				require.Equal(t, tokensRevokedAt, revokedAt)
+			},
+		},
+		{
+			name: "UserNotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+					Times(1).
+					Return(db.GetUserTokensRevokedAtRow{}, db.ErrRecordNotFound)
+			},
+			check: func(t *testing.T, revokedAt time.Time, err error) {
+				require.NoError(t, err)
+				require.WithinDuration(t, time.Now(), revokedAt, time.Second)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(username)).
+					Times(1).
+					Return(db.GetUserTokensRevokedAtRow{}, sql.ErrConnDone)
+			},
+			check: func(t *testing.T, revokedAt time.Time, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			revokedAt, err := NewPostgresBackend(store).UserTokensRevokedAt(context.Background(), username)
+			tc.check(t, revokedAt, err)
+		})
+	}
+}
+
+func TestPostgresBackendRevokeUserTokens(t *testing.T) {
+	username := util.RandomOwner()
+	second := time.Now().Truncate(time.Second)
+
+	testCases := []struct {
+		name      string
+		revokedAt time.Time
+		stored    time.Time
+	}{
+		{
+			name:      "RoundedUp",
+			revokedAt: second.Add(1500 * time.Nanosecond),
+			stored:    second.Add(2 * time.Microsecond),
+		},
+		{
+			name:      "WholeMicrosecond",
+			revokedAt: second.Add(time.Microsecond),
+			stored:    second.Add(time.Microsecond),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			store.EXPECT().
+				RevokeUserTokens(gomock.Any(), gomock.Eq(db.RevokeUserTokensParams{Username: username, TokensRevokedAt: tc.stored})).
+				Times(1).
+				Return(nil)
+
+			err := NewPostgresBackend(store).RevokeUserTokens(context.Background(), username, tc.revokedAt)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestListCachesPostgresBackend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	payload := randomPayload(t, util.RandomOwner())
+
+	// the second check is answered by the cache
+	store.EXPECT().
+		IsTokenRevoked(gomock.Any(), gomock.Eq(payload.ID)).
+		Times(1).
+		Return(false, nil)
+	store.EXPECT().
+		GetUserTokensRevokedAt(gomock.Any(), gomock.Eq(payload.Username)).
+		Times(1).
+		Return(db.GetUserTokensRevokedAtRow{}, nil)
+
+	list := NewList(NewPostgresBackend(store), time.Minute)
+	require.NoError(t, list.Check(context.Background(), payload))
+	require.NoError(t, list.Check(context.Background(), payload))
+}
@@ -0,0 +1,199 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// camt053DateTimeLayout is the ISO 20022 ISODateTime in UTC
+const camt053DateTimeLayout = "2006-01-02T15:04:05Z"
+
+// credit and debit indicators of ISO 20022 amounts, which are always positive
+const (
+	camt053Credit = "CRDT"
+	camt053Debit  = "DBIT"
+)
+
+type camt053Document struct {
+	XMLName   xml.Name         `xml:"Document"`
+	Namespace string           `xml:"xmlns,attr"`
+	Statement camt053BkToCstmr `xml:"BkToCstmrStmt"`
+}
+
+type camt053BkToCstmr struct {
+	GroupHeader camt053GroupHeader `xml:"GrpHdr"`
+	Statement   camt053Statement   `xml:"Stmt"`
+}
+
+type camt053GroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camt053Statement struct {
+	ID       string           `xml:"Id"`
+	CreDtTm  string           `xml:"CreDtTm"`
+	FromDtTm string           `xml:"FrToDt>FrDtTm"`
+	ToDtTm   string           `xml:"FrToDt>ToDtTm"`
+	Account  camt053Account   `xml:"Acct"`
+	Balances []camt053Balance `xml:"Bal"`
+	Entries  []camt053Entry   `xml:"Ntry"`
+}
+
+type camt053Account struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+	Owner    string `xml:"Ownr>Nm"`
+	Servicer string `xml:"Svcr>FinInstnId>Othr>Id"`
+}
+
+type camt053Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camt053Balance struct {
+	Type      string        `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camt053Amount `xml:"Amt"`
+	CdtDbtInd string        `xml:"CdtDbtInd"`
+	DtTm      string        `xml:"Dt>DtTm"`
+}
+
+type camt053Entry struct {
+	NtryRef     string               `xml:"NtryRef"`
+	Amount      camt053Amount        `xml:"Amt"`
+	CdtDbtInd   string               `xml:"CdtDbtInd"`
+	Status      string               `xml:"Sts"`
+	BookingDate string               `xml:"BookgDt>DtTm"`
+	ValueDate   string               `xml:"ValDt>DtTm"`
+	AcctSvcrRef string               `xml:"AcctSvcrRef"`
+	BkTxCd      string               `xml:"BkTxCd>Prtry>Cd"`
+	Details     *camt053EntryDetails `xml:"NtryDtls>TxDtls,omitempty"`
+}
+
+type camt053EntryDetails struct {
+	TxID           string                `xml:"Refs>TxId"`
+	RelatedParties camt053RelatedParties `xml:"RltdPties"`
+}
+
+type camt053RelatedParties struct {
+	Debtor          *camt053Party        `xml:"Dbtr,omitempty"`
+	DebtorAccount   *camt053PartyAccount `xml:"DbtrAcct,omitempty"`
+	Creditor        *camt053Party        `xml:"Cdtr,omitempty"`
+	CreditorAccount *camt053PartyAccount `xml:"CdtrAcct,omitempty"`
+}
+
+type camt053Party struct {
+	Name string `xml:"Nm"`
+}
+
+type camt053PartyAccount struct {
+	ID string `xml:"Id>Othr>Id"`
+}
+
+// writeCamt053 writes the statement as an ISO 20022 camt.053 bank to customer statement
+func writeCamt053(w io.Writer, statement db.AccountStatementTxResult, generatedAt time.Time) error {
+	account := statement.Account
+	id := fmt.Sprintf("%d-%s", account.ID, statement.StartTime.UTC().Format("20060102150405"))
+
+	doc := camt053Document{
+		Namespace: camt053Namespace,
+		Statement: camt053BkToCstmr{
+			GroupHeader: camt053GroupHeader{
+				MsgID:   id,
+				CreDtTm: formatCamt053DateTime(generatedAt),
+			},
+			Statement: camt053Statement{
+				ID:       id,
+				CreDtTm:  formatCamt053DateTime(generatedAt),
+				FromDtTm: formatCamt053DateTime(statement.StartTime),
+				ToDtTm:   formatCamt053DateTime(statement.EndTime),
+				Account: camt053Account{
+					ID:       strconv.FormatInt(account.ID, 10),
+					Currency: account.Currency,
+					Owner:    account.Owner,
+					Servicer: bankID,
+				},
+				Balances: []camt053Balance{
+					newCamt053Balance("OPBD", statement.OpeningBalance, account.Currency, statement.StartTime),
+					newCamt053Balance("CLBD", statement.ClosingBalance, account.Currency, statement.EndTime),
+				},
+				Entries: make([]camt053Entry, 0, len(statement.Lines)),
+			},
+		},
+	}
+
+	for _, line := range statement.Lines {
+		amount, indicator := splitCamt053Amount(line.Amount, account.Currency)
+
+		entry := camt053Entry{
+			NtryRef:     strconv.FormatInt(line.ID, 10),
+			Amount:      camt053Amount{Currency: account.Currency, Value: amount},
+			CdtDbtInd:   indicator,
+			Status:      "BOOK",
+			BookingDate: formatCamt053DateTime(line.CreatedAt),
+			ValueDate:   formatCamt053DateTime(line.CreatedAt),
+			AcctSvcrRef: strconv.FormatInt(line.ID, 10),
+			BkTxCd:      entryType(line),
+		}
+
+		if line.TransferID.Valid {
+			counterparty := &camt053Party{Name: line.CounterpartyOwner.String}
+			counterpartyAccount := &camt053PartyAccount{ID: strconv.FormatInt(line.CounterpartyAccountID.Int64, 10)}
+
+			entry.Details = &camt053EntryDetails{TxID: strconv.FormatInt(line.TransferID.Int64, 10)}
+			if indicator == camt053Debit {
+				entry.Details.RelatedParties.Creditor = counterparty
+				entry.Details.RelatedParties.CreditorAccount = counterpartyAccount
+			} else {
+				entry.Details.RelatedParties.Debtor = counterparty
+				entry.Details.RelatedParties.DebtorAccount = counterpartyAccount
+			}
+		}
+
+		doc.Statement.Statement.Entries = append(doc.Statement.Statement.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func newCamt053Balance(code string, balance int64, currency string, at time.Time) camt053Balance {
+	amount, indicator := splitCamt053Amount(balance, currency)
+
+	return camt053Balance{
+		Type:      code,
+		Amount:    camt053Amount{Currency: currency, Value: amount},
+		CdtDbtInd: indicator,
+		DtTm:      formatCamt053DateTime(at),
+	}
+}
+
+// splitCamt053Amount returns the absolute decimal amount and its credit or debit indicator
+func splitCamt053Amount(amount int64, currency string) (string, string) {
+	if amount < 0 {
+		return util.FormatAmount(-amount, currency), camt053Debit
+	}
+	return util.FormatAmount(amount, currency), camt053Credit
+}
+
+func formatCamt053DateTime(t time.Time) string {
+	return t.UTC().Format(camt053DateTimeLayout)
+}
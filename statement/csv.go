@@ -0,0 +1,58 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"date",
+	"entry_id",
+	"type",
+	"amount",
+	"currency",
+	"running_balance",
+	"transfer_id",
+	"counterparty_account_id",
+	"counterparty_owner",
+}
+
+// writeCSV writes one row per statement line, amounts are decimals in the account currency
+func writeCSV(w io.Writer, statement db.AccountStatementTxResult) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, line := range statement.Lines {
+		record := []string{
+			line.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(line.ID, 10),
+			entryType(line),
+			util.FormatAmount(line.Amount, statement.Account.Currency),
+			statement.Account.Currency,
+			util.FormatAmount(line.RunningBalance, statement.Account.Currency),
+			"",
+			"",
+			line.CounterpartyOwner.String,
+		}
+		if line.TransferID.Valid {
+			record[6] = strconv.FormatInt(line.TransferID.Int64, 10)
+		}
+		if line.CounterpartyAccountID.Valid {
+			record[7] = strconv.FormatInt(line.CounterpartyAccountID.Int64, 10)
+		}
+
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
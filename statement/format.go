@@ -0,0 +1,81 @@
+package statement
+
+import (
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+// Format is a file format a statement can be exported to
+type Format string
+
+// supported export formats
+const (
+	FormatCSV     Format = "csv"
+	FormatOFX     Format = "ofx"
+	FormatCamt053 Format = "camt053"
+)
+
+// bankID identifies the bank in the exported files
+const bankID = "SMPLBANK"
+
+// Formats lists the supported export formats
+var Formats = []Format{FormatCSV, FormatOFX, FormatCamt053}
+
+// ContentType returns the MIME type of the format
+func (format Format) ContentType() string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatCamt053:
+		return "application/xml"
+	}
+	return "application/octet-stream"
+}
+
+// Extension returns the file extension of the format
+func (format Format) Extension() string {
+	switch format {
+	case FormatCamt053:
+		return "xml"
+	}
+	return string(format)
+}
+
+// FormatFromContentType returns the format that matches a MIME type
+func FormatFromContentType(contentType string) (Format, bool) {
+	for _, format := range Formats {
+		if format.ContentType() == contentType {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// Write encodes the statement in the given format.
+// generatedAt is the creation time written into the file headers.
+func Write(w io.Writer, format Format, statement db.AccountStatementTxResult, generatedAt time.Time) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, statement)
+	case FormatOFX:
+		return writeOFX(w, statement, generatedAt)
+	case FormatCamt053:
+		return writeCamt053(w, statement, generatedAt)
+	}
+	return fmt.Errorf("unsupported statement format %q", format)
+}
+
+// entryType describes what moved the money of a statement line
+func entryType(line db.AccountStatementLine) string {
+	switch {
+	case line.TransferID.Valid:
+		return "transfer"
+	case line.Amount < 0:
+		return "withdrawal"
+	}
+	return "deposit"
+}
@@ -0,0 +1,143 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+const ofxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+// ofxDateTimeLayout is the OFX datetime with milliseconds and the GMT offset
+const ofxDateTimeLayout = "20060102150405.000[0:GMT]"
+
+type ofxDocument struct {
+	XMLName xml.Name     `xml:"OFX"`
+	SignOn  ofxSignOn    `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStmtTrnRs `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStmtTrnRs struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs ofxStmtRs `xml:"STMTRS"`
+}
+
+type ofxStmtRs struct {
+	CurDef    string      `xml:"CURDEF"`
+	BankAcct  ofxBankAcct `xml:"BANKACCTFROM"`
+	TranList  ofxTranList `xml:"BANKTRANLIST"`
+	LedgerBal ofxBalance  `xml:"LEDGERBAL"`
+}
+
+type ofxBankAcct struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// writeOFX writes the statement as an OFX 2.2 bank statement response
+func writeOFX(w io.Writer, statement db.AccountStatementTxResult, generatedAt time.Time) error {
+	ok := ofxStatus{Code: 0, Severity: "INFO"}
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ok,
+			DTServer: formatOFXDateTime(generatedAt),
+			Language: "ENG",
+		},
+		Bank: ofxStmtTrnRs{
+			TrnUID: fmt.Sprintf("%d-%d", statement.Account.ID, statement.StartTime.Unix()),
+			Status: ok,
+			StmtRs: ofxStmtRs{
+				CurDef: statement.Account.Currency,
+				BankAcct: ofxBankAcct{
+					BankID:   bankID,
+					AcctID:   strconv.FormatInt(statement.Account.ID, 10),
+					AcctType: "CHECKING",
+				},
+				TranList: ofxTranList{
+					DTStart:      formatOFXDateTime(statement.StartTime),
+					DTEnd:        formatOFXDateTime(statement.EndTime),
+					Transactions: make([]ofxTransaction, 0, len(statement.Lines)),
+				},
+				LedgerBal: ofxBalance{
+					BalAmt: util.FormatAmount(statement.ClosingBalance, statement.Account.Currency),
+					DTAsOf: formatOFXDateTime(statement.EndTime),
+				},
+			},
+		},
+	}
+
+	for _, line := range statement.Lines {
+		trnType := "CREDIT"
+		if line.TransferID.Valid {
+			trnType = "XFER"
+		} else if line.Amount < 0 {
+			trnType = "DEBIT"
+		}
+
+		doc.Bank.StmtRs.TranList.Transactions = append(doc.Bank.StmtRs.TranList.Transactions, ofxTransaction{
+			TrnType:  trnType,
+			DTPosted: formatOFXDateTime(line.CreatedAt),
+			TrnAmt:   util.FormatAmount(line.Amount, statement.Account.Currency),
+			FITID:    strconv.FormatInt(line.ID, 10),
+			Name:     line.CounterpartyOwner.String,
+			Memo:     entryType(line),
+		})
+	}
+
+	if _, err := io.WriteString(w, ofxHeader); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatOFXDateTime(t time.Time) string {
+	return t.UTC().Format(ofxDateTimeLayout)
+}
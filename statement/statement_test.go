@@ -0,0 +1,143 @@
+package statement
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+// run `go test ./statement -update` to rewrite the golden files after a format change
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestWrite(t *testing.T) {
+	generatedAt := time.Date(2024, 2, 1, 8, 30, 0, 0, time.UTC)
+
+	testCases := []struct {
+		format Format
+		golden string
+	}{
+		{format: FormatCSV, golden: "statement.csv.golden"},
+		{format: FormatOFX, golden: "statement.ofx.golden"},
+		{format: FormatCamt053, golden: "statement.camt053.golden"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(string(tc.format), func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, tc.format, fixedStatement(), generatedAt)
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.golden)
+			if *update {
+				err = os.WriteFile(golden, buf.Bytes(), 0644)
+				require.NoError(t, err)
+			}
+
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(expected), buf.String())
+		})
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, Format("pdf"), fixedStatement(), time.Now())
+	require.Error(t, err)
+	require.Empty(t, buf.Bytes())
+}
+
+func TestFormatFromContentType(t *testing.T) {
+	for _, format := range Formats {
+		got, ok := FormatFromContentType(format.ContentType())
+		require.True(t, ok)
+		require.Equal(t, format, got)
+	}
+
+	_, ok := FormatFromContentType("application/json")
+	require.False(t, ok)
+}
+
+// fixedStatement is a statement with a deposit, an incoming and an outgoing transfer and a withdrawal
+func fixedStatement() db.AccountStatementTxResult {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	return db.AccountStatementTxResult{
+		Account: db.Account{
+			ID:       42,
+			Owner:    "alice",
+			Balance:  12050,
+			Currency: util.USD,
+		},
+		StartTime:      from,
+		EndTime:        time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 10000,
+		ClosingBalance: 12050,
+		Lines: []db.AccountStatementLine{
+			{
+				ListAccountStatementEntriesRow: db.ListAccountStatementEntriesRow{
+					ID:        101,
+					AccountID: 42,
+					Amount:    5000,
+					CreatedAt: from.Add(24 * time.Hour),
+				},
+				RunningBalance: 15000,
+			},
+			{
+				ListAccountStatementEntriesRow: db.ListAccountStatementEntriesRow{
+					ID:                    102,
+					AccountID:             42,
+					Amount:                -2500,
+					CreatedAt:             from.Add(48 * time.Hour),
+					TransferID:            pgtype.Int8{Int64: 7, Valid: true},
+					CounterpartyAccountID: pgtype.Int8{Int64: 43, Valid: true},
+					CounterpartyOwner:     pgtype.Text{String: "bob", Valid: true},
+				},
+				RunningBalance: 12500,
+			},
+			{
+				ListAccountStatementEntriesRow: db.ListAccountStatementEntriesRow{
+					ID:                    105,
+					AccountID:             42,
+					Amount:                750,
+					CreatedAt:             from.Add(72*time.Hour + 15*time.Minute),
+					TransferID:            pgtype.Int8{Int64: 9, Valid: true},
+					CounterpartyAccountID: pgtype.Int8{Int64: 44, Valid: true},
+					CounterpartyOwner:     pgtype.Text{String: "carol & co", Valid: true},
+				},
+				RunningBalance: 13250,
+			},
+			{
+				ListAccountStatementEntriesRow: db.ListAccountStatementEntriesRow{
+					ID:        110,
+					AccountID: 42,
+					Amount:    -1200,
+					CreatedAt: from.Add(96 * time.Hour),
+				},
+				RunningBalance: 12050,
+			},
+		},
+	}
+}
+
+func TestWriteCSVMinorUnits(t *testing.T) {
+	statement := fixedStatement()
+	statement.Account.Currency = util.JPY
+
+	var buf bytes.Buffer
+	err := Write(&buf, FormatCSV, statement, time.Now())
+	require.NoError(t, err)
+
+	// yen have no decimals
+	require.Contains(t, buf.String(), "2024-01-02T00:00:00Z,101,deposit,5000,JPY,15000,,,\n")
+}
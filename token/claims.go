@@ -0,0 +1,114 @@
+package token
+
+import (
+	"slices"
+	"time"
+)
+
+// DefaultClockSkew is how far the clocks of the issuing and the verifying services may drift apart
+const DefaultClockSkew = 30 * time.Second
+
+// ClaimsConfig sets the issuer and audience written in new tokens and the values accepted when verifying them.
+// The zero value writes none and accepts any.
+type ClaimsConfig struct {
+	Issuer           string        // issuer of new tokens
+	Audience         []string      // audience of new tokens
+	AllowedIssuers   []string      // issuers accepted when verifying, Issuer alone when empty
+	AllowedAudiences []string      // a token must name one of them, Audience when empty
+	ClockSkew        time.Duration // tolerance on the expiry and not-before times
+}
+
+// Option customizes the ClaimsConfig of a token maker
+type Option func(config *ClaimsConfig)
+
+// WithIssuer sets the issuer of new tokens, only tokens of this issuer are accepted unless WithAllowedIssuers is used
+func WithIssuer(issuer string) Option {
+	return func(config *ClaimsConfig) {
+		config.Issuer = issuer
+	}
+}
+
+// WithAudience sets the audience of new tokens, tokens must name one of them unless WithAllowedAudiences is used
+func WithAudience(audience ...string) Option {
+	return func(config *ClaimsConfig) {
+		config.Audience = audience
+	}
+}
+
+// WithAllowedIssuers sets the issuers accepted when verifying tokens
+func WithAllowedIssuers(issuers ...string) Option {
+	return func(config *ClaimsConfig) {
+		config.AllowedIssuers = issuers
+	}
+}
+
+// WithAllowedAudiences sets the audiences accepted when verifying tokens
+func WithAllowedAudiences(audiences ...string) Option {
+	return func(config *ClaimsConfig) {
+		config.AllowedAudiences = audiences
+	}
+}
+
+// WithClockSkew sets the tolerance on the expiry and not-before times, DefaultClockSkew otherwise
+func WithClockSkew(clockSkew time.Duration) Option {
+	return func(config *ClaimsConfig) {
+		config.ClockSkew = clockSkew
+	}
+}
+
+// newClaimsConfig applies the options of a token maker on top of the defaults
+func newClaimsConfig(opts []Option) ClaimsConfig {
+	config := ClaimsConfig{
+		ClockSkew: DefaultClockSkew,
+	}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return config
+}
+
+// NewPayload creates a new token payload carrying the issuer and audience of the config
+func (config ClaimsConfig) NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	payload, err := NewPayload(username, role, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Issuer = config.Issuer
+	payload.Audience = config.Audience
+
+	return payload, nil
+}
+
+// Validate checks the times, the issuer and the audience of a token payload
+func (config ClaimsConfig) Validate(payload *Payload) error {
+	now := time.Now()
+
+	if now.After(payload.ExpiredAt.Add(config.ClockSkew)) {
+		return ErrExpiredToken
+	}
+
+	if now.Before(payload.NotBefore.Add(-config.ClockSkew)) {
+		return ErrTokenNotYetValid
+	}
+
+	allowedIssuers := config.AllowedIssuers
+	if len(allowedIssuers) == 0 && config.Issuer != "" {
+		allowedIssuers = []string{config.Issuer}
+	}
+	if len(allowedIssuers) > 0 && !slices.Contains(allowedIssuers, payload.Issuer) {
+		return ErrInvalidIssuer
+	}
+
+	allowedAudiences := config.AllowedAudiences
+	if len(allowedAudiences) == 0 {
+		allowedAudiences = config.Audience
+	}
+	if len(allowedAudiences) > 0 && !slices.ContainsFunc(payload.Audience, func(audience string) bool {
+		return slices.Contains(allowedAudiences, audience)
+	}) {
+		return ErrInvalidAudience
+	}
+
+	return nil
+}
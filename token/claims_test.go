@@ -0,0 +1,168 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// claimsMakers builds one maker of every token type from the same keys and options
+func claimsMakers(t *testing.T, secretKey string, rsaKey *rsa.PrivateKey, ed25519Key ed25519.PrivateKey, opts ...Option) map[string]Maker {
+	pasetoMaker, err := NewPasetoMaker(secretKey, opts...)
+	require.NoError(t, err)
+	pasetoPublicMaker, err := NewPasetoPublicMaker(ed25519Key, nil, opts...)
+	require.NoError(t, err)
+	hs256Maker, err := NewJWTMaker(secretKey, opts...)
+	require.NoError(t, err)
+	rs256Maker, err := NewRS256JWTMaker(rsaKey, opts...)
+	require.NoError(t, err)
+	eddsaMaker, err := NewEdDSAJWTMaker(ed25519Key, opts...)
+	require.NoError(t, err)
+
+	return map[string]Maker{
+		TypePaseto:       pasetoMaker,
+		TypePasetoPublic: pasetoPublicMaker,
+		TypeJWTHS256:     hs256Maker,
+		TypeJWTRS256:     rs256Maker,
+		TypeJWTEdDSA:     eddsaMaker,
+	}
+}
+
+func TestMakerClaims(t *testing.T) {
+	secretKey := util.RandomString(32)
+	rsaKey := randomRSAKey(t)
+	ed25519Key := randomEd25519Key(t)
+
+	production := claimsMakers(t, secretKey, rsaKey, ed25519Key, WithIssuer("simplebank-production"), WithAudience("simplebank-api"))
+
+	testCases := []struct {
+		name        string
+		verifier    map[string]Maker
+		expectedErr error
+	}{
+		{
+			name:     "SameEnvironment",
+			verifier: production,
+		},
+		{
+			name:        "OtherIssuer",
+			verifier:    claimsMakers(t, secretKey, rsaKey, ed25519Key, WithIssuer("simplebank-staging"), WithAudience("simplebank-api")),
+			expectedErr: ErrInvalidIssuer,
+		},
+		{
+			name:     "AllowedIssuer",
+			verifier: claimsMakers(t, secretKey, rsaKey, ed25519Key, WithIssuer("simplebank-staging"), WithAllowedIssuers("simplebank-staging", "simplebank-production")),
+		},
+		{
+			name:        "OtherAudience",
+			verifier:    claimsMakers(t, secretKey, rsaKey, ed25519Key, WithIssuer("simplebank-production"), WithAudience("simplebank-admin")),
+			expectedErr: ErrInvalidAudience,
+		},
+		{
+			name:     "AllowedAudience",
+			verifier: claimsMakers(t, secretKey, rsaKey, ed25519Key, WithIssuer("simplebank-production"), WithAllowedAudiences("simplebank-admin", "simplebank-api")),
+		},
+		{
+			name:     "NoClaimsChecked",
+			verifier: claimsMakers(t, secretKey, rsaKey, ed25519Key),
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			for tokenType, maker := range production {
+				token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+				require.NoError(t, err)
+
+				payload, err := tc.verifier[tokenType].VerifyToken(token)
+				if tc.expectedErr != nil {
+					require.ErrorIs(t, err, tc.expectedErr, tokenType)
+					require.Nil(t, payload)
+					continue
+				}
+
+				require.NoError(t, err, tokenType)
+				require.Equal(t, "simplebank-production", payload.Issuer)
+				require.Equal(t, []string{"simplebank-api"}, payload.Audience)
+				require.WithinDuration(t, payload.IssuedAt, payload.NotBefore, time.Second)
+			}
+		})
+	}
+}
+
+func TestMakerClockSkew(t *testing.T) {
+	secretKey := util.RandomString(32)
+	rsaKey := randomRSAKey(t)
+	ed25519Key := randomEd25519Key(t)
+
+	tolerant := claimsMakers(t, secretKey, rsaKey, ed25519Key, WithClockSkew(time.Minute))
+	strict := claimsMakers(t, secretKey, rsaKey, ed25519Key, WithClockSkew(time.Second))
+
+	for tokenType, maker := range tolerant {
+		// expired 10 seconds ago, still within one minute of skew
+		token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -10*time.Second)
+		require.NoError(t, err)
+
+		_, err = maker.VerifyToken(token)
+		require.NoError(t, err, tokenType)
+
+		_, err = strict[tokenType].VerifyToken(token)
+		require.ErrorIs(t, err, ErrExpiredToken, tokenType)
+	}
+}
+
+func TestJWTTokenNotYetValid(t *testing.T) {
+	secretKey := util.RandomString(32)
+	maker, err := NewJWTMaker(secretKey)
+	require.NoError(t, err)
+
+	now := time.Now()
+	claims := jwtClaims{
+		Username: util.RandomOwner(),
+		Role:     util.DepositorRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "0b8a9f2e-3d1c-4f6e-9a7b-5c2d8e1f4a3b",
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now.Add(time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(2 * time.Hour)),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.ErrorIs(t, err, ErrTokenNotYetValid)
+	require.Nil(t, payload)
+}
+
+func TestClaimsConfigValidate(t *testing.T) {
+	config := ClaimsConfig{
+		Issuer:    "simplebank-production",
+		Audience:  []string{"simplebank-api"},
+		ClockSkew: 30 * time.Second,
+	}
+
+	payload, err := config.NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	require.NoError(t, config.Validate(payload))
+
+	// the clock of the issuer is 10 seconds ahead of ours
+	payload.NotBefore = time.Now().Add(10 * time.Second)
+	require.NoError(t, config.Validate(payload))
+
+	payload.NotBefore = time.Now().Add(time.Minute)
+	require.ErrorIs(t, config.Validate(payload), ErrTokenNotYetValid)
+	require.ErrorIs(t, payload.Valid(), ErrTokenNotYetValid)
+
+	payload.NotBefore = payload.IssuedAt
+	payload.Audience = nil
+	require.ErrorIs(t, config.Validate(payload), ErrInvalidAudience)
+	require.NoError(t, payload.Valid())
+}
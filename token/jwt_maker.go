@@ -0,0 +1,163 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const minSecretKeySize = 32
+
+// jwtClaims carries the Payload fields as JWT claims: ID is jti, Issuer is iss, Audience is aud,
+// IssuedAt is iat, NotBefore is nbf and ExpiredAt is exp
+type jwtClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// JWTMaker is a JSON Web Token maker
+type JWTMaker struct {
+	method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+	claims    ClaimsConfig
+}
+
+// NewJWTMaker creates a new JWTMaker signing with HS256
+func NewJWTMaker(secretKey string, opts ...Option) (Maker, error) {
+	if len(secretKey) < minSecretKeySize {
+		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
+	}
+
+	maker := &JWTMaker{
+		method:    jwt.SigningMethodHS256,
+		signKey:   []byte(secretKey),
+		verifyKey: []byte(secretKey),
+		claims:    newClaimsConfig(opts),
+	}
+
+	return maker, nil
+}
+
+// NewRS256JWTMaker creates a new JWTMaker signing with RS256
+func NewRS256JWTMaker(privateKey *rsa.PrivateKey, opts ...Option) (Maker, error) {
+	if privateKey == nil || privateKey.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("invalid key size: must be at least 2048 bits")
+	}
+
+	maker := &JWTMaker{
+		method:    jwt.SigningMethodRS256,
+		signKey:   privateKey,
+		verifyKey: &privateKey.PublicKey,
+		claims:    newClaimsConfig(opts),
+	}
+
+	return maker, nil
+}
+
+// NewEdDSAJWTMaker creates a new JWTMaker signing with EdDSA over Ed25519
+func NewEdDSAJWTMaker(privateKey ed25519.PrivateKey, opts ...Option) (Maker, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PrivateKeySize)
+	}
+
+	maker := &JWTMaker{
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   privateKey,
+		verifyKey: privateKey.Public(),
+		claims:    newClaimsConfig(opts),
+	}
+
+	return maker, nil
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *JWTMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	payload, err := maker.claims.NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	claims := jwtClaims{
+		Username: payload.Username,
+		Role:     payload.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        payload.ID.String(),
+			Issuer:    payload.Issuer,
+			Audience:  payload.Audience,
+			IssuedAt:  jwt.NewNumericDate(payload.IssuedAt),
+			NotBefore: jwt.NewNumericDate(payload.NotBefore),
+			ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(maker.method, claims).SignedString(maker.signKey)
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(t *jwt.Token) (any, error) {
+		// never trust the alg header: "none" or an HMAC token signed with our public key must fail
+		if t.Method != maker.method {
+			return nil, ErrInvalidToken
+		}
+		return maker.verifyKey, nil
+	}
+
+	claims := &jwtClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, keyFunc,
+		jwt.WithValidMethods([]string{maker.method.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(maker.claims.ClockSkew),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, jwt.ErrTokenExpired):
+			return nil, ErrExpiredToken
+		case errors.Is(err, jwt.ErrTokenNotValidYet):
+			return nil, ErrTokenNotYetValid
+		}
+		return nil, ErrInvalidToken
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// WithIssuedAt only checks iat when it is there, the revocation list needs it
+	if claims.IssuedAt == nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		IssuedAt:  claims.IssuedAt.Time,
+		ExpiredAt: claims.ExpiresAt.Time,
+		// NumericDate claims are whole seconds
+		IssuedAtPrecision: time.Second,
+	}
+	if claims.NotBefore != nil {
+		payload.NotBefore = claims.NotBefore.Time
+	}
+
+	// check the times, the issuer and the audience
+	err = maker.claims.Validate(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
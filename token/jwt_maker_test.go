@@ -0,0 +1,219 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func randomRSAKey(t *testing.T) *rsa.PrivateKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestJWTMaker(t *testing.T) {
+	hs256Maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+	rs256Maker, err := NewRS256JWTMaker(randomRSAKey(t))
+	require.NoError(t, err)
+	eddsaMaker, err := NewEdDSAJWTMaker(randomEd25519Key(t))
+	require.NoError(t, err)
+
+	makers := map[string]Maker{
+		"HS256": hs256Maker,
+		"RS256": rs256Maker,
+		"EdDSA": eddsaMaker,
+	}
+
+	for alg, maker := range makers {
+		t.Run(alg, func(t *testing.T) {
+			username := util.RandomOwner()
+			role := util.DepositorRole
+			duration := time.Minute
+			issuedAt := time.Now()
+			expiredAt := issuedAt.Add(duration)
+
+			token, payload, err := maker.CreateToken(username, role, duration)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+			require.NotEmpty(t, payload)
+
+			parsed, _, err := jwt.NewParser().ParseUnverified(token, &jwtClaims{})
+			require.NoError(t, err)
+			require.Equal(t, alg, parsed.Header["alg"])
+
+			payload, err = maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			require.NotZero(t, payload.ID)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, role, payload.Role)
+			require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+			require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+			require.Equal(t, time.Second, payload.IssuedAtPrecision)
+		})
+	}
+}
+
+func TestExpiredJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTTokenAlgNone(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	claims := jwtClaims{
+		Username: util.RandomOwner(),
+		Role:     util.BankerRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodNone, claims).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTTokenNoIssuedAt(t *testing.T) {
+	secretKey := util.RandomString(32)
+	maker, err := NewJWTMaker(secretKey)
+	require.NoError(t, err)
+
+	// correctly signed, but without the iat the revocation list compares with
+	claims := jwtClaims{
+		Username: util.RandomOwner(),
+		Role:     util.DepositorRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidJWTTokenAlgConfusion(t *testing.T) {
+	privateKey := randomRSAKey(t)
+	maker, err := NewRS256JWTMaker(privateKey)
+	require.NoError(t, err)
+
+	// the public key is public: an attacker signs an HS256 token using it as the HMAC secret
+	publicDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	require.NoError(t, err)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})
+
+	claims := jwtClaims{
+		Username: util.RandomOwner(),
+		Role:     util.BankerRole,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	for _, secret := range [][]byte{publicPEM, publicDER} {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		require.NoError(t, err)
+
+		payload, err := maker.VerifyToken(token)
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	}
+}
+
+func TestInvalidJWTToken(t *testing.T) {
+	maker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+	otherMaker, err := NewJWTMaker(util.RandomString(32))
+	require.NoError(t, err)
+	rs256Maker, err := NewRS256JWTMaker(randomRSAKey(t))
+	require.NoError(t, err)
+
+	otherToken, _, err := otherMaker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	rs256Token, _, err := rs256Maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	invalidTokens := map[string]string{
+		"Random":       util.RandomString(32),
+		"OtherSecret":  otherToken,
+		"OtherAlg":     rs256Token,
+		"PasetoFormat": "v2.local." + util.RandomString(32),
+	}
+
+	for name, invalidToken := range invalidTokens {
+		t.Run(name, func(t *testing.T) {
+			payload, err := maker.VerifyToken(invalidToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestJWTMakerInvalidKey(t *testing.T) {
+	_, err := NewJWTMaker(util.RandomString(31))
+	require.Error(t, err)
+
+	smallKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	_, err = NewRS256JWTMaker(smallKey)
+	require.Error(t, err)
+
+	_, err = NewEdDSAJWTMaker(nil)
+	require.Error(t, err)
+}
+
+func TestParseRSAPrivateKeyPEM(t *testing.T) {
+	privateKey := randomRSAKey(t)
+
+	pkcs1 := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	parsed, err := ParseRSAPrivateKeyPEM(pkcs1)
+	require.NoError(t, err)
+	require.True(t, privateKey.Equal(parsed))
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	require.NoError(t, err)
+	pkcs8 := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	parsed, err = ParseRSAPrivateKeyPEM(pkcs8)
+	require.NoError(t, err)
+	require.True(t, privateKey.Equal(parsed))
+
+	// an Ed25519 key is not an RSA key
+	der, err = x509.MarshalPKCS8PrivateKey(randomEd25519Key(t))
+	require.NoError(t, err)
+	_, err = ParseRSAPrivateKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.Error(t, err)
+}
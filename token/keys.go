@@ -0,0 +1,145 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// ParseEd25519PrivateKeyPEM parses a PKCS #8 "PRIVATE KEY" PEM block holding an Ed25519 key,
+// as written by `openssl genpkey -algorithm ed25519`
+func ParseEd25519PrivateKeyPEM(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("no PRIVATE KEY PEM block found")
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key: %w", err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is a %T, not an Ed25519 key", key)
+	}
+
+	return privateKey, nil
+}
+
+// ParseEd25519PublicKeyPEM parses a PKIX "PUBLIC KEY" PEM block holding an Ed25519 key,
+// as written by `openssl pkey -pubout`
+func ParseEd25519PublicKeyPEM(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("no PUBLIC KEY PEM block found")
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key: %w", err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is a %T, not an Ed25519 key", key)
+	}
+
+	return publicKey, nil
+}
+
+// ParseRSAPrivateKeyPEM parses a PKCS #1 "RSA PRIVATE KEY" or a PKCS #8 "PRIVATE KEY" PEM block holding an RSA key
+func ParseRSAPrivateKeyPEM(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse private key: %w", err)
+		}
+		return privateKey, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse private key: %w", err)
+		}
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("private key is a %T, not an RSA key", key)
+		}
+		return privateKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected %s PEM block", block.Type)
+	}
+}
+
+// LoadRSAPrivateKey reads an RSA private key from a PEM file
+func LoadRSAPrivateKey(privateKeyFile string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read private key file: %w", err)
+	}
+
+	privateKey, err := ParseRSAPrivateKeyPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", privateKeyFile, err)
+	}
+
+	return privateKey, nil
+}
+
+// LoadEd25519Keys reads the signing key and the extra verification keys from PEM files
+func LoadEd25519Keys(privateKeyFile string, publicKeyFiles []string) (ed25519.PrivateKey, []ed25519.PublicKey, error) {
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read private key file: %w", err)
+	}
+
+	privateKey, err := ParseEd25519PrivateKeyPEM(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", privateKeyFile, err)
+	}
+
+	publicKeys, err := LoadEd25519PublicKeys(publicKeyFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return privateKey, publicKeys, nil
+}
+
+// LoadEd25519PublicKeys reads verification keys from PEM files, for a service that verifies tokens without signing any
+func LoadEd25519PublicKeys(publicKeyFiles []string) ([]ed25519.PublicKey, error) {
+	publicKeys := make([]ed25519.PublicKey, 0, len(publicKeyFiles))
+	for _, file := range publicKeyFiles {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read public key file: %w", err)
+		}
+
+		publicKey, err := ParseEd25519PublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		publicKeys = append(publicKeys, publicKey)
+	}
+
+	return publicKeys, nil
+}
+
+// KeyID derives the key ID written in the token footer from a public key,
+// so that every service computes the same ID without sharing anything else
+func KeyID(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
@@ -2,6 +2,15 @@ package token
 
 import "time"
 
+// Token types that can be chosen with the TOKEN_TYPE config
+const (
+	TypePaseto       = "paseto"        // PASETO v2.local, encrypted with TOKEN_SYMMETRIC_KEY
+	TypePasetoPublic = "paseto_public" // PASETO v4.public, signed with the Ed25519 key of TOKEN_PRIVATE_KEY_FILE, verify only without it
+	TypeJWTHS256     = "jwt_hs256"     // JWT signed with HS256 and TOKEN_SYMMETRIC_KEY
+	TypeJWTRS256     = "jwt_rs256"     // JWT signed with RS256 and the RSA key of TOKEN_PRIVATE_KEY_FILE
+	TypeJWTEdDSA     = "jwt_eddsa"     // JWT signed with EdDSA and the Ed25519 key of TOKEN_PRIVATE_KEY_FILE
+)
+
 // maker is an interface for managing tokens
 type Maker interface {
 	// CreateToken creates a new token for a specific username and duration
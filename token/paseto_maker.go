@@ -12,10 +12,11 @@ import (
 type PasetoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	claims       ClaimsConfig
 }
 
 // NewPasetoMaker creates a new PasetoMaker
-func NewPasetoMaker(symmetricKey string) (Maker, error) {
+func NewPasetoMaker(symmetricKey string, opts ...Option) (Maker, error) {
 	if len(symmetricKey) != chacha20poly1305.KeySize {
 		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", chacha20poly1305.KeySize)
 	}
@@ -23,6 +24,7 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 	maker := &PasetoMaker{
 		paseto:       paseto.NewV2(),
 		symmetricKey: []byte(symmetricKey),
+		claims:       newClaimsConfig(opts),
 	}
 
 	return maker, nil
@@ -30,7 +32,7 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 
 // CreateToken creates a new token for a specific username and duration
 func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, role, duration)
+	payload, err := maker.claims.NewPayload(username, role, duration)
 	if err != nil {
 		return "", payload, err
 	}
@@ -48,8 +50,8 @@ func (maker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrInvalidToken
 	}
 
-	// check the ExpiredAt duration
-	err = payload.Valid()
+	// check the times, the issuer and the audience
+	err = maker.claims.Validate(payload)
 	if err != nil {
 		return nil, err
 	}
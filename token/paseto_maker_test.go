@@ -18,12 +18,12 @@ func TestPasetoMaker(t *testing.T) {
 	issuedAt := time.Now()
 	expiredAt := issuedAt.Add(duration)
 
-	token, payload, err := maker.CreateToken(username, role, duration)
+	token, created, err := maker.CreateToken(username, role, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
-	require.NotEmpty(t, payload)
+	require.NotEmpty(t, created)
 
-	payload, err = maker.VerifyToken(token)
+	payload, err := maker.VerifyToken(token)
 	require.NoError(t, err)
 	require.NotEmpty(t, payload)
 
@@ -32,6 +32,10 @@ func TestPasetoMaker(t *testing.T) {
 	require.Equal(t, role, payload.Role)
 	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	// the revocation list compares the issue time at full precision
+	require.True(t, created.IssuedAt.Equal(payload.IssuedAt))
+	require.Zero(t, payload.IssuedAtPrecision)
 }
 
 func TestPasetoKeyLengthMaker(t *testing.T) {
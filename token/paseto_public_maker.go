@@ -0,0 +1,281 @@
+package token
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// pasetoV4PublicHeader is the header of every PASETO v4.public token
+const pasetoV4PublicHeader = "v4.public."
+
+// pasetoFooter is the unencrypted footer of a token, it tells which key signed it
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// ErrVerifyOnly is returned when a token maker built without a signing key is asked to create a token
+var ErrVerifyOnly = errors.New("token maker can only verify tokens")
+
+// pasetoPublicClaims carries the Payload fields as the registered PASETO claims, so that any PASETO library can check them:
+// ID is jti, Issuer is iss, Audience is aud, IssuedAt is iat, NotBefore is nbf and ExpiredAt is exp
+type pasetoPublicClaims struct {
+	ID        string         `json:"jti"`
+	Issuer    string         `json:"iss,omitempty"`
+	Audience  pasetoAudience `json:"aud,omitempty"`
+	IssuedAt  time.Time      `json:"iat"`
+	NotBefore time.Time      `json:"nbf"`
+	ExpiresAt time.Time      `json:"exp"`
+	Username  string         `json:"username"`
+	Role      string         `json:"role"`
+}
+
+// pasetoAudience is the aud claim, a string as the PASETO specification has it, or an array when a token names several audiences
+type pasetoAudience []string
+
+func (audience pasetoAudience) MarshalJSON() ([]byte, error) {
+	if len(audience) == 1 {
+		return json.Marshal(audience[0])
+	}
+	return json.Marshal([]string(audience))
+}
+
+func (audience *pasetoAudience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*audience = pasetoAudience{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(audience))
+}
+
+// PasetoPublicMaker is a PASETO v4.public token maker.
+// Tokens are signed with an Ed25519 private key, so other services only need the public keys to verify them.
+// A maker built by NewPasetoPublicVerifier has no private key and can only verify tokens.
+type PasetoPublicMaker struct {
+	signingKey   ed25519.PrivateKey
+	signingKeyID string
+	keyring      map[string]ed25519.PublicKey
+	claims       ClaimsConfig
+}
+
+// NewPasetoPublicMaker creates a new PasetoPublicMaker signing with signingKey.
+// verificationKeys are also accepted when verifying, e.g. the keys used before a rotation.
+func NewPasetoPublicMaker(signingKey ed25519.PrivateKey, verificationKeys []ed25519.PublicKey, opts ...Option) (Maker, error) {
+	if len(signingKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PrivateKeySize)
+	}
+
+	publicKey := signingKey.Public().(ed25519.PublicKey)
+	maker := &PasetoPublicMaker{
+		signingKey:   signingKey,
+		signingKeyID: KeyID(publicKey),
+		keyring: map[string]ed25519.PublicKey{
+			KeyID(publicKey): publicKey,
+		},
+		claims: newClaimsConfig(opts),
+	}
+
+	for _, key := range verificationKeys {
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid verification key size: must be exactly %d bytes", ed25519.PublicKeySize)
+		}
+		maker.keyring[KeyID(key)] = key
+	}
+
+	return maker, nil
+}
+
+// NewPasetoPublicVerifier creates a PasetoPublicMaker that only verifies tokens signed by one of verificationKeys.
+// Its CreateToken returns ErrVerifyOnly, so a service that checks tokens never holds the signing key.
+func NewPasetoPublicVerifier(verificationKeys []ed25519.PublicKey, opts ...Option) (Maker, error) {
+	if len(verificationKeys) == 0 {
+		return nil, fmt.Errorf("at least one verification key is needed")
+	}
+
+	maker := &PasetoPublicMaker{
+		keyring: make(map[string]ed25519.PublicKey, len(verificationKeys)),
+		claims:  newClaimsConfig(opts),
+	}
+
+	for _, key := range verificationKeys {
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid verification key size: must be exactly %d bytes", ed25519.PublicKeySize)
+		}
+		maker.keyring[KeyID(key)] = key
+	}
+
+	return maker, nil
+}
+
+// CreateToken creates a new token for a specific username and duration
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
+	if maker.signingKey == nil {
+		return "", nil, ErrVerifyOnly
+	}
+
+	payload, err := maker.claims.NewPayload(username, role, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(pasetoPublicClaims{
+		ID:        payload.ID.String(),
+		Issuer:    payload.Issuer,
+		Audience:  payload.Audience,
+		IssuedAt:  payload.IssuedAt,
+		NotBefore: payload.NotBefore,
+		ExpiresAt: payload.ExpiredAt,
+		Username:  payload.Username,
+		Role:      payload.Role,
+	})
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: maker.signingKeyID})
+	if err != nil {
+		return "", payload, err
+	}
+
+	return signV4Public(maker.signingKey, message, footer, nil), payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	body, footer, err := decodeV4Public(token)
+	if err != nil {
+		return nil, err
+	}
+
+	// the footer is required to find the key
+	var f pasetoFooter
+	if err := json.Unmarshal(footer, &f); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	publicKey, ok := maker.keyring[f.KeyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message, err := verifyV4Public(publicKey, body, footer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims pasetoPublicClaims
+	if err := json.Unmarshal(message, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	tokenID, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// a token without an expiry would never expire
+	if claims.ExpiresAt.IsZero() {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{
+		ID:        tokenID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		IssuedAt:  claims.IssuedAt,
+		NotBefore: claims.NotBefore,
+		ExpiredAt: claims.ExpiresAt,
+	}
+
+	// check the times, the issuer and the audience
+	err = maker.claims.Validate(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// signV4Public signs message, footer and the implicit assertion with key and returns the v4.public token.
+// The footer is left out of the token when it is empty, the implicit assertion is never part of it.
+func signV4Public(key ed25519.PrivateKey, message, footer, implicit []byte) string {
+	signature := ed25519.Sign(key, pae([]byte(pasetoV4PublicHeader), message, footer, implicit))
+
+	token := pasetoV4PublicHeader + base64.RawURLEncoding.EncodeToString(append(message[:len(message):len(message)], signature...))
+	if len(footer) > 0 {
+		token += "." + base64.RawURLEncoding.EncodeToString(footer)
+	}
+
+	return token
+}
+
+// decodeV4Public splits a v4.public token into its signed body and its footer, which may be empty
+func decodeV4Public(token string) (body []byte, footer []byte, err error) {
+	if !strings.HasPrefix(token, pasetoV4PublicHeader) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// body[.footer]
+	parts := strings.Split(strings.TrimPrefix(token, pasetoV4PublicHeader), ".")
+	if len(parts) > 2 {
+		return nil, nil, ErrInvalidToken
+	}
+
+	body, err = base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, nil, ErrInvalidToken
+	}
+
+	if len(parts) == 2 {
+		footer, err = base64.RawURLEncoding.DecodeString(parts[1])
+		if err != nil {
+			return nil, nil, ErrInvalidToken
+		}
+	}
+
+	return body, footer, nil
+}
+
+// verifyV4Public checks the signature at the end of body against key, the footer and the implicit assertion.
+// It returns the signed message.
+func verifyV4Public(key ed25519.PublicKey, body, footer, implicit []byte) ([]byte, error) {
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(key, pae([]byte(pasetoV4PublicHeader), message, footer, implicit), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	return message, nil
+}
+
+// pae is the Pre-Authentication Encoding of the PASETO specification.
+// It encodes the number of pieces then each piece prefixed by its length, all as 64-bit little-endian integers.
+func pae(pieces ...[]byte) []byte {
+	var buf bytes.Buffer
+
+	le64 := func(n int) {
+		var b [8]byte
+		// the most significant bit must stay clear
+		binary.LittleEndian.PutUint64(b[:], uint64(n)&(1<<63-1))
+		buf.Write(b[:])
+	}
+
+	le64(len(pieces))
+	for _, piece := range pieces {
+		le64(len(piece))
+		buf.Write(piece)
+	}
+
+	return buf.Bytes()
+}
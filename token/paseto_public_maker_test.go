@@ -0,0 +1,307 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func randomEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	duration := time.Minute
+	issuedAt := time.Now()
+	expiredAt := issuedAt.Add(duration)
+
+	token, created, err := maker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, created)
+	require.True(t, strings.HasPrefix(token, "v4.public."))
+
+	payload, err := maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.WithinDuration(t, issuedAt, payload.IssuedAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+
+	// the revocation list compares the issue time at full precision
+	require.True(t, created.IssuedAt.Equal(payload.IssuedAt))
+	require.Zero(t, payload.IssuedAtPrecision)
+}
+
+func TestPasetoPublicMakerRegisteredClaims(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Key(t), nil, WithIssuer("simplebank"), WithAudience("simplebank-api"))
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	body, _, err := decodeV4Public(token)
+	require.NoError(t, err)
+
+	// other PASETO libraries only know the registered claims
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(body[:len(body)-ed25519.SignatureSize], &claims))
+	require.Equal(t, payload.ID.String(), claims["jti"])
+	require.Equal(t, "simplebank", claims["iss"])
+	require.Equal(t, "simplebank-api", claims["aud"])
+	for _, claim := range []string{"iat", "nbf", "exp"} {
+		value, ok := claims[claim].(string)
+		require.True(t, ok, claim)
+		_, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err, claim)
+	}
+	require.NotContains(t, claims, "expired_at")
+	require.NotContains(t, claims, "issued_at")
+}
+
+func TestPasetoPublicVerifier(t *testing.T) {
+	signingKey := randomEd25519Key(t)
+	maker, err := NewPasetoPublicMaker(signingKey, nil)
+	require.NoError(t, err)
+
+	verifier, err := NewPasetoPublicVerifier([]ed25519.PublicKey{signingKey.Public().(ed25519.PublicKey)})
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	verified, err := verifier.VerifyToken(token)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, payload.Username, verified.Username)
+
+	// it holds no signing key
+	_, _, err = verifier.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.ErrorIs(t, err, ErrVerifyOnly)
+
+	_, err = NewPasetoPublicVerifier(nil)
+	require.Error(t, err)
+}
+
+func TestPasetoPublicMakerFooter(t *testing.T) {
+	signingKey := randomEd25519Key(t)
+	maker, err := NewPasetoPublicMaker(signingKey, nil)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 4)
+
+	footer, err := base64.RawURLEncoding.DecodeString(parts[3])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"kid":"`+KeyID(signingKey.Public().(ed25519.PublicKey))+`"}`, string(footer))
+}
+
+func TestPasetoPublicMakerKeyRotation(t *testing.T) {
+	oldKey := randomEd25519Key(t)
+	newKey := randomEd25519Key(t)
+
+	oldMaker, err := NewPasetoPublicMaker(oldKey, nil)
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// the new maker signs with the new key and still accepts the old one
+	newMaker, err := NewPasetoPublicMaker(newKey, []ed25519.PublicKey{oldKey.Public().(ed25519.PublicKey)})
+	require.NoError(t, err)
+
+	payload, err := newMaker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.NotNil(t, payload)
+
+	newToken, _, err := newMaker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	// a service that only knows the old key can't verify tokens signed with the new one
+	payload, err = oldMaker.VerifyToken(newToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+
+	// once the old key is retired, its tokens are rejected
+	retiredMaker, err := NewPasetoPublicMaker(newKey, nil)
+	require.NoError(t, err)
+	payload, err = retiredMaker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestExpiredInvalidPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker(randomEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+	require.NoError(t, err)
+
+	// Expired
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrExpiredToken.Error())
+	require.Nil(t, payload)
+
+	validToken, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, time.Minute)
+	require.NoError(t, err)
+	parts := strings.Split(validToken, ".")
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	tampered := append([]byte{}, body...)
+	tampered[0] ^= 1
+
+	otherFooter := base64.RawURLEncoding.EncodeToString([]byte(`{"kid":"unknown"}`))
+
+	invalidTokens := map[string]string{
+		"Random":        util.RandomString(32),
+		"LocalHeader":   "v4.local." + parts[2] + "." + parts[3],
+		"NoFooter":      "v4.public." + parts[2],
+		"TamperedBody":  "v4.public." + base64.RawURLEncoding.EncodeToString(tampered) + "." + parts[3],
+		"UnknownKeyID":  "v4.public." + parts[2] + "." + otherFooter,
+		"ShortBody":     "v4.public." + base64.RawURLEncoding.EncodeToString(body[:10]) + "." + parts[3],
+		"InvalidBase64": "v4.public.!!!." + parts[3],
+	}
+
+	for name, invalidToken := range invalidTokens {
+		t.Run(name, func(t *testing.T) {
+			payload, err := maker.VerifyToken(invalidToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestPasetoPublicMakerInvalidKey(t *testing.T) {
+	_, err := NewPasetoPublicMaker(ed25519.PrivateKey(util.RandomString(32)), nil)
+	require.Error(t, err)
+
+	_, err = NewPasetoPublicMaker(randomEd25519Key(t), []ed25519.PublicKey{ed25519.PublicKey(util.RandomString(31))})
+	require.Error(t, err)
+}
+
+func TestPAE(t *testing.T) {
+	// examples of the PASETO specification
+	require.Equal(t, []byte("\x00\x00\x00\x00\x00\x00\x00\x00"), pae())
+	require.Equal(t, []byte("\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"), pae([]byte("")))
+	require.Equal(t, []byte("\x01\x00\x00\x00\x00\x00\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00test"), pae([]byte("test")))
+}
+
+func TestPasetoV4PublicVectors(t *testing.T) {
+	// test vectors 4-S-1 to 4-S-3 of the PASETO specification
+	seed, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774")
+	require.NoError(t, err)
+	secretKey := ed25519.NewKeyFromSeed(seed)
+	require.Equal(t, "b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a37741eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2", hex.EncodeToString(secretKey))
+
+	publicKey, err := hex.DecodeString("1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+	require.Equal(t, ed25519.PublicKey(publicKey), secretKey.Public())
+
+	payload := `{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`
+
+	testCases := []struct {
+		name     string
+		footer   string
+		implicit string
+		token    string
+	}{
+		{
+			name:  "4-S-1",
+			token: "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA",
+		},
+		{
+			name:   "4-S-2",
+			footer: `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`,
+			token:  "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9v3Jt8mx_TdM2ceTGoqwrh4yDFn0XsHvvV_D0DtwQxVrJEBMl0F2caAdgnpKlt4p7xBnx1HcO-SPo8FPp214HDw.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+		{
+			name:     "4-S-3",
+			footer:   `{"kid":"zVhMiPBP9fRf2snEcT7gFTioeA9COcNy9DfgL1W60haN"}`,
+			implicit: `{"test-vector":"4-S-3"}`,
+			token:    "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9NPWciuD3d0o5eXJXG5pJy-DiVEoyPYWs1YSTwWHNJq6DZD3je5gf-0M4JR9ipdUSJbIovzmBECeaWmaqcaP0DQ.eyJraWQiOiJ6VmhNaVBCUDlmUmYyc25FY1Q3Z0ZUaW9lQTlDT2NOeTlEZmdMMVc2MGhhTiJ9",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			// Ed25519 is deterministic, signing must give the exact token
+			token := signV4Public(secretKey, []byte(payload), []byte(tc.footer), []byte(tc.implicit))
+			require.Equal(t, tc.token, token)
+
+			body, footer, err := decodeV4Public(tc.token)
+			require.NoError(t, err)
+			require.Equal(t, tc.footer, string(footer))
+
+			message, err := verifyV4Public(publicKey, body, footer, []byte(tc.implicit))
+			require.NoError(t, err)
+			require.Equal(t, payload, string(message))
+
+			// the implicit assertion is signed but not part of the token
+			_, err = verifyV4Public(publicKey, body, footer, []byte(`{"test-vector":"other"}`))
+			require.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestLoadEd25519Keys(t *testing.T) {
+	dir := t.TempDir()
+	signingKey := randomEd25519Key(t)
+	oldKey := randomEd25519Key(t)
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(signingKey)
+	require.NoError(t, err)
+	privateKeyFile := filepath.Join(dir, "token_key.pem")
+	err = os.WriteFile(privateKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600)
+	require.NoError(t, err)
+
+	publicDER, err := x509.MarshalPKIXPublicKey(oldKey.Public())
+	require.NoError(t, err)
+	publicKeyFile := filepath.Join(dir, "token_key_old.pub.pem")
+	err = os.WriteFile(publicKeyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644)
+	require.NoError(t, err)
+
+	privateKey, publicKeys, err := LoadEd25519Keys(privateKeyFile, []string{publicKeyFile})
+	require.NoError(t, err)
+	require.Equal(t, signingKey, privateKey)
+	require.Len(t, publicKeys, 1)
+	require.Equal(t, oldKey.Public(), publicKeys[0])
+
+	// a verify only service reads the public keys alone
+	publicKeys, err = LoadEd25519PublicKeys([]string{publicKeyFile})
+	require.NoError(t, err)
+	require.Equal(t, []ed25519.PublicKey{oldKey.Public().(ed25519.PublicKey)}, publicKeys)
+
+	// the files are swapped
+	_, _, err = LoadEd25519Keys(publicKeyFile, []string{privateKeyFile})
+	require.Error(t, err)
+
+	_, _, err = LoadEd25519Keys(filepath.Join(dir, "missing.pem"), nil)
+	require.Error(t, err)
+}
@@ -8,8 +8,11 @@ import (
 )
 
 var (
-	ErrInvalidToken = errors.New("token is invalid")
-	ErrExpiredToken = errors.New("token has expired")
+	ErrInvalidToken     = errors.New("token is invalid")
+	ErrExpiredToken     = errors.New("token has expired")
+	ErrTokenNotYetValid = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("token issuer is not allowed")
+	ErrInvalidAudience  = errors.New("token audience is not allowed")
 )
 
 // Payload contains the payload data of the token
@@ -17,8 +20,13 @@ type Payload struct {
 	ID        uuid.UUID `json:"id"`
 	Username  string    `json:"username"`
 	Role      string    `json:"role"`
+	Issuer    string    `json:"issuer,omitempty"`
+	Audience  []string  `json:"audience,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
+	NotBefore time.Time `json:"not_before"`
 	ExpiredAt time.Time `json:"expired_at"`
+	// IssuedAtPrecision is what IssuedAt was truncated to by the token format, zero when it is kept in full
+	IssuedAtPrecision time.Duration `json:"-"`
 }
 
 // NewPayload creates a new token payload with a specific userrname and duration
@@ -28,22 +36,20 @@ func NewPayload(username string, role string, duration time.Duration) (*Payload,
 		return nil, err
 	}
 
+	now := time.Now()
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
 		Role:      role,
-		IssuedAt:  time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		IssuedAt:  now,
+		NotBefore: now,
+		ExpiredAt: now.Add(duration),
 	}
 
 	return payload, nil
 }
 
-// Valid checks if the token payload is valid or not
+// Valid checks if the token payload is valid or not, without clock skew tolerance nor issuer and audience checks
 func (payload *Payload) Valid() error {
-	if time.Now().After(payload.ExpiredAt) {
-		return ErrExpiredToken
-	}
-
-	return nil
+	return ClaimsConfig{}.Validate(payload)
 }
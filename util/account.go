@@ -0,0 +1,8 @@
+package util
+
+// constants for account status
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen" // kept by the bank, no money moves in or out
+	AccountStatusClosed = "closed" // closed with a zero balance, kept for its entries and transfers
+)
@@ -9,16 +9,42 @@ import (
 // config stores all configuration of the application.
 // the values are read by viper from a config file or env variables
 type Config struct {
-	DBDriver             string        `mapstructure:"DB_DRIVER"`
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	RedisAddress         string        `mapstructure:"REDIS_ADDRESS"`
-	ServerAddress        string        `mapstructure:"SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
-	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
-	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	DBDriver                 string        `mapstructure:"DB_DRIVER"`
+	DBSource                 string        `mapstructure:"DB_SOURCE"`
+	RedisAddress             string        `mapstructure:"REDIS_ADDRESS"`
+	ServerAddress            string        `mapstructure:"SERVER_ADDRESS"`
+	TrustedProxies           []string      `mapstructure:"TRUSTED_PROXIES"` // comma separated IPs or CIDRs allowed to set X-Forwarded-For, none when empty
+	TokenType                string        `mapstructure:"TOKEN_TYPE"`      // paseto, paseto_public, jwt_hs256, jwt_rs256 or jwt_eddsa
+	TokenSymmetricKey        string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenPrivateKeyFile      string        `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`     // PEM key signing the token types that use a key pair
+	TokenPublicKeyFiles      []string      `mapstructure:"TOKEN_PUBLIC_KEY_FILES"`     // comma separated PEM files of keys still accepted after a rotation
+	TokenIssuer              string        `mapstructure:"TOKEN_ISSUER"`               // written in new tokens, e.g. simplebank-production
+	TokenAudience            []string      `mapstructure:"TOKEN_AUDIENCE"`             // comma separated audiences written in new tokens
+	TokenAllowedIssuers      []string      `mapstructure:"TOKEN_ALLOWED_ISSUERS"`      // issuers accepted when verifying, TOKEN_ISSUER alone when empty
+	TokenAllowedAudiences    []string      `mapstructure:"TOKEN_ALLOWED_AUDIENCES"`    // audiences accepted when verifying, TOKEN_AUDIENCE when empty
+	TokenClockSkew           time.Duration `mapstructure:"TOKEN_CLOCK_SKEW"`           // tolerance on the token times, 30s when empty
+	TokenRevocationBackend   string        `mapstructure:"TOKEN_REVOCATION_BACKEND"`   // postgres or memory, postgres when empty
+	TokenRevocationCacheTTL  time.Duration `mapstructure:"TOKEN_REVOCATION_CACHE_TTL"` // how long revocations read from the backend are trusted
+	AccessTokenDuration      time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	MfaTokenDuration         time.Duration `mapstructure:"MFA_TOKEN_DURATION"`  // how long a login waits for its second factor
+	TotpEncryptionKey        string        `mapstructure:"TOTP_ENCRYPTION_KEY"` // 32 characters AES key encrypting the TOTP secrets
+	RefreshTokenDuration     time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	LoginLockoutBackend      string        `mapstructure:"LOGIN_LOCKOUT_BACKEND"`       // postgres or memory, postgres when empty
+	LoginMaxUsernameFailures int           `mapstructure:"LOGIN_MAX_USERNAME_FAILURES"` // failed logins locking a username out, 5 when empty
+	LoginMaxIPFailures       int           `mapstructure:"LOGIN_MAX_IP_FAILURES"`       // failed logins locking a client IP out, 20 when empty
+	LoginBackoffBase         time.Duration `mapstructure:"LOGIN_BACKOFF_BASE"`          // wait after a first failed login, doubled after each next one
+	LoginLockoutDuration     time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`      // length of a lockout, 15m when empty
+	IdempotencyKeyTTL        time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`         // how long a transfer replays its response to a retry with the same key, 24h when empty
+	FxQuoteDuration          time.Duration `mapstructure:"FX_QUOTE_DURATION"`           // how long an fx quote locks its rate, 30s when empty
+	VerifyEmailCooldown      time.Duration `mapstructure:"VERIFY_EMAIL_COOLDOWN"`       // wait between two verification emails resent to a user, 1m when empty
+	PasswordResetCooldown    time.Duration `mapstructure:"PASSWORD_RESET_COOLDOWN"`     // wait between two password reset codes sent to a user, 1m when empty
+	OutboxRelayInterval      time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`       // how often pending tasks are moved from the outbox to Redis, 1s when empty
+	OutboxRetention          time.Duration `mapstructure:"OUTBOX_RETENTION"`            // how long sent tasks are kept in the outbox, 168h when empty
+	CurrenciesFile           string        `mapstructure:"CURRENCIES_FILE"`             // replaces the built-in currency registry when set
+	RolesFile                string        `mapstructure:"ROLES_FILE"`                  // replaces the built-in role permissions when set
+	EmailSenderName          string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress       string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	EmailSenderPassword      string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
 }
 
 // localconfig reads configuration from file or environment
@@ -29,6 +55,11 @@ func LoadConfig(path string) (config Config, err error) {
 
 	viper.AutomaticEnv()
 
+	viper.SetDefault("IDEMPOTENCY_KEY_TTL", 24*time.Hour)
+	viper.SetDefault("FX_QUOTE_DURATION", 30*time.Second)
+	viper.SetDefault("VERIFY_EMAIL_COOLDOWN", time.Minute)
+	viper.SetDefault("PASSWORD_RESET_COOLDOWN", time.Minute)
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
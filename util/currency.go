@@ -1,26 +1,153 @@
 package util
 
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+)
+
 // constants for currency
 const (
 	USD = "USD"
 	CAD = "CAD"
 	EUR = "EUR"
+	JPY = "JPY"
+	BHD = "BHD"
+)
+
+// maxMinorUnits is the largest exponent ISO 4217 uses
+const maxMinorUnits = 4
+
+// Currency is an ISO 4217 currency of the registry
+type Currency struct {
+	Code       string `json:"code"`
+	MinorUnits int    `json:"minor_units"` // number of decimal places, amounts are stored in these minor units
+	Enabled    bool   `json:"enabled"`     // only enabled currencies pass the currency validator
+}
+
+// defaultCurrencies is the registry used until LoadCurrencies replaces it
+//
+//go:embed currencies.json
+var defaultCurrencies []byte
+
+var currencyCodeRegexp = regexp.MustCompile(`^[A-Z]{3}$`)
+
+var (
+	currencyMutex sync.RWMutex
+	currencies    []Currency
+	currencyIndex map[string]Currency
 )
 
+func init() {
+	if err := parseCurrencies(defaultCurrencies); err != nil {
+		panic(fmt.Sprintf("invalid default currencies: %v", err))
+	}
+}
+
+// LoadCurrencies replaces the currency registry with the currencies of a JSON file
+func LoadCurrencies(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read currencies file: %w", err)
+	}
+
+	return parseCurrencies(data)
+}
+
+// SetCurrencies replaces the currency registry
+func SetCurrencies(list []Currency) error {
+	index := make(map[string]Currency, len(list))
+	for _, currency := range list {
+		if !currencyCodeRegexp.MatchString(currency.Code) {
+			return fmt.Errorf("invalid currency code %q", currency.Code)
+		}
+		if currency.MinorUnits < 0 || currency.MinorUnits > maxMinorUnits {
+			return fmt.Errorf("invalid minor units %d for currency %s", currency.MinorUnits, currency.Code)
+		}
+		if _, ok := index[currency.Code]; ok {
+			return fmt.Errorf("duplicate currency %s", currency.Code)
+		}
+		index[currency.Code] = currency
+	}
+
+	currencyMutex.Lock()
+	defer currencyMutex.Unlock()
+
+	currencies = append([]Currency(nil), list...)
+	currencyIndex = index
+	return nil
+}
+
+func parseCurrencies(data []byte) error {
+	var list []Currency
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("cannot parse currencies: %w", err)
+	}
+
+	return SetCurrencies(list)
+}
+
+// GetCurrency returns a currency of the registry, enabled or not
+func GetCurrency(code string) (Currency, bool) {
+	currencyMutex.RLock()
+	defer currencyMutex.RUnlock()
+
+	currency, ok := currencyIndex[code]
+	return currency, ok
+}
+
+// SupportedCurrencies returns the codes of the enabled currencies, in registry order
+func SupportedCurrencies() []string {
+	currencyMutex.RLock()
+	defer currencyMutex.RUnlock()
+
+	codes := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		if currency.Enabled {
+			codes = append(codes, currency.Code)
+		}
+	}
+	return codes
+}
+
 // IsSupportedCurrency returns true if the currency is supported
 func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
+	c, ok := GetCurrency(currency)
+	return ok && c.Enabled
+}
+
+// MinorUnits returns the number of decimal places of a currency, 0 for unknown currencies
+func MinorUnits(currency string) int {
+	c, _ := GetCurrency(currency)
+	return c.MinorUnits
+}
+
+// FormatAmount formats an amount in minor units as a decimal string of the currency, 1234 USD is "12.34"
+func FormatAmount(amount int64, currency string) string {
+	digits := MinorUnits(currency)
+	if digits == 0 {
+		return fmt.Sprintf("%d", amount)
+	}
+
+	sign := ""
+	// the absolute value is computed unsigned so that math.MinInt64 does not overflow
+	abs := uint64(amount)
+	if amount < 0 {
+		sign = "-"
+		abs = -abs
 	}
-	return false
+
+	unit := uint64(pow10(digits))
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/unit, digits, abs%unit)
 }
 
-// IsSupportedCurrency returns true if the currency is supported
-func IsSupportedRole(role string) bool {
-	switch role {
-	case BankerRole, DepositorRole:
-		return true
+func pow10(n int) int64 {
+	result := int64(1)
+	for i := 0; i < n; i++ {
+		result *= 10
 	}
-	return false
+	return result
 }
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultCurrencies(t *testing.T) {
+	for _, code := range []string{USD, EUR, CAD, JPY, BHD} {
+		require.True(t, IsSupportedCurrency(code), code)
+	}
+
+	// known but disabled
+	currency, ok := GetCurrency("GBP")
+	require.True(t, ok)
+	require.False(t, currency.Enabled)
+	require.False(t, IsSupportedCurrency("GBP"))
+
+	require.False(t, IsSupportedCurrency("XYZ"))
+	require.NotContains(t, SupportedCurrencies(), "GBP")
+
+	require.Equal(t, 2, MinorUnits(USD))
+	require.Equal(t, 0, MinorUnits(JPY))
+	require.Equal(t, 3, MinorUnits(BHD))
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "0.00", FormatAmount(0, USD))
+	require.Equal(t, "0.05", FormatAmount(5, USD))
+	require.Equal(t, "12.34", FormatAmount(1234, EUR))
+	require.Equal(t, "-12.34", FormatAmount(-1234, CAD))
+	require.Equal(t, "-0.99", FormatAmount(-99, USD))
+	require.Equal(t, "1234", FormatAmount(1234, JPY))
+	require.Equal(t, "-1234", FormatAmount(-1234, JPY))
+	require.Equal(t, "1.234", FormatAmount(1234, BHD))
+	require.Equal(t, "0.005", FormatAmount(5, BHD))
+	require.Equal(t, "-92233720368547758.08", FormatAmount(math.MinInt64, USD))
+}
+
+func TestLoadCurrencies(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, parseCurrencies(defaultCurrencies))
+	})
+
+	path := filepath.Join(t.TempDir(), "currencies.json")
+	err := os.WriteFile(path, []byte(`[{"code": "CHF", "minor_units": 2, "enabled": true}]`), 0644)
+	require.NoError(t, err)
+
+	err = LoadCurrencies(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"CHF"}, SupportedCurrencies())
+	require.False(t, IsSupportedCurrency(USD))
+	require.Equal(t, "CHF", RandomCurrency())
+
+	err = LoadCurrencies(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestSetCurrenciesInvalid(t *testing.T) {
+	testCases := []struct {
+		name       string
+		currencies []Currency
+	}{
+		{name: "LowercaseCode", currencies: []Currency{{Code: "usd", MinorUnits: 2, Enabled: true}}},
+		{name: "LongCode", currencies: []Currency{{Code: "USDT", MinorUnits: 2, Enabled: true}}},
+		{name: "NegativeMinorUnits", currencies: []Currency{{Code: "USD", MinorUnits: -1, Enabled: true}}},
+		{name: "TooManyMinorUnits", currencies: []Currency{{Code: "USD", MinorUnits: 5, Enabled: true}}},
+		{name: "Duplicate", currencies: []Currency{{Code: "USD", MinorUnits: 2}, {Code: "USD", MinorUnits: 2}}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := SetCurrencies(tc.currencies)
+			require.Error(t, err)
+
+			// the registry is left untouched
+			require.True(t, IsSupportedCurrency(USD))
+		})
+	}
+}
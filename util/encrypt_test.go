@@ -0,0 +1,28 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptString(t *testing.T) {
+	key := RandomString(EncryptionKeySize)
+	plaintext := RandomString(32)
+
+	encrypted1, err := EncryptString(key, plaintext)
+	require.NoError(t, err)
+	encrypted2, err := EncryptString(key, plaintext)
+	require.NoError(t, err)
+	require.NotEqual(t, encrypted1, encrypted2)
+
+	decrypted, err := DecryptString(key, encrypted1)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	_, err = DecryptString(RandomString(EncryptionKeySize), encrypted1)
+	require.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = EncryptString(RandomString(16), plaintext)
+	require.Error(t, err)
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// FxRateScale is the fixed point scale of exchange rates, a rate of 1.25 is stored as 125000000
+const FxRateScale int64 = 100_000_000
+
+// ErrConvertedAmountTooSmall is returned when an amount converts to less than one minor unit of the other currency
+var ErrConvertedAmountTooSmall = errors.New("converted amount is less than one minor unit")
+
+// ConvertAmount converts an amount in minor units of fromCurrency into minor units of toCurrency.
+// The rate is the scaled price of one fromCurrency unit in toCurrency units, the result is rounded down
+// and ErrConvertedAmountTooSmall is returned when nothing is left.
+func ConvertAmount(amount int64, rate int64, fromCurrency string, toCurrency string) (int64, error) {
+	if rate <= 0 {
+		return 0, fmt.Errorf("invalid exchange rate %d", rate)
+	}
+
+	// the product can overflow int64 before it is scaled back down
+	converted := new(big.Int).Mul(big.NewInt(amount), big.NewInt(rate))
+	converted.Mul(converted, big.NewInt(pow10(MinorUnits(toCurrency))))
+	converted.Quo(converted, big.NewInt(FxRateScale*pow10(MinorUnits(fromCurrency))))
+	if !converted.IsInt64() {
+		return 0, fmt.Errorf("converted amount of %d at rate %d is too large", amount, rate)
+	}
+	if converted.Sign() <= 0 {
+		return 0, ErrConvertedAmountTooSmall
+	}
+
+	return converted.Int64(), nil
+}
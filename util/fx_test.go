@@ -0,0 +1,49 @@
+package util
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertAmount(t *testing.T) {
+	converted, err := ConvertAmount(1000, FxRateScale, USD, CAD)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), converted)
+
+	// 1.25
+	converted, err = ConvertAmount(1000, 125_000_000, USD, CAD)
+	require.NoError(t, err)
+	require.Equal(t, int64(1250), converted)
+
+	// 0.33333333 rounds down
+	converted, err = ConvertAmount(100, 33_333_333, USD, EUR)
+	require.NoError(t, err)
+	require.Equal(t, int64(33), converted)
+
+	// 1.00 USD at 150 JPY has no decimals on the JPY side
+	converted, err = ConvertAmount(100, 150*FxRateScale, USD, JPY)
+	require.NoError(t, err)
+	require.Equal(t, int64(150), converted)
+
+	// 1.000 BHD at 2.65 USD
+	converted, err = ConvertAmount(1000, 265_000_000, BHD, USD)
+	require.NoError(t, err)
+	require.Equal(t, int64(265), converted)
+
+	// the intermediate product overflows int64 but the result does not
+	converted, err = ConvertAmount(math.MaxInt64/10, FxRateScale, USD, EUR)
+	require.NoError(t, err)
+	require.Equal(t, int64(math.MaxInt64/10), converted)
+
+	_, err = ConvertAmount(math.MaxInt64, 2*FxRateScale, USD, EUR)
+	require.Error(t, err)
+
+	_, err = ConvertAmount(1000, 0, USD, EUR)
+	require.Error(t, err)
+
+	// 1 JPY at 0.0067 USD is less than a cent
+	_, err = ConvertAmount(1, 670_000, JPY, USD)
+	require.ErrorIs(t, err, ErrConvertedAmountTooSmall)
+}
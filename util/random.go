@@ -41,9 +41,9 @@ func RandomMoney() int64 {
 	return RandomInt(0, 1000)
 }
 
-// randomCurrency generates a random currency code
+// randomCurrency generates a random currency code among the enabled currencies of the registry
 func RandomCurrency() string {
-	currencies := []string{EUR, USD, CAD}
+	currencies := SupportedCurrencies()
 	n := len(currencies)
 	return currencies[rand.Intn(n)]
 }
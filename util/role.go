@@ -1,6 +1,169 @@
 package util
 
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// constants for role
 const (
 	DepositorRole = "depositor"
 	BankerRole    = "banker"
+	AuditorRole   = "auditor" // reads everything, changes nothing
+	AdminRole     = "admin"
+)
+
+// constants for the permission scopes: own covers the resources of the user, any covers the ones of every user
+const (
+	ScopeOwn = "own"
+	ScopeAny = "any"
+)
+
+// permissions are resource:action:scope, a role granted the any scope also has the own scope
+const (
+	PermAccountsCreateOwn   = "accounts:create:own"
+	PermAccountsReadOwn     = "accounts:read:own"
+	PermAccountsReadAny     = "accounts:read:any"
+	PermAccountsUpdateAny   = "accounts:update:any"
+	PermAccountsFreezeAny   = "accounts:freeze:any"
+	PermAccountsCloseOwn    = "accounts:close:own"
+	PermAccountsCloseAny    = "accounts:close:any"
+	PermAccountsReopenAny   = "accounts:reopen:any"
+	PermEntriesCreateAny    = "entries:create:any"
+	PermTransfersCreateOwn  = "transfers:create:own"
+	PermTransfersReadOwn    = "transfers:read:own"
+	PermTransfersReadAny    = "transfers:read:any"
+	PermStatementsReadOwn   = "statements:read:own"
+	PermStatementsReadAny   = "statements:read:any"
+	PermUsersReadAny        = "users:read:any"
+	PermUsersUpdateOwn      = "users:update:own"
+	PermUsersUpdateAny      = "users:update:any"
+	PermUsersChangeRoleAny  = "users:change_role:any"
+	PermAdminsChangeRoleAny = "admins:change_role:any" // needed on top of the users permissions to give or take the admin role, or to update or end the sessions of an admin
+	PermFxRatesReadAny      = "fx_rates:read:any"
+	PermFxRatesUpdateAny    = "fx_rates:update:any"
+	PermFxRatesDeleteAny    = "fx_rates:delete:any"
+	PermFxQuotesCreateOwn   = "fx_quotes:create:own"
+	PermSessionsDeleteAny   = "sessions:delete:any"
+	PermLockoutsReadAny     = "lockouts:read:any"
+	PermLockoutsDeleteAny   = "lockouts:delete:any"
+	PermBackOfficeAccessAny = "back_office:access:any" // needed by every /admin route
+	PermAuditEventsReadAny  = "audit_events:read:any"
 )
+
+// defaultRoles is the role policy used until LoadRoles replaces it
+//
+//go:embed roles.json
+var defaultRoles []byte
+
+// a granted permission may use * for its resource or action, e.g. *:read:any
+var grantRegexp = regexp.MustCompile(`^([a-z_]+|\*):([a-z_]+|\*):(own|any)$`)
+
+var (
+	roleMutex sync.RWMutex
+	roles     map[string][]string
+)
+
+func init() {
+	if err := parseRoles(defaultRoles); err != nil {
+		panic(fmt.Sprintf("invalid default roles: %v", err))
+	}
+}
+
+// LoadRoles replaces the role policy with the roles and permissions of a JSON file
+func LoadRoles(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read roles file: %w", err)
+	}
+
+	return parseRoles(data)
+}
+
+// SetRoles replaces the role policy, each role is given the list of its granted permissions
+func SetRoles(policy map[string][]string) error {
+	copied := make(map[string][]string, len(policy))
+	for role, grants := range policy {
+		if role == "" {
+			return fmt.Errorf("empty role name")
+		}
+		for _, grant := range grants {
+			if !grantRegexp.MatchString(grant) {
+				return fmt.Errorf("invalid permission %q of role %s", grant, role)
+			}
+		}
+		copied[role] = append([]string(nil), grants...)
+	}
+
+	roleMutex.Lock()
+	defer roleMutex.Unlock()
+
+	roles = copied
+	return nil
+}
+
+func parseRoles(data []byte) error {
+	var policy map[string][]string
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return fmt.Errorf("cannot parse roles: %w", err)
+	}
+
+	return SetRoles(policy)
+}
+
+// SupportedRoles returns the roles of the policy, sorted by name
+func SupportedRoles() []string {
+	roleMutex.RLock()
+	defer roleMutex.RUnlock()
+
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSupportedRole returns true if the role is in the policy
+func IsSupportedRole(role string) bool {
+	roleMutex.RLock()
+	defer roleMutex.RUnlock()
+
+	_, ok := roles[role]
+	return ok
+}
+
+// HasPermission returns true if one of the permissions granted to the role covers the permission
+func HasPermission(role string, permission string) bool {
+	roleMutex.RLock()
+	defer roleMutex.RUnlock()
+
+	for _, grant := range roles[role] {
+		if grantCovers(grant, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantCovers matches a permission against a granted one, part by part
+func grantCovers(grant string, permission string) bool {
+	granted := strings.Split(grant, ":")
+	wanted := strings.Split(permission, ":")
+	if len(granted) != 3 || len(wanted) != 3 {
+		return false
+	}
+
+	for i := 0; i < 2; i++ {
+		if granted[i] != "*" && granted[i] != wanted[i] {
+			return false
+		}
+	}
+	return granted[2] == wanted[2] || (granted[2] == ScopeAny && wanted[2] == ScopeOwn)
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultRoles(t *testing.T) {
+	require.Equal(t, []string{AdminRole, AuditorRole, BankerRole, DepositorRole}, SupportedRoles())
+	require.False(t, IsSupportedRole("root"))
+
+	testCases := []struct {
+		role       string
+		permission string
+		granted    bool
+	}{
+		{role: DepositorRole, permission: PermAccountsReadOwn, granted: true},
+		{role: DepositorRole, permission: PermAccountsReadAny, granted: false},
+		{role: DepositorRole, permission: PermTransfersCreateOwn, granted: true},
+		{role: DepositorRole, permission: PermEntriesCreateAny, granted: false},
+		{role: DepositorRole, permission: PermAccountsCloseOwn, granted: true},
+		{role: DepositorRole, permission: PermAccountsCloseAny, granted: false},
+		{role: BankerRole, permission: PermAccountsCloseOwn, granted: true},
+		{role: BankerRole, permission: PermAccountsReopenAny, granted: true},
+		{role: BankerRole, permission: PermAccountsReadOwn, granted: true},
+		{role: BankerRole, permission: PermAccountsReadAny, granted: true},
+		{role: BankerRole, permission: PermTransfersCreateOwn, granted: false},
+		{role: AuditorRole, permission: PermTransfersReadOwn, granted: true},
+		{role: AuditorRole, permission: PermStatementsReadAny, granted: true},
+		{role: AuditorRole, permission: PermFxRatesUpdateAny, granted: false},
+		{role: AuditorRole, permission: PermUsersUpdateOwn, granted: false},
+		{role: AuditorRole, permission: PermAuditEventsReadAny, granted: true},
+		{role: BankerRole, permission: PermAuditEventsReadAny, granted: false},
+		{role: AdminRole, permission: PermLockoutsDeleteAny, granted: true},
+		{role: AdminRole, permission: "anything:else:own", granted: true},
+		{role: "root", permission: PermAccountsReadOwn, granted: false},
+		{role: AdminRole, permission: "accounts:read", granted: false},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.granted, HasPermission(tc.role, tc.permission), "%s %s", tc.role, tc.permission)
+	}
+}
+
+func TestLoadRoles(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, parseRoles(defaultRoles))
+	})
+
+	path := filepath.Join(t.TempDir(), "roles.json")
+	err := os.WriteFile(path, []byte(`{"teller": ["accounts:read:any", "entries:*:any"]}`), 0644)
+	require.NoError(t, err)
+
+	err = LoadRoles(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"teller"}, SupportedRoles())
+	require.False(t, IsSupportedRole(BankerRole))
+	require.True(t, HasPermission("teller", PermEntriesCreateAny))
+	require.False(t, HasPermission("teller", PermAccountsUpdateAny))
+
+	err = LoadRoles(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestSetRolesInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy map[string][]string
+	}{
+		{name: "EmptyRole", policy: map[string][]string{"": {PermAccountsReadOwn}}},
+		{name: "MissingScope", policy: map[string][]string{BankerRole: {"accounts:read"}}},
+		{name: "UnknownScope", policy: map[string][]string{BankerRole: {"accounts:read:all"}}},
+		{name: "WildcardScope", policy: map[string][]string{BankerRole: {"accounts:read:*"}}},
+		{name: "Uppercase", policy: map[string][]string{BankerRole: {"Accounts:read:any"}}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := SetRoles(tc.policy)
+			require.Error(t, err)
+			require.True(t, IsSupportedRole(BankerRole))
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TOTP parameters of RFC 6238, the defaults every authenticator app understands
+const (
+	TOTPDigits = 6
+	TOTPPeriod = 30 * time.Second
+
+	// totpSecretSize is the size in bytes of a secret, the HMAC-SHA1 block RFC 4226 recommends
+	totpSecretSize = 20
+	// totpSkewSteps is how many periods a code may be early or late, for clocks drifting apart
+	totpSkewSteps = 1
+)
+
+// recoveryCodeAlphabet has no letter that looks like another one
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random secret, base32 encoded as authenticator apps expect it
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPStep returns the number of the period t falls in
+func TOTPStep(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// TOTPCode returns the code of the secret for the given period
+func TOTPCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid totp secret: %w", err)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, value%mod), nil
+}
+
+// ValidateTOTP checks the code against the periods around t.
+// It returns the period the code belongs to, so that the caller can refuse to accept it twice.
+func ValidateTOTP(secret string, code string, t time.Time) (int64, bool) {
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := TOTPStep(t)
+	for step := current - totpSkewSteps; step <= current+totpSkewSteps; step++ {
+		expected, err := TOTPCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI authenticator apps read from a QR code
+func TOTPProvisioningURI(issuer string, accountName string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(TOTPDigits))
+	query.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+	return u.String()
+}
+
+// GenerateRecoveryCodes returns n random one-time recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	for i := range codes {
+		code, err := randomCode(10)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// GenerateSecretCode returns a random code of n letters and digits to send by email, such as a password reset code.
+// Unlike RandomString it reads crypto/rand, so a code can't be guessed from the time it was made.
+func GenerateSecretCode(n int) (string, error) {
+	code, err := randomCode(n)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate secret code: %w", err)
+	}
+	return code, nil
+}
+
+// randomCode returns n characters of recoveryCodeAlphabet read from crypto/rand
+func randomCode(n int) (string, error) {
+	var sb strings.Builder
+	var b [1]byte
+	for sb.Len() < n {
+		if _, err := rand.Read(b[:]); err != nil {
+			return "", err
+		}
+		// skip the bytes that would make the first letters more likely
+		if int(b[0]) >= 256-256%len(recoveryCodeAlphabet) {
+			continue
+		}
+		sb.WriteByte(recoveryCodeAlphabet[int(b[0])%len(recoveryCodeAlphabet)])
+	}
+	return sb.String(), nil
+}
+
+// HashRecoveryCode returns the SHA-256 hash of a recovery code.
+// Codes are random enough for a fast hash, which lets the database look them up.
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
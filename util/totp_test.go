@@ -0,0 +1,104 @@
+package util
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890" base32 encoded
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCodeVectors(t *testing.T) {
+	// the last 6 digits of the 8-digit codes of RFC 6238 appendix B
+	vectors := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range vectors {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, expected, code, unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := TOTPCode(secret, TOTPStep(now))
+	require.NoError(t, err)
+
+	step, ok := ValidateTOTP(secret, code, now)
+	require.True(t, ok)
+	require.Equal(t, TOTPStep(now), step)
+
+	// one period of clock drift is tolerated
+	_, ok = ValidateTOTP(secret, code, now.Add(TOTPPeriod))
+	require.True(t, ok)
+
+	_, ok = ValidateTOTP(secret, code, now.Add(3*TOTPPeriod))
+	require.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now)
+	require.False(t, ok)
+
+	otherSecret, err := GenerateTOTPSecret()
+	require.NoError(t, err)
+	_, ok = ValidateTOTP(otherSecret, code, now)
+	require.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Simple Bank", "johndoe", rfc6238Secret)
+
+	u, err := url.Parse(uri)
+	require.NoError(t, err)
+	require.Equal(t, "otpauth", u.Scheme)
+	require.Equal(t, "totp", u.Host)
+	require.Equal(t, "/Simple Bank:johndoe", u.Path)
+	require.Equal(t, rfc6238Secret, u.Query().Get("secret"))
+	require.Equal(t, "Simple Bank", u.Query().Get("issuer"))
+	require.Equal(t, "6", u.Query().Get("digits"))
+	require.Equal(t, "30", u.Query().Get("period"))
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+	require.Len(t, codes, 10)
+
+	seen := make(map[string]bool)
+	for _, code := range codes {
+		require.Len(t, code, 11)
+		require.Equal(t, byte('-'), code[5])
+		require.False(t, seen[code])
+		seen[code] = true
+
+		// hashing ignores the case and surrounding spaces of what the user typed
+		require.Equal(t, HashRecoveryCode(code), HashRecoveryCode(" "+strings.ToUpper(code)+" "))
+	}
+}
+
+func TestGenerateSecretCode(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		code, err := GenerateSecretCode(32)
+		require.NoError(t, err)
+		require.Len(t, code, 32)
+		for _, c := range code {
+			require.Contains(t, recoveryCodeAlphabet, string(c))
+		}
+		require.False(t, seen[code])
+		seen[code] = true
+	}
+}
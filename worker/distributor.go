@@ -12,6 +12,16 @@ type TaskDistributor interface {
 		payload *PayloadSendVerifyEmail,
 		opts ...asynq.Option,
 	) error
+	DistributeTaskSendResetPassword(
+		ctx context.Context,
+		payload *PayloadSendResetPassword,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendEmailChanged(
+		ctx context.Context,
+		payload *PayloadSendEmailChanged,
+		opts ...asynq.Option,
+	) error
 }
 
 type RedisTaskDistributor struct {
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// IdempotencyKeyCleaner deletes the expired idempotency keys, a retry with one of them runs as a new request anyway
+type IdempotencyKeyCleaner struct {
+	store    db.Store
+	interval time.Duration
+}
+
+// NewIdempotencyKeyCleaner creates a cleaner deleting the expired idempotency keys every interval
+func NewIdempotencyKeyCleaner(store db.Store, interval time.Duration) *IdempotencyKeyCleaner {
+	return &IdempotencyKeyCleaner{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Start deletes the expired idempotency keys every interval until ctx is done
+func (cleaner *IdempotencyKeyCleaner) Start(ctx context.Context) {
+	log.Info().Dur("interval", cleaner.interval).Msg("start idempotency key cleaner")
+
+	ticker := time.NewTicker(cleaner.interval)
+	defer ticker.Stop()
+
+	for {
+		_, err := cleaner.DeleteExpired(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("delete expired idempotency keys failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("stop idempotency key cleaner")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeleteExpired deletes the expired idempotency keys and returns how many were deleted
+func (cleaner *IdempotencyKeyCleaner) DeleteExpired(ctx context.Context) (int64, error) {
+	deleted, err := cleaner.store.DeleteExpiredIdempotencyKeys(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("deleted expired idempotency keys")
+	}
+	return deleted, nil
+}
@@ -0,0 +1,33 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "simplebank/db/mock"
+	"simplebank/worker"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyKeyCleanerDeleteExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().DeleteExpiredIdempotencyKeys(gomock.Any()).Times(1).Return(int64(2), nil),
+		store.EXPECT().DeleteExpiredIdempotencyKeys(gomock.Any()).Times(1).Return(int64(0), errors.New("connection refused")),
+	)
+
+	cleaner := worker.NewIdempotencyKeyCleaner(store, time.Hour)
+	deleted, err := cleaner.DeleteExpired(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+
+	_, err = cleaner.DeleteExpired(context.Background())
+	require.Error(t, err)
+}
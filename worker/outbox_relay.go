@@ -0,0 +1,199 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	outboxBatchSize  = 100
+	outboxMaxBackoff = 10 * time.Minute
+	// outboxLease is how long a claimed task is hidden from the other relays, it is claimed again if it is neither sent nor failed by then
+	outboxLease = time.Minute
+	// outboxCleanupInterval is how often the sent tasks older than the retention are deleted
+	outboxCleanupInterval = time.Hour
+)
+
+// errUnpublishable is returned for an outbox task that no retry can publish, such as an unknown type or a broken payload
+var errUnpublishable = errors.New("task can't be published")
+
+// OutboxRelay publishes the tasks that database transactions wrote in the outbox table to the task distributor.
+// A task is marked sent only once it is enqueued, so it is delivered at least once.
+// Its outbox ID is its task ID: while the first copy is still queued or being retried, Redis drops a copy published again
+// by a relay that failed to mark it sent. Once the task has completed, asynq forgets the ID and a late copy runs again.
+// A task is marked failed, and never tried again, after max_retry failed attempts or when it can't be published at all.
+// Sent tasks are deleted once older than the retention, failed ones are kept to be looked into.
+type OutboxRelay struct {
+	store       db.Store
+	distributor TaskDistributor
+	interval    time.Duration
+	retention   time.Duration
+}
+
+// NewOutboxRelay creates a relay looking for pending tasks every interval and deleting the tasks sent longer than retention ago
+func NewOutboxRelay(store db.Store, distributor TaskDistributor, interval time.Duration, retention time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+		retention:   retention,
+	}
+}
+
+// NewOutboxMessage builds the outbox row of a task, to be written in the transaction of the change that needs it
+func NewOutboxMessage(taskType string, payload any, queue string, maxRetry int32) (db.CreateOutboxMessageParams, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return db.CreateOutboxMessageParams{}, fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	return db.CreateOutboxMessageParams{
+		TaskType: taskType,
+		Payload:  jsonPayload,
+		Queue:    queue,
+		MaxRetry: maxRetry,
+	}, nil
+}
+
+// Start relays the pending tasks every interval until ctx is done
+func (relay *OutboxRelay) Start(ctx context.Context) {
+	log.Info().Dur("interval", relay.interval).Msg("start outbox relay")
+
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		if time.Since(lastCleanup) >= outboxCleanupInterval {
+			_, err := relay.DeleteSent(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("delete sent outbox tasks failed")
+			}
+			lastCleanup = time.Now()
+		}
+
+		// a full batch means more tasks are waiting, they are relayed without waiting for the ticker
+		sent, err := relay.RelayPending(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("relay outbox failed")
+		}
+		if err == nil && sent == outboxBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("stop outbox relay")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayPending claims a batch of the pending tasks and publishes them, returning how many were sent.
+// Tasks claimed by another relay are skipped, so several relays never publish the same task at once.
+// A task that can't be published is tried again later, waiting longer after each failure, until it has used up its attempts.
+func (relay *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := relay.store.ClaimOutboxMessages(ctx, db.ClaimOutboxMessagesParams{
+		LeaseUntil: time.Now().Add(outboxLease),
+		BatchSize:  outboxBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, message := range messages {
+		err = relay.publish(ctx, message)
+		if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+			attempts := message.Attempts + 1
+			arg := db.MarkOutboxMessageFailedParams{
+				ID:          message.ID,
+				LastError:   pgtype.Text{String: err.Error(), Valid: true},
+				AvailableAt: time.Now().Add(outboxBackoff(attempts)),
+			}
+			if attempts > message.MaxRetry || errors.Is(err, errUnpublishable) {
+				arg.FailedAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
+			}
+
+			log.Error().Err(err).Int64("outbox_id", message.ID).Str("type", message.TaskType).
+				Int32("attempts", attempts).Bool("failed", arg.FailedAt.Valid).Msg("publish outbox task failed")
+
+			err = relay.store.MarkOutboxMessageFailed(ctx, arg)
+			if err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		err = relay.store.MarkOutboxMessageSent(ctx, message.ID)
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// DeleteSent deletes the tasks sent longer than the retention ago and returns how many were deleted
+func (relay *OutboxRelay) DeleteSent(ctx context.Context) (int64, error) {
+	deleted, err := relay.store.DeleteSentOutboxMessages(ctx, time.Now().Add(-relay.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		log.Info().Int64("deleted", deleted).Msg("deleted sent outbox tasks")
+	}
+	return deleted, nil
+}
+
+// publish hands an outbox task to the distributor of its type
+func (relay *OutboxRelay) publish(ctx context.Context, message db.Outbox) error {
+	opts := []asynq.Option{
+		asynq.Queue(message.Queue),
+		asynq.MaxRetry(int(message.MaxRetry)),
+		asynq.TaskID(fmt.Sprintf("outbox:%d", message.ID)),
+	}
+
+	switch message.TaskType {
+	case TaskSendVerifyEmail:
+		var payload PayloadSendVerifyEmail
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: failed to unmarshal payload: %w", errUnpublishable, err)
+		}
+		return relay.distributor.DistributeTaskSendVerifyEmail(ctx, &payload, opts...)
+	case TaskSendResetPassword:
+		var payload PayloadSendResetPassword
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: failed to unmarshal payload: %w", errUnpublishable, err)
+		}
+		return relay.distributor.DistributeTaskSendResetPassword(ctx, &payload, opts...)
+	case TaskSendEmailChanged:
+		var payload PayloadSendEmailChanged
+		if err := json.Unmarshal(message.Payload, &payload); err != nil {
+			return fmt.Errorf("%w: failed to unmarshal payload: %w", errUnpublishable, err)
+		}
+		return relay.distributor.DistributeTaskSendEmailChanged(ctx, &payload, opts...)
+	default:
+		return fmt.Errorf("%w: unknown task type %q", errUnpublishable, message.TaskType)
+	}
+}
+
+// outboxBackoff is how long a task waits after its nth failed attempt, doubling from a second up to outboxMaxBackoff
+func outboxBackoff(attempts int32) time.Duration {
+	backoff := time.Second
+	for i := int32(1); i < attempts && backoff < outboxMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, outboxMaxBackoff)
+}
@@ -0,0 +1,278 @@
+package worker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/worker"
+	mockwk "simplebank/worker/mock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+)
+
+func newVerifyEmailOutbox(t *testing.T, id int64, attempts int32) db.Outbox {
+	arg, err := worker.NewOutboxMessage(
+		worker.TaskSendVerifyEmail,
+		&worker.PayloadSendVerifyEmail{Username: "alice"},
+		worker.QueueCritical,
+		10,
+	)
+	require.NoError(t, err)
+
+	return db.Outbox{
+		ID:       id,
+		TaskType: arg.TaskType,
+		Payload:  arg.Payload,
+		Queue:    arg.Queue,
+		MaxRetry: arg.MaxRetry,
+		Attempts: attempts,
+	}
+}
+
+func TestOutboxRelayRelayPending(t *testing.T) {
+	testCases := []struct {
+		name       string
+		buildStubs func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor)
+		check      func(t *testing.T, sent int, err error)
+	}{
+		{
+			name: "Sent",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 1, 0)
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.ClaimOutboxMessagesParams) ([]db.Outbox, error) {
+						// the claimed tasks are hidden from the other relays while this one publishes them
+						require.Equal(t, int32(100), arg.BatchSize)
+						require.True(t, arg.LeaseUntil.After(time.Now()))
+						return []db.Outbox{message}, nil
+					})
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Eq(&worker.PayloadSendVerifyEmail{Username: "alice"}), gomock.Any()).
+					Times(1).
+					Return(nil)
+				store.EXPECT().
+					MarkOutboxMessageSent(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(nil)
+				store.EXPECT().MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, sent)
+			},
+		},
+		{
+			name: "AlreadyEnqueued",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 2, 0)
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Outbox{message}, nil)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(asynq.ErrTaskIDConflict)
+				store.EXPECT().
+					MarkOutboxMessageSent(gomock.Any(), gomock.Eq(message.ID)).
+					Times(1).
+					Return(nil)
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, sent)
+			},
+		},
+		{
+			name: "DistributeFailed",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 3, 2)
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Outbox{message}, nil)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("redis is down"))
+				store.EXPECT().
+					MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkOutboxMessageFailedParams) error {
+						require.Equal(t, message.ID, arg.ID)
+						require.Contains(t, arg.LastError.String, "redis is down")
+						// third failure waits 4 seconds
+						require.WithinDuration(t, time.Now().Add(4*time.Second), arg.AvailableAt, time.Second)
+						require.False(t, arg.FailedAt.Valid)
+						return nil
+					})
+				store.EXPECT().MarkOutboxMessageSent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, sent)
+			},
+		},
+		{
+			name: "BackoffCapped",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 4, 50)
+				message.MaxRetry = 100
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Outbox{message}, nil)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("redis is down"))
+				store.EXPECT().
+					MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkOutboxMessageFailedParams) error {
+						require.WithinDuration(t, time.Now().Add(10*time.Minute), arg.AvailableAt, time.Second)
+						return nil
+					})
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, sent)
+			},
+		},
+		{
+			name: "UnknownTaskType",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 5, 0)
+				message.TaskType = "task:unknown"
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Outbox{message}, nil)
+				store.EXPECT().
+					MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkOutboxMessageFailedParams) error {
+						require.Contains(t, arg.LastError.String, "unknown task type")
+						// no retry can publish it
+						require.True(t, arg.FailedAt.Valid)
+						return nil
+					})
+				store.EXPECT().MarkOutboxMessageSent(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, sent)
+			},
+		},
+		{
+			name: "AttemptsUsedUp",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 6, 10)
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Outbox{message}, nil)
+				distributor.EXPECT().
+					DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(errors.New("redis is down"))
+				store.EXPECT().
+					MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkOutboxMessageFailedParams) error {
+						require.Equal(t, message.ID, arg.ID)
+						require.True(t, arg.FailedAt.Valid)
+						return nil
+					})
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, sent)
+			},
+		},
+		{
+			name: "BadPayload",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				message := newVerifyEmailOutbox(t, 7, 0)
+				message.Payload = []byte("{")
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.Outbox{message}, nil)
+				distributor.EXPECT().DistributeTaskSendVerifyEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					MarkOutboxMessageFailed(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.MarkOutboxMessageFailedParams) error {
+						require.Contains(t, arg.LastError.String, "unmarshal payload")
+						require.True(t, arg.FailedAt.Valid)
+						return nil
+					})
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, sent)
+			},
+		},
+		{
+			name: "ClaimError",
+			buildStubs: func(t *testing.T, store *mockdb.MockStore, distributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().
+					ClaimOutboxMessages(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, errors.New("connection refused"))
+			},
+			check: func(t *testing.T, sent int, err error) {
+				require.Error(t, err)
+				require.Zero(t, sent)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			distributor := mockwk.NewMockTaskDistributor(ctrl)
+			tc.buildStubs(t, store, distributor)
+
+			relay := worker.NewOutboxRelay(store, distributor, time.Second, time.Hour)
+			sent, err := relay.RelayPending(context.Background())
+			tc.check(t, sent, err)
+		})
+	}
+}
+
+func TestOutboxRelayDeleteSent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	distributor := mockwk.NewMockTaskDistributor(ctrl)
+
+	store.EXPECT().
+		DeleteSentOutboxMessages(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, sentBefore time.Time) (int64, error) {
+			// only the tasks sent longer than the retention ago are deleted
+			require.WithinDuration(t, time.Now().Add(-time.Hour), sentBefore, time.Second)
+			return 3, nil
+		})
+
+	relay := worker.NewOutboxRelay(store, distributor, time.Second, time.Hour)
+	deleted, err := relay.DeleteSent(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), deleted)
+}
@@ -16,7 +16,10 @@ const (
 
 type TaskProcess interface {
 	Start() error
+	Shutdown()
 	ProcessTaskSendVerifyEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendResetPassword(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendEmailChanged(ctx context.Context, task *asynq.Task) error
 }
 
 type RedisTaskProcessor struct {
@@ -54,6 +57,13 @@ func (processor *RedisTaskProcessor) Start() error {
 	mux := asynq.NewServeMux()
 
 	mux.HandleFunc(TaskSendVerifyEmail, processor.ProcessTaskSendVerifyEmail)
+	mux.HandleFunc(TaskSendResetPassword, processor.ProcessTaskSendResetPassword)
+	mux.HandleFunc(TaskSendEmailChanged, processor.ProcessTaskSendEmailChanged)
 
 	return processor.server.Start(mux)
 }
+
+// Shutdown stops pulling new tasks and waits for the tasks being processed to finish, up to the shutdown timeout of asynq
+func (processor *RedisTaskProcessor) Shutdown() {
+	processor.server.Shutdown()
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const TaskSendEmailChanged = "task:send_email_changed"
+
+// PayloadSendEmailChanged warns the old address of a user about a request to change it
+type PayloadSendEmailChanged struct {
+	Username string `json:"username"`
+	OldEmail string `json:"old_email"`
+	NewEmail string `json:"new_email"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendEmailChanged(
+	ctx context.Context,
+	payload *PayloadSendEmailChanged,
+	opts ...asynq.Option,
+) error {
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendEmailChanged, jsonPayload, opts...)
+
+	// send this task to a Redis Queue
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task payload: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).
+		Int("max_retry", info.MaxRetry).
+		Msg("enqueue task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendEmailChanged(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendEmailChanged
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user doesnt exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// the notice goes to the old address, the one the owner of the account is known to read
+	subject := "Your SimpleBank email is being changed"
+	to := []string{payload.OldEmail}
+	content := fmt.Sprintf(`Hello %s, <br/> We received a request to change the email of your account to <b>%s</b>. <br/>
+	It takes effect once confirmed from the new address.<br/>
+	If you didn't ask for it, change your password and contact us.<br/>`,
+		user.FullName, payload.NewEmail)
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send email changed notice: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).
+		Bytes("payload", task.Payload()).Str("email", payload.OldEmail).Msg("processed task")
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+)
+
+const TaskSendResetPassword = "task:send_reset_password"
+
+// PayloadSendResetPassword names the password reset whose code is emailed, created in the transaction writing the task
+type PayloadSendResetPassword struct {
+	Username string `json:"username"`
+	ResetID  int64  `json:"reset_id"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendResetPassword(
+	ctx context.Context,
+	payload *PayloadSendResetPassword,
+	opts ...asynq.Option,
+) error {
+
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendResetPassword, jsonPayload, opts...)
+
+	// send this task to a Redis Queue
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task payload: %w", err)
+	}
+
+	log.Info().
+		Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).
+		Int("max_retry", info.MaxRetry).
+		Msg("enqueue task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendResetPassword(ctx context.Context, task *asynq.Task) error {
+	var payload PayloadSendResetPassword
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("user doesnt exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	passwordReset, err := processor.store.GetPasswordReset(ctx, payload.ResetID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return fmt.Errorf("password reset doesnt exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get password reset: %w", err)
+	}
+	if passwordReset.Username != user.Username {
+		return fmt.Errorf("password reset belongs to another user: %w", asynq.SkipRetry)
+	}
+
+	// a code that can't be used anymore isn't worth sending
+	if passwordReset.IsUsed || time.Now().After(passwordReset.ExpiredAt) {
+		log.Info().Str("type", task.Type()).
+			Bytes("payload", task.Payload()).Msg("skip expired password reset")
+		return nil
+	}
+
+	subject := "Reset your SimpleBank password"
+	to := []string{passwordReset.Email}
+	content := fmt.Sprintf(`Hello %s, <br/> We received a request to reset your password. <br/>
+	Use reset id <b>%d</b> and code <b>%s</b> to choose a new password. The code expires at %s.<br/>
+	If you didn't ask for it, you can ignore this email.<br/>`,
+		user.FullName, passwordReset.ID, passwordReset.SecretCode, passwordReset.ExpiredAt.Format("2006-01-02 15:04 MST"))
+
+	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("failed to send reset password email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).
+		Bytes("payload", task.Payload()).Str("email", passwordReset.Email).Msg("processed task")
+
+	return nil
+}
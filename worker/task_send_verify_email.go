@@ -7,8 +7,10 @@ import (
 	"fmt"
 	db "simplebank/db/sqlc"
 	"simplebank/util"
+	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/rs/zerolog/log"
 )
 
@@ -16,6 +18,7 @@ const TaskSendVerifyEmail = "task:send_verify_email"
 
 type PayloadSendVerifyEmail struct {
 	Username string `json:"username"`
+	Email    string `json:"email,omitempty"` // pending new email to verify, the current email of the user when empty
 }
 
 func (distributor *RedisTaskDistributor) DistributeTaskSendVerifyEmail(
@@ -62,22 +65,48 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Cont
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
-	// // send confirmation email to sender
-	// generate a verified email and save it to database
-	verifyEmail, err := processor.store.CreateVerifyEmail(ctx, db.CreateVerifyEmailParams{
-		Username:   user.Username,
-		Email:      user.Email,
-		SecretCode: util.RandomString(32),
+	// a new email is verified at its own address, as long as the user still wants it
+	email := user.Email
+	if payload.Email != "" {
+		if user.PendingEmail.String != payload.Email {
+			return fmt.Errorf("email change is no longer pending: %w", asynq.SkipRetry)
+		}
+		email = payload.Email
+	}
+
+	// only the code of the latest email can verify the address, the older ones expire with its creation.
+	// The relay delivers a task at least once, a task delivered again sends the code it created the first time.
+	taskID, _ := asynq.GetTaskID(ctx)
+	result, err := processor.store.CreateVerifyEmailTx(ctx, db.CreateVerifyEmailTxParams{
+		CreateVerifyEmailParams: db.CreateVerifyEmailParams{
+			Username:   user.Username,
+			Email:      email,
+			SecretCode: util.RandomString(32),
+			TaskID:     pgtype.Text{String: taskID, Valid: taskID != ""},
+		},
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create verify email: %w", err)
 	}
 
+	verifyEmail := result.VerifyEmail
+
+	// a code that can't be used anymore isn't worth sending again
+	if result.Reused && (verifyEmail.IsUsed || time.Now().After(verifyEmail.ExpiredAt)) {
+		log.Info().Str("type", task.Type()).
+			Bytes("payload", task.Payload()).Msg("skip expired verify email")
+		return nil
+	}
+
 	// initial config verification email
 	subject := "Welcome to SimpleBank"
 	verifyUrl := fmt.Sprintf("http://localhost:8080/verify_email?email_id=%d&secret_code=%s", verifyEmail.ID, verifyEmail.SecretCode)
-	to := []string{user.Email}
+	to := []string{email}
 	content := fmt.Sprintf(`Hello %s, <br/> Thank you for registering with us. <br/> Please <a href="%s"> Click here <a/> to verify your email address.<br/>`, user.FullName, verifyUrl)
+	if email != user.Email {
+		subject = "Confirm your new SimpleBank email"
+		content = fmt.Sprintf(`Hello %s, <br/> You asked to use this address for your SimpleBank account. <br/> Please <a href="%s"> Click here <a/> to confirm it. Until then, we keep writing to your previous address.<br/>`, user.FullName, verifyUrl)
+	}
 
 	// trigger send email function
 	err = processor.mailer.SendEmail(subject, content, to, nil, nil, nil)
@@ -87,7 +116,7 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerifyEmail(ctx context.Cont
 
 	// // conclude the task
 	log.Info().Str("type", task.Type()).
-		Bytes("payload", task.Payload()).Str("email", user.Email).Msg("processed task")
+		Bytes("payload", task.Payload()).Str("email", email).Msg("processed task")
 
 	return nil
 }